@@ -3,7 +3,11 @@ package client
 import (
 	"archive/tar"
 	"bufio"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
 	"encoding/base64"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -19,8 +23,12 @@ import (
 	"strconv"
 	"strings"
 
+	"github.com/Sirupsen/logrus"
 	"github.com/docker/docker/api"
+	"github.com/docker/docker/api/types"
 	Cli "github.com/docker/docker/cli"
+	"github.com/docker/docker/cliconfig"
+	"github.com/docker/docker/cliconfig/credentials"
 	"github.com/docker/docker/graph/tags"
 	"github.com/docker/docker/opts"
 	"github.com/docker/docker/pkg/archive"
@@ -31,6 +39,7 @@ import (
 	"github.com/docker/docker/pkg/parsers"
 	"github.com/docker/docker/pkg/progressreader"
 	"github.com/docker/docker/pkg/streamformatter"
+	"github.com/docker/docker/pkg/tarsum"
 	"github.com/docker/docker/pkg/ulimit"
 	"github.com/docker/docker/pkg/units"
 	"github.com/docker/docker/pkg/urlutil"
@@ -65,6 +74,10 @@ func (cli *DockerCli) CmdBuild(args ...string) error {
 	flCPUSetCpus := cmd.String([]string{"-cpuset-cpus"}, "", "CPUs in which to allow execution (0-3, 0,1)")
 	flCPUSetMems := cmd.String([]string{"-cpuset-mems"}, "", "MEMs in which to allow execution (0-3, 0,1)")
 	flCgroupParent := cmd.String([]string{"-cgroup-parent"}, "", "Optional parent cgroup for the container")
+	flProgress := cmd.String([]string{"-progress"}, "auto", "Set type of progress output (auto, json)")
+	flIncremental := cmd.Bool([]string{"-incremental"}, false, "Only send the changes to the context since the previous build of PATH")
+	flCheck := cmd.Bool([]string{"-check"}, false, "Check the Dockerfile for problems without building it")
+	flPlatform := cmd.String([]string{"-platform"}, "", "Build the image for a platform (os/arch[/variant]) other than the daemon's")
 	flBuildArg := opts.NewListOpts(opts.ValidateEnv)
 	cmd.Var(&flBuildArg, []string{"-build-arg"}, "Set build-time variables")
 
@@ -79,6 +92,15 @@ func (cli *DockerCli) CmdBuild(args ...string) error {
 
 	cmd.ParseFlags(args, true)
 
+	if *flProgress != "auto" && *flProgress != "json" {
+		return fmt.Errorf("Invalid progress output type: %s", *flProgress)
+	}
+	if *flPlatform != "" {
+		if _, _, _, err := parsers.ParsePlatform(*flPlatform); err != nil {
+			return err
+		}
+	}
+
 	var (
 		context  io.ReadCloser
 		isRemote bool
@@ -116,6 +138,11 @@ func (cli *DockerCli) CmdBuild(args ...string) error {
 		contextDir = tempDir
 	}
 
+	if *flCheck {
+		buildArgs := runconfig.ConvertKVStringsToMap(flBuildArg.GetAll())
+		return cli.checkDockerfile(filepath.Join(contextDir, relDockerfile), relDockerfile, buildArgs)
+	}
+
 	// Resolve the FROM lines in the Dockerfile to trusted digest references
 	// using Notary. On a successful build, we must tag the resolved digests
 	// to the original name specified in the Dockerfile.
@@ -155,11 +182,35 @@ func (cli *DockerCli) CmdBuild(args ...string) error {
 		includes = append(includes, ".dockerignore", relDockerfile)
 	}
 
-	context, err = archive.TarWithOptions(contextDir, &archive.TarOptions{
+	tarOptions := &archive.TarOptions{
 		Compression:     archive.Uncompressed,
 		ExcludePatterns: excludes,
 		IncludeFiles:    includes,
-	})
+	}
+
+	// An incremental build keeps the context in a session on the daemon, so
+	// that subsequent builds only need to send the files that changed. It is
+	// only possible for a local directory, as the session is identified by
+	// its path and the session key of the client.
+	var (
+		session     string
+		sessionSums map[string]string
+	)
+	if *flIncremental && tempDir == "" {
+		if session, err = buildSessionID(cliconfig.ConfigDir(), contextDir); err != nil {
+			logrus.Debugf("Sending full build context without a session: %v", err)
+		} else {
+			// Daemons which don't know the session, or don't support sessions
+			// at all, get the full context.
+			sessionSums = cli.getBuildSessionSums(session)
+		}
+	}
+
+	if sessionSums != nil {
+		context, err = getIncrementalContext(contextDir, tarOptions, relDockerfile, sessionSums)
+	} else {
+		context, err = archive.TarWithOptions(contextDir, tarOptions)
+	}
 	if err != nil {
 		return err
 	}
@@ -168,17 +219,21 @@ func (cli *DockerCli) CmdBuild(args ...string) error {
 	// Dockerfile which uses trusted pulls.
 	context = replaceDockerfileTarWrapper(context, newDockerfile, relDockerfile)
 
-	// Setup an upload progress bar
+	// Setup an upload progress bar, unless the raw json stream was
+	// requested, in which case the daemon reports the context size.
 	// FIXME: ProgressReader shouldn't be this annoying to use
-	sf := streamformatter.NewStreamFormatter()
-	var body io.Reader = progressreader.New(progressreader.Config{
-		In:        context,
-		Out:       cli.out,
-		Formatter: sf,
-		NewLines:  true,
-		ID:        "",
-		Action:    "Sending build context to Docker daemon",
-	})
+	var body io.Reader = context
+	if *flProgress != "json" {
+		sf := streamformatter.NewStreamFormatter()
+		body = progressreader.New(progressreader.Config{
+			In:        context,
+			Out:       cli.out,
+			Formatter: sf,
+			NewLines:  true,
+			ID:        "",
+			Action:    "Sending build context to Docker daemon",
+		})
+	}
 
 	var memory int64
 	if *flMemoryString != "" {
@@ -253,6 +308,21 @@ func (cli *DockerCli) CmdBuild(args ...string) error {
 
 	v.Set("dockerfile", relDockerfile)
 
+	if *flProgress == "json" {
+		v.Set("progress", "json")
+	}
+
+	if *flPlatform != "" {
+		v.Set("platform", *flPlatform)
+	}
+
+	if session != "" {
+		v.Set("session", session)
+		if sessionSums != nil {
+			v.Set("incremental", "1")
+		}
+	}
+
 	ulimitsVar := flUlimits.GetList()
 	ulimitsJSON, err := json.Marshal(ulimitsVar)
 	if err != nil {
@@ -269,7 +339,11 @@ func (cli *DockerCli) CmdBuild(args ...string) error {
 	v.Set("buildargs", string(buildArgsJSON))
 
 	headers := http.Header(make(map[string][]string))
-	buf, err := json.Marshal(cli.configFile.AuthConfigs)
+	authConfigs, err := credentials.GetAll(cli.configFile)
+	if err != nil {
+		return err
+	}
+	buf, err := json.Marshal(authConfigs)
 	if err != nil {
 		return err
 	}
@@ -278,6 +352,7 @@ func (cli *DockerCli) CmdBuild(args ...string) error {
 
 	sopts := &streamOpts{
 		rawTerminal: true,
+		rawJSON:     *flProgress == "json",
 		in:          body,
 		out:         cli.out,
 		headers:     headers,
@@ -318,6 +393,163 @@ func (cli *DockerCli) CmdBuild(args ...string) error {
 	return nil
 }
 
+// buildSessionKeyFile is the name of the file, in the client configuration
+// directory, holding the random key from which the build context session IDs
+// are derived. The daemon returns the sums of the files of a session's context
+// to whoever knows its ID, so the IDs must not be guessable.
+const buildSessionKeyFile = "build-session.key"
+
+// buildSessionID returns the ID of the build context session used for
+// incremental builds of the context in contextDir from this host, with the
+// session key kept in configDir.
+func buildSessionID(configDir, contextDir string) (string, error) {
+	key, err := loadBuildSessionKey(filepath.Join(configDir, buildSessionKeyFile))
+	if err != nil {
+		return "", err
+	}
+	hostname, _ := os.Hostname()
+	h := hmac.New(sha256.New, key)
+	fmt.Fprintf(h, "%s\x00%s", hostname, contextDir)
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// loadBuildSessionKey reads the build context session key from the given
+// file, and generates it if the file doesn't exist yet.
+func loadBuildSessionKey(path string) ([]byte, error) {
+	key, err := ioutil.ReadFile(path)
+	if err == nil {
+		if len(key) != sha256.Size {
+			return nil, fmt.Errorf("Invalid build session key in %s", path)
+		}
+		return key, nil
+	}
+	if !os.IsNotExist(err) {
+		return nil, err
+	}
+
+	key = make([]byte, sha256.Size)
+	if _, err := rand.Read(key); err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return nil, err
+	}
+	if err := ioutil.WriteFile(path, key, 0600); err != nil {
+		return nil, err
+	}
+	return key, nil
+}
+
+// getBuildSessionSums returns the tarsums of the files in the build context
+// the daemon holds for the given session, or nil if it has none.
+func (cli *DockerCli) getBuildSessionSums(session string) map[string]string {
+	serverResp, err := cli.call("GET", "/build/sessions/"+session, nil, nil)
+	if err != nil {
+		logrus.Debugf("Sending full build context: %v", err)
+		return nil
+	}
+	defer serverResp.body.Close()
+
+	var buildSession types.BuildSession
+	if err := json.NewDecoder(serverResp.body).Decode(&buildSession); err != nil {
+		logrus.Debugf("Sending full build context: %v", err)
+		return nil
+	}
+	return buildSession.Sums
+}
+
+// checkDockerfile has the daemon check the given Dockerfile and prints the
+// warnings it reports. An error is returned if there is any.
+func (cli *DockerCli) checkDockerfile(dockerfilePath, displayName string, buildArgs map[string]string) error {
+	f, err := os.Open(dockerfilePath)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	buildArgsJSON, err := json.Marshal(buildArgs)
+	if err != nil {
+		return err
+	}
+	v := url.Values{}
+	v.Set("buildargs", string(buildArgsJSON))
+
+	serverResp, err := cli.clientRequest("POST", "/build/check?"+v.Encode(), f, nil)
+	if err != nil {
+		return err
+	}
+	defer serverResp.body.Close()
+
+	var check types.BuildCheck
+	if err := json.NewDecoder(serverResp.body).Decode(&check); err != nil {
+		return err
+	}
+
+	for _, w := range check.Warnings {
+		fmt.Fprintf(cli.out, "%s:%d: %s (%s)\n", displayName, w.Line, w.Message, w.Code)
+	}
+	if len(check.Warnings) > 0 {
+		return Cli.StatusError{StatusCode: 1}
+	}
+	return nil
+}
+
+// getIncrementalContext returns a layer of the changes between the build
+// context in contextDir and the one the daemon holds, as described by its
+// tarsums. Files the daemon holds which are no longer part of the context
+// are marked with whiteouts. The Dockerfile and .dockerignore are always
+// sent, as the daemon needs them in every build.
+func getIncrementalContext(contextDir string, options *archive.TarOptions, relDockerfile string, sessionSums map[string]string) (io.ReadCloser, error) {
+	context, err := archive.TarWithOptions(contextDir, options)
+	if err != nil {
+		return nil, err
+	}
+	defer context.Close()
+
+	ts, err := tarsum.NewTarSum(context, true, tarsum.Version1)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := io.Copy(ioutil.Discard, ts); err != nil {
+		return nil, err
+	}
+
+	var changes []archive.Change
+	localSums := make(map[string]string)
+	for _, fis := range ts.GetSums() {
+		localSums[fis.Name()] = fis.Sum()
+	}
+	for name, sum := range localSums {
+		sessionSum, ok := sessionSums[name]
+		if ok && sessionSum == sum && name != relDockerfile && name != ".dockerignore" {
+			continue
+		}
+		changes = append(changes, archive.Change{Path: "/" + name, Kind: archive.ChangeModify})
+	}
+	for name := range sessionSums {
+		if _, ok := localSums[name]; ok {
+			continue
+		}
+		// Deleting a directory deletes its content as well, and a
+		// whiteout below it would recreate the directory.
+		deletedParent := false
+		for dir := path.Dir(name); dir != "." && dir != "/"; dir = path.Dir(dir) {
+			if _, ok := sessionSums[dir]; ok {
+				if _, ok := localSums[dir]; !ok {
+					deletedParent = true
+					break
+				}
+			}
+		}
+		if !deletedParent {
+			changes = append(changes, archive.Change{Path: "/" + name, Kind: archive.ChangeDelete})
+		}
+	}
+	logrus.Debugf("Sending %d changes to the build context", len(changes))
+
+	return archive.ExportChanges(contextDir, changes)
+}
+
 // isUNC returns true if the path is UNC (one starting \\). It always returns
 // false on Linux.
 func isUNC(path string) bool {
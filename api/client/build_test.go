@@ -0,0 +1,157 @@
+package client
+
+import (
+	"archive/tar"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+
+	"github.com/docker/docker/pkg/archive"
+	"github.com/docker/docker/pkg/tarsum"
+)
+
+// contextSums returns the tarsums of the build context in dir, as the daemon
+// computes them for a session.
+func contextSums(t *testing.T, dir string) map[string]string {
+	context, err := archive.TarWithOptions(dir, &archive.TarOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer context.Close()
+	ts, err := tarsum.NewTarSum(context, true, tarsum.Version1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := io.Copy(ioutil.Discard, ts); err != nil {
+		t.Fatal(err)
+	}
+	sums := make(map[string]string)
+	for _, fis := range ts.GetSums() {
+		sums[fis.Name()] = fis.Sum()
+	}
+	return sums
+}
+
+func TestGetIncrementalContext(t *testing.T) {
+	dir, err := ioutil.TempDir("", "docker-build-incremental-test-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	files := map[string]string{
+		"Dockerfile":      "FROM busybox",
+		"unchanged":       "unchanged",
+		"changed":         "old",
+		"removed":         "removed",
+		"removeddir/file": "removed",
+	}
+	for name, content := range files {
+		if err := os.MkdirAll(filepath.Dir(filepath.Join(dir, name)), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	sessionSums := contextSums(t, dir)
+
+	if err := ioutil.WriteFile(filepath.Join(dir, "changed"), []byte("new"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(dir, "added"), []byte("added"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Remove(filepath.Join(dir, "removed")); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.RemoveAll(filepath.Join(dir, "removeddir")); err != nil {
+		t.Fatal(err)
+	}
+
+	context, err := getIncrementalContext(dir, &archive.TarOptions{}, "Dockerfile", sessionSums)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer context.Close()
+
+	var names []string
+	tr := tar.NewReader(context)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		names = append(names, hdr.Name)
+	}
+	sort.Strings(names)
+
+	// The Dockerfile is always sent, and the content of a removed directory
+	// is removed along with it.
+	expected := []string{".wh.removed", ".wh.removeddir", "Dockerfile", "added", "changed"}
+	if len(names) != len(expected) {
+		t.Fatalf("Expected %v, got %v", expected, names)
+	}
+	for i := range expected {
+		if names[i] != expected[i] {
+			t.Fatalf("Expected %v, got %v", expected, names)
+		}
+	}
+}
+
+func TestBuildSessionID(t *testing.T) {
+	configDir, err := ioutil.TempDir("", "docker-build-session-key-test-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(configDir)
+
+	id, err := buildSessionID(configDir, "/context")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(id) != 64 {
+		t.Fatalf("Expected a 64 character session ID, got %q", id)
+	}
+	fi, err := os.Stat(filepath.Join(configDir, buildSessionKeyFile))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fi.Mode().Perm() != 0600 {
+		t.Fatalf("Expected the session key to be private, got mode %v", fi.Mode())
+	}
+
+	again, err := buildSessionID(configDir, "/context")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if again != id {
+		t.Fatalf("Expected the same session ID for the same context, got %q and %q", id, again)
+	}
+	other, err := buildSessionID(configDir, "/other")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if other == id {
+		t.Fatal("Expected different session IDs for different contexts")
+	}
+
+	otherConfigDir, err := ioutil.TempDir("", "docker-build-session-key-test-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(otherConfigDir)
+	otherClient, err := buildSessionID(otherConfigDir, "/context")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if otherClient == id {
+		t.Fatal("Expected different session IDs for clients with different keys")
+	}
+}
@@ -98,27 +98,37 @@ func (cli *DockerCli) CmdImages(args ...string) error {
 			repoDigests = []string{}
 		}
 
-		// combine the tags and digests lists
-		tagsAndDigests := append(repoTags, repoDigests...)
-		for _, repoAndRef := range tagsAndDigests {
+		// group the tags and digests by repository, so that the digests an
+		// image was pulled with are shown along with its tags
+		var repos []string
+		repoRefs := make(map[string]*imageRepoRefs)
+		for _, repoAndRef := range append(repoTags, repoDigests...) {
 			repo, ref := parsers.ParseRepositoryTag(repoAndRef)
-			// default tag and digest to none - if there's a value, it'll be set below
-			tag := "<none>"
-			digest := "<none>"
+			refs, ok := repoRefs[repo]
+			if !ok {
+				refs = &imageRepoRefs{}
+				repoRefs[repo] = refs
+				repos = append(repos, repo)
+			}
 			if utils.DigestReference(ref) {
-				digest = ref
+				refs.digests = append(refs.digests, ref)
 			} else {
-				tag = ref
+				refs.tags = append(refs.tags, ref)
 			}
+		}
 
-			if !*quiet {
-				if *showDigests {
-					fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s ago\t%s\n", repo, tag, digest, ID, units.HumanDuration(time.Now().UTC().Sub(time.Unix(int64(image.Created), 0))), units.HumanSize(float64(image.VirtualSize)))
+		for _, repo := range repos {
+			for _, tagAndDigest := range repoRefs[repo].rows(*showDigests) {
+				tag, digest := tagAndDigest[0], tagAndDigest[1]
+				if !*quiet {
+					if *showDigests {
+						fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s ago\t%s\n", repo, tag, digest, ID, units.HumanDuration(time.Now().UTC().Sub(time.Unix(int64(image.Created), 0))), units.HumanSize(float64(image.VirtualSize)))
+					} else {
+						fmt.Fprintf(w, "%s\t%s\t%s\t%s ago\t%s\n", repo, tag, ID, units.HumanDuration(time.Now().UTC().Sub(time.Unix(int64(image.Created), 0))), units.HumanSize(float64(image.VirtualSize)))
+					}
 				} else {
-					fmt.Fprintf(w, "%s\t%s\t%s\t%s ago\t%s\n", repo, tag, ID, units.HumanDuration(time.Now().UTC().Sub(time.Unix(int64(image.Created), 0))), units.HumanSize(float64(image.VirtualSize)))
+					fmt.Fprintln(w, ID)
 				}
-			} else {
-				fmt.Fprintln(w, ID)
 			}
 		}
 	}
@@ -128,3 +138,33 @@ func (cli *DockerCli) CmdImages(args ...string) error {
 	}
 	return nil
 }
+
+// imageRepoRefs holds the tags and digests referencing an image in a
+// repository.
+type imageRepoRefs struct {
+	tags    []string
+	digests []string
+}
+
+// rows returns the tag and digest of each row listing the image in the
+// repository. The digests are listed along with each tag, and on their own
+// only if no tag of the repository references the image.
+func (r *imageRepoRefs) rows(showDigests bool) [][2]string {
+	var rows [][2]string
+	if len(r.tags) == 0 {
+		for _, digest := range r.digests {
+			rows = append(rows, [2]string{"<none>", digest})
+		}
+		return rows
+	}
+	for _, tag := range r.tags {
+		if !showDigests || len(r.digests) == 0 {
+			rows = append(rows, [2]string{tag, "<none>"})
+			continue
+		}
+		for _, digest := range r.digests {
+			rows = append(rows, [2]string{tag, digest})
+		}
+	}
+	return rows
+}
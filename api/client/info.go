@@ -6,6 +6,7 @@ import (
 
 	"github.com/docker/docker/api/types"
 	Cli "github.com/docker/docker/cli"
+	"github.com/docker/docker/cliconfig/credentials"
 	"github.com/docker/docker/pkg/httputils"
 	"github.com/docker/docker/pkg/ioutils"
 	flag "github.com/docker/docker/pkg/mflag"
@@ -67,8 +68,8 @@ func (cli *DockerCli) CmdInfo(args ...string) error {
 	ioutils.FprintfIfNotEmpty(cli.out, "No Proxy: %s\n", info.NoProxy)
 
 	if info.IndexServerAddress != "" {
-		u := cli.configFile.AuthConfigs[info.IndexServerAddress].Username
-		if len(u) > 0 {
+		authConfig, _ := credentials.DetectStore(cli.configFile, info.IndexServerAddress).Get(info.IndexServerAddress)
+		if u := authConfig.Username; len(u) > 0 {
 			fmt.Fprintf(cli.out, "Username: %v\n", u)
 			fmt.Fprintf(cli.out, "Registry: %v\n", info.IndexServerAddress)
 		}
@@ -82,6 +83,12 @@ func (cli *DockerCli) CmdInfo(args ...string) error {
 			if !info.SwapLimit {
 				fmt.Fprintf(cli.err, "WARNING: No swap limit support\n")
 			}
+			if !info.PidsLimit {
+				fmt.Fprintf(cli.err, "WARNING: No pids limit support\n")
+			}
+			if !info.Seccomp {
+				fmt.Fprintf(cli.err, "WARNING: No seccomp support, containers run without the default seccomp profile\n")
+			}
 			if !info.IPv4Forwarding {
 				fmt.Fprintf(cli.err, "WARNING: IPv4 forwarding is disabled\n")
 			}
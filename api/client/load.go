@@ -5,27 +5,34 @@ import (
 	"os"
 
 	Cli "github.com/docker/docker/cli"
+	"github.com/docker/docker/pkg/archive"
 	flag "github.com/docker/docker/pkg/mflag"
 )
 
 // CmdLoad loads an image from a tar archive.
 //
 // The tar archive is read from STDIN by default, or from a tar archive file.
+// An OCI image layout can also be loaded from its directory.
 //
 // Usage: docker load [OPTIONS]
 func (cli *DockerCli) CmdLoad(args ...string) error {
 	cmd := Cli.Subcmd("load", nil, "Load an image from a tar archive or STDIN", true)
-	infile := cmd.String([]string{"i", "-input"}, "", "Read from a tar archive file, instead of STDIN")
+	infile := cmd.String([]string{"i", "-input"}, "", "Read from a tar archive file or OCI layout, instead of STDIN")
 	cmd.Require(flag.Exact, 0)
 
 	cmd.ParseFlags(args, true)
 
-	var (
-		input io.Reader = cli.in
-		err   error
-	)
+	var input io.Reader = cli.in
 	if *infile != "" {
-		input, err = os.Open(*infile)
+		fi, err := os.Stat(*infile)
+		if err != nil {
+			return err
+		}
+		if fi.IsDir() {
+			input, err = archive.Tar(*infile, archive.Uncompressed)
+		} else {
+			input, err = os.Open(*infile)
+		}
 		if err != nil {
 			return err
 		}
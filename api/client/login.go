@@ -11,6 +11,7 @@ import (
 	"github.com/docker/docker/api/types"
 	Cli "github.com/docker/docker/cli"
 	"github.com/docker/docker/cliconfig"
+	"github.com/docker/docker/cliconfig/credentials"
 	flag "github.com/docker/docker/pkg/mflag"
 	"github.com/docker/docker/pkg/term"
 	"github.com/docker/docker/registry"
@@ -56,10 +57,13 @@ func (cli *DockerCli) CmdLogin(args ...string) error {
 		return string(line)
 	}
 
-	authconfig, ok := cli.configFile.AuthConfigs[serverAddress]
-	if !ok {
+	credsStore := credentials.DetectStore(cli.configFile, serverAddress)
+	authconfig, err := credsStore.Get(serverAddress)
+	if err != nil {
+		fmt.Fprintf(cli.err, "WARNING: could not get the saved credentials: %v\n", err)
 		authconfig = cliconfig.AuthConfig{}
 	}
+	saved := authconfig.Username != ""
 
 	if username == "" {
 		promptDefault("Username", authconfig.Username)
@@ -108,17 +112,21 @@ func (cli *DockerCli) CmdLogin(args ...string) error {
 			email = authconfig.Email
 		}
 	}
+	if username != authconfig.Username || password != authconfig.Password {
+		// The identity token was issued for the saved credentials.
+		authconfig.IdentityToken = ""
+	}
 	authconfig.Username = username
 	authconfig.Password = password
 	authconfig.Email = email
 	authconfig.ServerAddress = serverAddress
-	cli.configFile.AuthConfigs[serverAddress] = authconfig
 
-	serverResp, err := cli.call("POST", "/auth", cli.configFile.AuthConfigs[serverAddress], nil)
+	serverResp, err := cli.call("POST", "/auth", authconfig, nil)
 	if serverResp.statusCode == 401 {
-		delete(cli.configFile.AuthConfigs, serverAddress)
-		if err2 := cli.configFile.Save(); err2 != nil {
-			fmt.Fprintf(cli.out, "WARNING: could not save config file: %v\n", err2)
+		if saved {
+			if err2 := credsStore.Erase(serverAddress); err2 != nil {
+				fmt.Fprintf(cli.out, "WARNING: could not remove the saved credentials: %v\n", err2)
+			}
 		}
 		return err
 	}
@@ -130,15 +138,23 @@ func (cli *DockerCli) CmdLogin(args ...string) error {
 
 	var response types.AuthResponse
 	if err := json.NewDecoder(serverResp.body).Decode(&response); err != nil {
-		// Upon error, remove entry
-		delete(cli.configFile.AuthConfigs, serverAddress)
 		return err
 	}
 
-	if err := cli.configFile.Save(); err != nil {
-		return fmt.Errorf("Error saving config file: %v", err)
+	if response.IdentityToken != "" {
+		// Store the refresh token issued by the registry instead of the
+		// password.
+		authconfig.Password = ""
+		authconfig.IdentityToken = response.IdentityToken
+	}
+	if err := credsStore.Store(authconfig); err != nil {
+		return fmt.Errorf("Error saving credentials: %v", err)
+	}
+	if helper := credentials.Helper(cli.configFile, serverAddress); helper != "" {
+		fmt.Fprintf(cli.out, "Login credentials saved by the %s credentials helper\n", helper)
+	} else {
+		fmt.Fprintf(cli.out, "WARNING: login credentials saved in %s\n", cli.configFile.Filename())
 	}
-	fmt.Fprintf(cli.out, "WARNING: login credentials saved in %s\n", cli.configFile.Filename())
 
 	if response.Status != "" {
 		fmt.Fprintf(cli.out, "%s\n", response.Status)
@@ -4,6 +4,7 @@ import (
 	"fmt"
 
 	Cli "github.com/docker/docker/cli"
+	"github.com/docker/docker/cliconfig/credentials"
 	flag "github.com/docker/docker/pkg/mflag"
 	"github.com/docker/docker/registry"
 )
@@ -24,15 +25,15 @@ func (cli *DockerCli) CmdLogout(args ...string) error {
 		serverAddress = cmd.Arg(0)
 	}
 
-	if _, ok := cli.configFile.AuthConfigs[serverAddress]; !ok {
+	credsStore := credentials.DetectStore(cli.configFile, serverAddress)
+	if authConfig, err := credsStore.Get(serverAddress); err == nil && authConfig.Username == "" && authConfig.Email == "" {
 		fmt.Fprintf(cli.out, "Not logged in to %s\n", serverAddress)
 		return nil
 	}
 
 	fmt.Fprintf(cli.out, "Remove login credentials for %s\n", serverAddress)
-	delete(cli.configFile.AuthConfigs, serverAddress)
-	if err := cli.configFile.Save(); err != nil {
-		return fmt.Errorf("Failed to save docker config: %v", err)
+	if err := credsStore.Erase(serverAddress); err != nil {
+		return fmt.Errorf("Failed to remove the credentials: %v", err)
 	}
 
 	return nil
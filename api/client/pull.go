@@ -17,6 +17,7 @@ import (
 func (cli *DockerCli) CmdPull(args ...string) error {
 	cmd := Cli.Subcmd("pull", []string{"NAME[:TAG|@DIGEST]"}, "Pull an image or a repository from a registry", true)
 	allTags := cmd.Bool([]string{"a", "-all-tags"}, false, "Download all tagged images in the repository")
+	platform := cmd.String([]string{"-platform"}, "", "Pull the image for a platform (os/arch[/variant]) other than the daemon's")
 	addTrustedFlags(cmd, true)
 	cmd.Require(flag.Exact, 1)
 
@@ -31,6 +32,12 @@ func (cli *DockerCli) CmdPull(args ...string) error {
 		return fmt.Errorf("tag can't be used with --all-tags/-a")
 	}
 
+	if *platform != "" {
+		if _, _, _, err := parsers.ParsePlatform(*platform); err != nil {
+			return err
+		}
+	}
+
 	ref := registry.ParseReference(tag)
 
 	// Resolve the Repository name from fqn to RepositoryInfo
@@ -41,12 +48,15 @@ func (cli *DockerCli) CmdPull(args ...string) error {
 
 	if isTrusted() && !ref.HasDigest() {
 		// Check if tag is digest
-		authConfig := registry.ResolveAuthConfig(cli.configFile, repoInfo.Index)
-		return cli.trustedPull(repoInfo, ref, authConfig)
+		authConfig := cli.resolveAuthConfig(repoInfo.Index)
+		return cli.trustedPull(repoInfo, ref, authConfig, *platform)
 	}
 
 	v := url.Values{}
 	v.Set("fromImage", ref.ImageName(taglessRemote))
+	if *platform != "" {
+		v.Set("platform", *platform)
+	}
 
 	_, _, err = cli.clientRequestAttemptLogin("POST", "/images/create?"+v.Encode(), nil, cli.out, repoInfo.Index, "pull")
 	return err
@@ -7,6 +7,7 @@ import (
 
 	Cli "github.com/docker/docker/cli"
 	flag "github.com/docker/docker/pkg/mflag"
+	"github.com/docker/docker/runconfig"
 )
 
 // CmdRestart restarts one or more containers.
@@ -14,13 +15,17 @@ import (
 // Usage: docker restart [OPTIONS] CONTAINER [CONTAINER...]
 func (cli *DockerCli) CmdRestart(args ...string) error {
 	cmd := Cli.Subcmd("restart", []string{"CONTAINER [CONTAINER...]"}, "Restart a container", true)
-	nSeconds := cmd.Int([]string{"t", "-time"}, 10, "Seconds to wait for stop before killing the container")
+	nSeconds := cmd.Int([]string{"t", "-time"}, runconfig.DefaultStopTimeout, "Seconds to wait for stop before killing the container")
 	cmd.Require(flag.Min, 1)
 
 	cmd.ParseFlags(args, true)
 
 	v := url.Values{}
-	v.Set("t", strconv.Itoa(*nSeconds))
+	// Leave the daemon the choice of the container's stop timeout unless it
+	// was given.
+	if cmd.IsSet("t") || cmd.IsSet("-time") {
+		v.Set("t", strconv.Itoa(*nSeconds))
+	}
 
 	var errNames []string
 	for _, name := range cmd.Args() {
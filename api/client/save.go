@@ -2,6 +2,7 @@ package client
 
 import (
 	"errors"
+	"fmt"
 	"net/url"
 	"os"
 
@@ -17,6 +18,7 @@ import (
 func (cli *DockerCli) CmdSave(args ...string) error {
 	cmd := Cli.Subcmd("save", []string{"IMAGE [IMAGE...]"}, "Save an image(s) to a tar archive (streamed to STDOUT by default)", true)
 	outfile := cmd.String([]string{"o", "-output"}, "", "Write to a file, instead of STDOUT")
+	format := cmd.String([]string{"-format"}, "docker", "Archive format (docker or oci)")
 	cmd.Require(flag.Min, 1)
 
 	cmd.ParseFlags(args, true)
@@ -26,6 +28,9 @@ func (cli *DockerCli) CmdSave(args ...string) error {
 		err    error
 	)
 
+	if *format != "docker" && *format != "oci" {
+		return fmt.Errorf("Invalid format %q, expected docker or oci", *format)
+	}
 	if *outfile == "" && cli.isTerminalOut {
 		return errors.New("Cowardly refusing to save to a terminal. Use the -o flag or redirect.")
 	}
@@ -44,6 +49,9 @@ func (cli *DockerCli) CmdSave(args ...string) error {
 	for _, arg := range cmd.Args() {
 		v.Add("names", arg)
 	}
+	if *format != "docker" {
+		v.Set("format", *format)
+	}
 	if _, err := cli.stream("GET", "/images/get?"+v.Encode(), sopts); err != nil {
 		return err
 	}
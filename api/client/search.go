@@ -5,12 +5,15 @@ import (
 	"fmt"
 	"net/url"
 	"sort"
+	"strconv"
 	"strings"
 	"text/tabwriter"
 
 	Cli "github.com/docker/docker/cli"
+	"github.com/docker/docker/opts"
 	flag "github.com/docker/docker/pkg/mflag"
 	"github.com/docker/docker/pkg/parsers"
+	"github.com/docker/docker/pkg/parsers/filters"
 	"github.com/docker/docker/pkg/stringutils"
 	"github.com/docker/docker/registry"
 )
@@ -31,13 +34,38 @@ func (cli *DockerCli) CmdSearch(args ...string) error {
 	trusted := cmd.Bool([]string{"#t", "#trusted", "#-trusted"}, false, "Only show trusted builds")
 	automated := cmd.Bool([]string{"-automated"}, false, "Only show automated builds")
 	stars := cmd.Uint([]string{"s", "#stars", "-stars"}, 0, "Only displays with at least x stars")
+	limit := cmd.Int([]string{"-limit"}, registry.DefaultSearchLimit, "Max number of search results")
+	flFilter := opts.NewListOpts(nil)
+	cmd.Var(&flFilter, []string{"f", "-filter"}, "Filter output based on conditions provided")
 	cmd.Require(flag.Exact, 1)
 
 	cmd.ParseFlags(args, true)
 
+	if err := registry.ValidateSearchLimit(*limit); err != nil {
+		return err
+	}
+	// Consolidate all filter flags, and sanity check them early.
+	// They'll get processed in the daemon.
+	searchFilterArgs := filters.Args{}
+	for _, f := range flFilter.GetAll() {
+		var err error
+		searchFilterArgs, err = filters.ParseFlag(f, searchFilterArgs)
+		if err != nil {
+			return err
+		}
+	}
+
 	name := cmd.Arg(0)
 	v := url.Values{}
 	v.Set("term", name)
+	v.Set("limit", strconv.Itoa(*limit))
+	if len(searchFilterArgs) > 0 {
+		filterJSON, err := filters.ToParam(searchFilterArgs)
+		if err != nil {
+			return err
+		}
+		v.Set("filters", filterJSON)
+	}
 
 	// Resolve the Repository name from fqn to hostname + name
 	taglessRemote, _ := parsers.ParseRepositoryTag(name)
@@ -7,22 +7,27 @@ import (
 
 	Cli "github.com/docker/docker/cli"
 	flag "github.com/docker/docker/pkg/mflag"
+	"github.com/docker/docker/runconfig"
 )
 
 // CmdStop stops one or more running containers.
 //
-// A running container is stopped by first sending SIGTERM and then SIGKILL if the container fails to stop within a grace period (the default is 10 seconds).
+// A running container is stopped by first sending SIGTERM and then SIGKILL if the container fails to stop within a grace period (the default is the stop timeout of the container, 10 seconds unless set).
 //
 // Usage: docker stop [OPTIONS] CONTAINER [CONTAINER...]
 func (cli *DockerCli) CmdStop(args ...string) error {
 	cmd := Cli.Subcmd("stop", []string{"CONTAINER [CONTAINER...]"}, "Stop a running container by sending SIGTERM and then SIGKILL after a\ngrace period", true)
-	nSeconds := cmd.Int([]string{"t", "-time"}, 10, "Seconds to wait for stop before killing it")
+	nSeconds := cmd.Int([]string{"t", "-time"}, runconfig.DefaultStopTimeout, "Seconds to wait for stop before killing it")
 	cmd.Require(flag.Min, 1)
 
 	cmd.ParseFlags(args, true)
 
 	v := url.Values{}
-	v.Set("t", strconv.Itoa(*nSeconds))
+	// Leave the daemon the choice of the container's stop timeout unless it
+	// was given.
+	if cmd.IsSet("t") || cmd.IsSet("-time") {
+		v.Set("t", strconv.Itoa(*nSeconds))
+	}
 
 	var errNames []string
 	for _, name := range cmd.Args() {
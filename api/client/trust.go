@@ -209,7 +209,7 @@ func (cli *DockerCli) trustedReference(repo string, ref registry.Reference) (reg
 	}
 
 	// Resolve the Auth config relevant for this server
-	authConfig := registry.ResolveAuthConfig(cli.configFile, repoInfo.Index)
+	authConfig := cli.resolveAuthConfig(repoInfo.Index)
 
 	notaryRepo, err := cli.getNotaryRepository(repoInfo, authConfig)
 	if err != nil {
@@ -261,7 +261,7 @@ func notaryError(err error) error {
 	return err
 }
 
-func (cli *DockerCli) trustedPull(repoInfo *registry.RepositoryInfo, ref registry.Reference, authConfig cliconfig.AuthConfig) error {
+func (cli *DockerCli) trustedPull(repoInfo *registry.RepositoryInfo, ref registry.Reference, authConfig cliconfig.AuthConfig, platform string) error {
 	var (
 		v    = url.Values{}
 		refs = []target{}
@@ -301,6 +301,9 @@ func (cli *DockerCli) trustedPull(repoInfo *registry.RepositoryInfo, ref registr
 	}
 
 	v.Set("fromImage", repoInfo.LocalName)
+	if platform != "" {
+		v.Set("platform", platform)
+	}
 	for i, r := range refs {
 		displayTag := r.reference.String()
 		if displayTag != "" {
@@ -22,6 +22,7 @@ import (
 	"github.com/docker/docker/api/types"
 	"github.com/docker/docker/autogen/dockerversion"
 	"github.com/docker/docker/cliconfig"
+	"github.com/docker/docker/cliconfig/credentials"
 	"github.com/docker/docker/pkg/jsonmessage"
 	"github.com/docker/docker/pkg/signal"
 	"github.com/docker/docker/pkg/stdcopy"
@@ -163,19 +164,40 @@ func (cli *DockerCli) cmdAttempt(authConfig cliconfig.AuthConfig, method, path s
 func (cli *DockerCli) clientRequestAttemptLogin(method, path string, in io.Reader, out io.Writer, index *registry.IndexInfo, cmdName string) (io.ReadCloser, int, error) {
 
 	// Resolve the Auth config relevant for this server
-	authConfig := registry.ResolveAuthConfig(cli.configFile, index)
+	authConfig := cli.resolveAuthConfig(index)
 	body, statusCode, err := cli.cmdAttempt(authConfig, method, path, in, out)
 	if statusCode == http.StatusUnauthorized {
 		fmt.Fprintf(cli.out, "\nPlease login prior to %s:\n", cmdName)
 		if err = cli.CmdLogin(index.GetAuthConfigKey()); err != nil {
 			return nil, -1, err
 		}
-		authConfig = registry.ResolveAuthConfig(cli.configFile, index)
+		authConfig = cli.resolveAuthConfig(index)
 		return cli.cmdAttempt(authConfig, method, path, in, out)
 	}
 	return body, statusCode, err
 }
 
+// resolveAuthConfig returns the credentials of the registry of the given
+// index, from the credentials store configured for it.
+func (cli *DockerCli) resolveAuthConfig(index *registry.IndexInfo) cliconfig.AuthConfig {
+	configKey := index.GetAuthConfigKey()
+	authConfig, err := credentials.DetectStore(cli.configFile, configKey).Get(configKey)
+	if err != nil {
+		fmt.Fprintf(cli.err, "WARNING: could not get the credentials for %s: %v\n", configKey, err)
+	}
+	if authConfig.Username != "" || index.Official {
+		return authConfig
+	}
+
+	// Maybe the credentials are saved under a legacy key, which
+	// registry.ResolveAuthConfig also matches.
+	authConfigs, err := credentials.GetAll(cli.configFile)
+	if err != nil {
+		return authConfig
+	}
+	return registry.ResolveAuthConfig(&cliconfig.ConfigFile{AuthConfigs: authConfigs}, index)
+}
+
 func (cli *DockerCli) callWrapper(method, path string, data interface{}, headers map[string][]string) (io.ReadCloser, http.Header, int, error) {
 	sr, err := cli.call(method, path, data, headers)
 	return sr.body, sr.header, sr.statusCode, err
@@ -205,6 +227,7 @@ func (cli *DockerCli) call(method, path string, data interface{}, headers map[st
 
 type streamOpts struct {
 	rawTerminal bool
+	rawJSON     bool
 	in          io.Reader
 	out         io.Writer
 	err         io.Writer
@@ -216,6 +239,10 @@ func (cli *DockerCli) stream(method, path string, opts *streamOpts) (*serverResp
 	if err != nil {
 		return serverResp, err
 	}
+	if opts.rawJSON && api.MatchesContentType(serverResp.header.Get("Content-Type"), "application/json") {
+		defer serverResp.body.Close()
+		return serverResp, copyJSONMessagesStream(serverResp.body, opts.out)
+	}
 	return serverResp, cli.streamBody(serverResp.body, serverResp.header.Get("Content-Type"), opts.rawTerminal, opts.out, opts.err)
 }
 
@@ -239,6 +266,33 @@ func (cli *DockerCli) streamBody(body io.ReadCloser, contentType string, rawTerm
 	return nil
 }
 
+// copyJSONMessagesStream copies a json message stream from `in` to `out`
+// one message per line, without rendering it. Like
+// jsonmessage.DisplayJSONMessagesStream, it returns the first error message
+// found in the stream.
+func copyJSONMessagesStream(in io.Reader, out io.Writer) error {
+	dec := json.NewDecoder(in)
+	for {
+		var raw json.RawMessage
+		if err := dec.Decode(&raw); err != nil {
+			if err == io.EOF {
+				return nil
+			}
+			return err
+		}
+		if _, err := fmt.Fprintf(out, "%s\n", raw); err != nil {
+			return err
+		}
+		var jm jsonmessage.JSONMessage
+		if err := json.Unmarshal(raw, &jm); err != nil {
+			return err
+		}
+		if jm.Error != nil {
+			return jm.Error
+		}
+	}
+}
+
 func (cli *DockerCli) resizeTty(id string, isExec bool) {
 	height, width := cli.getTtySize()
 	if height == 0 && width == 0 {
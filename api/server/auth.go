@@ -16,11 +16,12 @@ func (s *Server) postAuth(ctx context.Context, w http.ResponseWriter, r *http.Re
 	if err != nil {
 		return err
 	}
-	status, err := s.daemon.RegistryService.Auth(config)
+	status, identityToken, err := s.daemon.RegistryService.Auth(config)
 	if err != nil {
 		return err
 	}
 	return writeJSON(w, http.StatusOK, &types.AuthResponse{
-		Status: status,
+		Status:        status,
+		IdentityToken: identityToken,
 	})
 }
@@ -198,7 +198,10 @@ func (s *Server) postContainersStop(ctx context.Context, w http.ResponseWriter,
 		return fmt.Errorf("Missing parameter")
 	}
 
-	seconds, _ := strconv.Atoi(r.Form.Get("t"))
+	seconds, err := stopTimeoutForm(ctx.Version(), r)
+	if err != nil {
+		return err
+	}
 
 	if err := s.daemon.ContainerStop(vars["name"], seconds); err != nil {
 		return err
@@ -208,6 +211,25 @@ func (s *Server) postContainersStop(ctx context.Context, w http.ResponseWriter,
 	return nil
 }
 
+// stopTimeoutForm returns the timeout (in seconds) given in the "t" parameter
+// of a stop or restart request, or nil to use the stop timeout of the
+// container. Before API 1.21 a missing or invalid "t" meant no timeout.
+func stopTimeoutForm(apiVersion version.Version, r *http.Request) (*int, error) {
+	t := r.Form.Get("t")
+	if apiVersion.LessThan("1.21") {
+		seconds, _ := strconv.Atoi(t)
+		return &seconds, nil
+	}
+	if t == "" {
+		return nil, nil
+	}
+	seconds, err := strconv.Atoi(t)
+	if err != nil {
+		return nil, fmt.Errorf("Invalid timeout %q: %v", t, err)
+	}
+	return &seconds, nil
+}
+
 func (s *Server) postContainersKill(ctx context.Context, w http.ResponseWriter, r *http.Request, vars map[string]string) error {
 	if vars == nil {
 		return fmt.Errorf("Missing parameter")
@@ -252,7 +274,10 @@ func (s *Server) postContainersRestart(ctx context.Context, w http.ResponseWrite
 		return fmt.Errorf("Missing parameter")
 	}
 
-	timeout, _ := strconv.Atoi(r.Form.Get("t"))
+	timeout, err := stopTimeoutForm(ctx.Version(), r)
+	if err != nil {
+		return err
+	}
 
 	if err := s.daemon.ContainerRestart(vars["name"], timeout); err != nil {
 		return err
@@ -0,0 +1,49 @@
+package server
+
+import (
+	"net/http"
+	"net/url"
+	"testing"
+
+	"github.com/docker/docker/pkg/version"
+)
+
+func TestStopTimeoutForm(t *testing.T) {
+	zero, five := 0, 5
+	cases := []struct {
+		version  version.Version
+		t        string
+		expected *int
+		err      bool
+	}{
+		{"1.21", "", nil, false},
+		{"1.21", "5", &five, false},
+		{"1.21", "five", nil, true},
+		{"1.20", "", &zero, false},
+		{"1.20", "5", &five, false},
+		{"1.20", "five", &zero, false},
+	}
+
+	for _, c := range cases {
+		v := url.Values{}
+		if c.t != "" {
+			v.Set("t", c.t)
+		}
+		r, _ := http.NewRequest("POST", "", nil)
+		r.Form = v
+
+		a, err := stopTimeoutForm(c.version, r)
+		if c.err {
+			if err == nil {
+				t.Fatalf("Version: %s, t: %q, expected an error", c.version, c.t)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		if (a == nil) != (c.expected == nil) || (a != nil && *a != *c.expected) {
+			t.Fatalf("Version: %s, t: %q, expected: %v, actual: %v", c.version, c.t, c.expected, a)
+		}
+	}
+}
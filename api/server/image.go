@@ -7,6 +7,7 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"strconv"
 	"strings"
 
 	"github.com/Sirupsen/logrus"
@@ -16,9 +17,12 @@ import (
 	"github.com/docker/docker/context"
 	"github.com/docker/docker/graph"
 	"github.com/docker/docker/pkg/ioutils"
+	"github.com/docker/docker/pkg/jsonmessage"
 	"github.com/docker/docker/pkg/parsers"
+	"github.com/docker/docker/pkg/parsers/filters"
 	"github.com/docker/docker/pkg/streamformatter"
 	"github.com/docker/docker/pkg/ulimit"
+	"github.com/docker/docker/registry"
 	"github.com/docker/docker/runconfig"
 	"github.com/docker/docker/utils"
 )
@@ -72,11 +76,17 @@ func (s *Server) postImagesCreate(ctx context.Context, w http.ResponseWriter, r
 	}
 
 	var (
-		image   = r.Form.Get("fromImage")
-		repo    = r.Form.Get("repo")
-		tag     = r.Form.Get("tag")
-		message = r.Form.Get("message")
+		image    = r.Form.Get("fromImage")
+		repo     = r.Form.Get("repo")
+		tag      = r.Form.Get("tag")
+		message  = r.Form.Get("message")
+		platform = r.Form.Get("platform")
 	)
+	if platform != "" {
+		if _, _, _, err := parsers.ParsePlatform(platform); err != nil {
+			return err
+		}
+	}
 	authEncoded := r.Header.Get("X-Registry-Auth")
 	authConfig := &cliconfig.AuthConfig{}
 	if authEncoded != "" {
@@ -110,6 +120,7 @@ func (s *Server) postImagesCreate(ctx context.Context, w http.ResponseWriter, r
 			MetaHeaders: metaHeaders,
 			AuthConfig:  authConfig,
 			OutStream:   output,
+			Platform:    platform,
 		}
 
 		err = s.daemon.Repositories().Pull(image, tag, imagePullConfig)
@@ -202,6 +213,15 @@ func (s *Server) getImagesGet(ctx context.Context, w http.ResponseWriter, r *htt
 		return err
 	}
 
+	export := s.daemon.Repositories().ImageExport
+	switch format := r.Form.Get("format"); format {
+	case "", "docker":
+	case "oci":
+		export = s.daemon.Repositories().ImageExportOCI
+	default:
+		return fmt.Errorf("Invalid format %q, expected docker or oci", format)
+	}
+
 	w.Header().Set("Content-Type", "application/x-tar")
 
 	output := ioutils.NewWriteFlusher(w)
@@ -212,7 +232,7 @@ func (s *Server) getImagesGet(ctx context.Context, w http.ResponseWriter, r *htt
 		names = r.Form["names"]
 	}
 
-	if err := s.daemon.Repositories().ImageExport(names, output); err != nil {
+	if err := export(names, output); err != nil {
 		if !output.Flushed() {
 			return err
 		}
@@ -313,6 +333,10 @@ func (s *Server) postBuild(ctx context.Context, w http.ResponseWriter, r *http.R
 	buildConfig.CPUSetCpus = r.FormValue("cpusetcpus")
 	buildConfig.CPUSetMems = r.FormValue("cpusetmems")
 	buildConfig.CgroupParent = r.FormValue("cgroupparent")
+	buildConfig.JSONProgress = r.FormValue("progress") == "json"
+	buildConfig.Session = r.FormValue("session")
+	buildConfig.Platform = r.FormValue("platform")
+	buildConfig.IncrementalContext = boolValue(r, "incremental")
 
 	var buildUlimits = []*ulimit.Ulimit{}
 	ulimitsJSON := r.FormValue("ulimits")
@@ -352,12 +376,51 @@ func (s *Server) postBuild(ctx context.Context, w http.ResponseWriter, r *http.R
 		if !output.Flushed() {
 			return err
 		}
+		// Errors with a code, such as a failed RUN, keep it so that the
+		// client exits with the same status.
+		if _, ok := err.(*jsonmessage.JSONError); !ok {
+			err = errors.New(utils.GetErrorMessage(err))
+		}
 		sf := streamformatter.NewJSONStreamFormatter()
-		w.Write(sf.FormatError(errors.New(utils.GetErrorMessage(err))))
+		w.Write(sf.FormatError(err))
 	}
 	return nil
 }
 
+func (s *Server) getBuildSession(ctx context.Context, w http.ResponseWriter, r *http.Request, vars map[string]string) error {
+	if vars == nil {
+		return fmt.Errorf("Missing parameter")
+	}
+
+	sums, err := builder.ContextSessionSums(s.daemon, vars["id"])
+	if err != nil {
+		return err
+	}
+
+	return writeJSON(w, http.StatusOK, &types.BuildSession{Sums: sums})
+}
+
+func (s *Server) postBuildCheck(ctx context.Context, w http.ResponseWriter, r *http.Request, vars map[string]string) error {
+	if err := parseForm(r); err != nil {
+		return err
+	}
+
+	var buildArgs = map[string]string{}
+	buildArgsJSON := r.FormValue("buildargs")
+	if buildArgsJSON != "" {
+		if err := json.NewDecoder(strings.NewReader(buildArgsJSON)).Decode(&buildArgs); err != nil {
+			return err
+		}
+	}
+
+	warnings, err := builder.Check(r.Body, buildArgs)
+	if err != nil {
+		return err
+	}
+
+	return writeJSON(w, http.StatusOK, &types.BuildCheck{Warnings: warnings})
+}
+
 func (s *Server) getImagesJSON(ctx context.Context, w http.ResponseWriter, r *http.Request, vars map[string]string) error {
 	if err := parseForm(r); err != nil {
 		return err
@@ -429,7 +492,18 @@ func (s *Server) getImagesSearch(ctx context.Context, w http.ResponseWriter, r *
 			headers[k] = v
 		}
 	}
-	query, err := s.daemon.RegistryService.Search(r.Form.Get("term"), config, headers)
+	limit := registry.DefaultSearchLimit
+	if tmpLimit := r.Form.Get("limit"); tmpLimit != "" {
+		var err error
+		if limit, err = strconv.Atoi(tmpLimit); err != nil {
+			return err
+		}
+	}
+	searchFilters, err := filters.FromParam(r.Form.Get("filters"))
+	if err != nil {
+		return err
+	}
+	query, err := s.daemon.RegistryService.Search(r.Form.Get("term"), limit, searchFilters, config, headers)
 	if err != nil {
 		return err
 	}
@@ -373,6 +373,7 @@ func createRouter(s *Server) *mux.Router {
 			"/version":                        s.getVersion,
 			"/images/json":                    s.getImagesJSON,
 			"/images/search":                  s.getImagesSearch,
+			"/build/sessions/{id:.*}":         s.getBuildSession,
 			"/images/get":                     s.getImagesGet,
 			"/images/{name:.*}/get":           s.getImagesGet,
 			"/images/{name:.*}/history":       s.getImagesHistory,
@@ -394,6 +395,7 @@ func createRouter(s *Server) *mux.Router {
 			"/auth":                         s.postAuth,
 			"/commit":                       s.postCommit,
 			"/build":                        s.postBuild,
+			"/build/check":                  s.postBuildCheck,
 			"/images/create":                s.postImagesCreate,
 			"/images/load":                  s.postImagesLoad,
 			"/images/{name:.*}/push":        s.postImagesPush,
@@ -49,6 +49,8 @@ type MemoryStats struct {
 	// number of times memory usage hits limits.
 	Failcnt uint64 `json:"failcnt"`
 	Limit   uint64 `json:"limit"`
+	// memory soft limit, 0 if unset.
+	Reservation uint64 `json:"reservation"`
 }
 
 // BlkioStatEntry is one small entity to store a piece of Blkio stats
@@ -74,6 +76,15 @@ type BlkioStats struct {
 	SectorsRecursive        []BlkioStatEntry `json:"sectors_recursive"`
 }
 
+// PidsStats contains the stats of a container's pids
+type PidsStats struct {
+	// Current is the number of pids in the cgroup
+	Current uint64 `json:"current,omitempty"`
+	// Limit is the hard limit on the number of pids in the cgroup.
+	// A "Limit" of 0 means that there is no limit.
+	Limit uint64 `json:"limit,omitempty"`
+}
+
 // NetworkStats aggregates All network stats of one container
 // TODO Windows: This will require refactoring
 type NetworkStats struct {
@@ -94,6 +105,7 @@ type Stats struct {
 	CPUStats    CPUStats    `json:"cpu_stats,omitempty"`
 	MemoryStats MemoryStats `json:"memory_stats,omitempty"`
 	BlkioStats  BlkioStats  `json:"blkio_stats,omitempty"`
+	PidsStats   PidsStats   `json:"pids_stats,omitempty"`
 }
 
 // StatsJSONPre121 is a backcompatibility struct along with ContainerConfig
@@ -32,6 +32,10 @@ type ContainerExecCreateResponse struct {
 type AuthResponse struct {
 	// Status is the authentication status
 	Status string `json:"Status"`
+
+	// IdentityToken is the refresh token the registry issued, to store
+	// and use instead of the password of the user.
+	IdentityToken string `json:"IdentityToken,omitempty"`
 }
 
 // ContainerWaitResponse contains response of Remote API:
@@ -97,6 +101,7 @@ type GraphDriverData struct {
 type ImageInspect struct {
 	ID              string `json:"Id"`
 	Tags            []string
+	RepoDigests     []string
 	Parent          string
 	Comment         string
 	Created         string
@@ -195,6 +200,8 @@ type Info struct {
 	Debug              bool
 	NFd                int
 	OomKillDisable     bool
+	PidsLimit          bool
+	Seccomp            bool
 	NGoroutines        int
 	SystemTime         string
 	ExecutionDriver    string
@@ -259,6 +266,7 @@ type ContainerJSONBase struct {
 	LogPath         string
 	Name            string
 	RestartCount    int
+	RestartReason   string
 	Driver          string
 	ExecDriver      string
 	MountLabel      string
@@ -341,3 +349,53 @@ type VolumeCreateRequest struct {
 	Driver     string            // Driver is the name of the driver that should be used to create the volume
 	DriverOpts map[string]string // DriverOpts holds the driver specific options to use for when creating the volume.
 }
+
+// BuildSession contains response of Remote API:
+// GET "/build/sessions/{id:.*}"
+type BuildSession struct {
+	Sums map[string]string // Sums are the tarsums of the files in the session's build context, keyed by path
+}
+
+// BuildCheck contains response of Remote API:
+// POST "/build/check"
+type BuildCheck struct {
+	Warnings []BuildWarning
+}
+
+// BuildWarning is a problem found in a Dockerfile by POST "/build/check".
+type BuildWarning struct {
+	Line        int    // Line is the line of the Dockerfile where the instruction starts
+	Instruction string // Instruction is the instruction the warning is about, in upper case
+	Code        string // Code identifies the kind of problem, e.g. "unused-arg"
+	Message     string
+}
+
+// BuildProgress is sent in the "aux" field of the response stream of
+// POST "/build" when structured progress is requested. Exactly one of
+// its fields is set.
+type BuildProgress struct {
+	Context *BuildContextProgress `json:",omitempty"` // Context describes the transfer of the build context
+	Step    *BuildStepProgress    `json:",omitempty"` // Step describes a completed Dockerfile step
+}
+
+// BuildContextProgress describes the transfer of the build context to the daemon.
+type BuildContextProgress struct {
+	Size  int64     // Size is the number of bytes of context received
+	Start time.Time // Start is when the daemon started reading the context
+	End   time.Time // End is when the context was fully unpacked
+}
+
+// BuildStepProgress describes the evaluation of a single Dockerfile step.
+type BuildStepProgress struct {
+	Step        int       // Step is the 1-based index of the step in the Dockerfile
+	Instruction string    // Instruction is the instruction as it was written in the Dockerfile
+	StartLine   int       // StartLine is the line of the Dockerfile where the instruction starts
+	EndLine     int       // EndLine is the line of the Dockerfile where the instruction ends
+	Triggers    []string  `json:",omitempty"` // Triggers are the ONBUILD triggers of the base image run by a FROM step
+	CacheHit    bool      // CacheHit is true if the result of the step was taken from the cache
+	ContainerID string    `json:",omitempty"` // ContainerID is the intermediate container created by the step, if any
+	ImageID     string    `json:",omitempty"` // ImageID is the image resulting from the step
+	Start       time.Time // Start is when the step started
+	End         time.Time // End is when the step finished
+	Error       string    `json:",omitempty"` // Error is set if the step failed
+}
@@ -0,0 +1,232 @@
+package builder
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/builder/command"
+	"github.com/docker/docker/builder/parser"
+	"github.com/docker/docker/pkg/parsers"
+	"github.com/docker/docker/pkg/urlutil"
+)
+
+// Codes of the warnings reported by Check.
+const (
+	WarnUnknownInstruction = "unknown-instruction"
+	WarnMissingArguments   = "missing-arguments"
+	WarnMissingFrom        = "missing-from"
+	WarnMaintainer         = "maintainer-deprecated"
+	WarnFromWithoutTag     = "from-without-tag"
+	WarnAddRemoteURL       = "add-remote-url"
+	WarnJSONForm           = "json-form"
+	WarnOnbuildTrigger     = "onbuild-trigger"
+	WarnSubstitution       = "substitution"
+	WarnUnusedArg          = "unused-arg"
+)
+
+// jsonFormCommands are the instructions accepting their arguments as a JSON
+// array, and how their arguments are used when they are not one.
+var jsonFormCommands = map[string]string{
+	command.Run:        "a shell command",
+	command.Cmd:        "a shell command",
+	command.Entrypoint: "a shell command",
+	command.Add:        "a list of paths",
+	command.Copy:       "a list of paths",
+	command.Volume:     "a list of paths",
+}
+
+// checkedArg is an ARG declared in the Dockerfile.
+type checkedArg struct {
+	line int
+	used bool
+}
+
+type checker struct {
+	buildArgs map[string]string
+	env       []string
+	args      map[string]*checkedArg
+	argNames  []string
+	warnings  []types.BuildWarning
+}
+
+// Check parses a Dockerfile and reports the problems found in it, without
+// running anything. ARG and ENV substitutions are resolved the same way as
+// during a build, buildArgs being the build-time arguments of the build.
+// An error is only returned if the Dockerfile cannot be parsed.
+func Check(dockerfile io.Reader, buildArgs map[string]string) ([]types.BuildWarning, error) {
+	ast, err := parser.Parse(dockerfile)
+	if err != nil {
+		return nil, err
+	}
+
+	c := &checker{
+		buildArgs: buildArgs,
+		args:      make(map[string]*checkedArg),
+	}
+	for i, n := range ast.Children {
+		if i == 0 && n.Value != command.From {
+			c.warn(n, WarnMissingFrom, "The first instruction should be FROM, to set the base image")
+		}
+		c.check(n)
+	}
+	for _, name := range c.argNames {
+		if arg := c.args[name]; !arg.used {
+			c.warnings = append(c.warnings, types.BuildWarning{
+				Line:        arg.line,
+				Instruction: "ARG",
+				Code:        WarnUnusedArg,
+				Message:     fmt.Sprintf("ARG %s is never used", name),
+			})
+		}
+	}
+
+	sort.Stable(warningsByLine(c.warnings))
+	if c.warnings == nil {
+		c.warnings = []types.BuildWarning{}
+	}
+	return c.warnings, nil
+}
+
+func (c *checker) warn(n *parser.Node, code, format string, args ...interface{}) {
+	c.warnings = append(c.warnings, types.BuildWarning{
+		Line:        n.StartLine,
+		Instruction: strings.ToUpper(n.Value),
+		Code:        code,
+		Message:     fmt.Sprintf(format, args...),
+	})
+}
+
+// check reports the problems of a top level instruction.
+func (c *checker) check(n *parser.Node) {
+	if !c.checkSyntax(n, n) {
+		return
+	}
+
+	switch n.Value {
+	case command.Maintainer:
+		c.warn(n, WarnMaintainer, "MAINTAINER is a candidate for deprecation, use a LABEL instead")
+	case command.Onbuild:
+		trigger := n.Next.Children[0]
+		switch trigger.Value {
+		case command.Onbuild, command.From, command.Maintainer:
+			c.warn(n, WarnOnbuildTrigger, "%s isn't allowed as an ONBUILD trigger", strings.ToUpper(trigger.Value))
+		default:
+			c.checkSyntax(n, trigger)
+		}
+		// Substitutions in triggers are resolved in the images built on
+		// top of this one.
+		return
+	}
+
+	words := c.substitute(n)
+
+	switch n.Value {
+	case command.From:
+		if _, tag := parsers.ParseRepositoryTag(words[0]); tag == "" && words[0] != "scratch" {
+			c.warn(n, WarnFromWithoutTag, "FROM %s does not specify a tag, the latest tag will be used", words[0])
+		}
+	case command.Add:
+		// The last argument is the destination.
+		for _, src := range words[:len(words)-1] {
+			if urlutil.IsURL(src) {
+				c.warn(n, WarnAddRemoteURL, "ADD of the remote URL %s, its content is downloaded on every build; download it in a RUN instruction to control caching and cleanup", src)
+			}
+		}
+	case command.Env:
+		for i := 0; i+1 < len(words); i += 2 {
+			c.env = append(c.env, words[i]+"="+words[i+1])
+		}
+	case command.Arg:
+		name := strings.SplitN(words[0], "=", 2)[0]
+		if _, ok := c.args[name]; !ok {
+			c.argNames = append(c.argNames, name)
+		}
+		c.args[name] = &checkedArg{line: n.StartLine}
+		if _, ok := c.buildArgs[name]; !ok && strings.Contains(words[0], "=") {
+			if c.buildArgs == nil {
+				c.buildArgs = make(map[string]string)
+			}
+			c.buildArgs[name] = strings.SplitN(words[0], "=", 2)[1]
+		}
+	}
+}
+
+// checkSyntax reports the problems of the parsed form of instruction n,
+// which is either the top level node 'top' or its ONBUILD trigger. It
+// returns false if the instruction cannot be checked any further.
+func (c *checker) checkSyntax(top, n *parser.Node) bool {
+	if _, ok := command.Commands[n.Value]; !ok {
+		c.warn(top, WarnUnknownInstruction, "Unknown instruction: %s", strings.ToUpper(n.Value))
+		return false
+	}
+	if n.Next == nil || (n.Value == command.Onbuild && len(n.Next.Children) == 0) {
+		c.warn(top, WarnMissingArguments, "%s requires at least one argument", strings.ToUpper(n.Value))
+		return false
+	}
+	if usage, ok := jsonFormCommands[n.Value]; ok && !n.Attributes["json"] {
+		args := strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(n.Original), strings.Fields(n.Original)[0]))
+		if strings.HasPrefix(args, "[") {
+			msg := "%s arguments look like a JSON array but are not valid JSON, they are used as %s"
+			if strings.Contains(args, "'") {
+				msg += " (JSON strings must use double quotes)"
+			}
+			c.warn(top, WarnJSONForm, msg, strings.ToUpper(n.Value), usage)
+		}
+	}
+	return true
+}
+
+// substitute returns the arguments of instruction n after the substitutions
+// done by the builder, and records the ARGs they use. Substitution errors
+// are reported as warnings.
+func (c *checker) substitute(n *parser.Node) []string {
+	envs := c.env
+	for name := range c.args {
+		if value, ok := c.buildArgs[name]; ok {
+			envs = append(envs, name+"="+value)
+		}
+	}
+
+	var words []string
+	for next := n.Next; next != nil; next = next.Next {
+		words = append(words, next.Value)
+	}
+
+	if _, ok := replaceEnvAllowed[n.Value]; ok {
+		for i, word := range words {
+			result, refs, err := processWordRefs(word, envs)
+			if err != nil {
+				c.warn(n, WarnSubstitution, "%s", err)
+				continue
+			}
+			c.useArgs(refs)
+			words[i] = result
+		}
+	} else if n.Value == command.Run {
+		// Build-time arguments are set in the environment of RUN
+		// instructions, where the shell expands them.
+		for _, word := range words {
+			if _, refs, err := processWordRefs(word, envs); err == nil {
+				c.useArgs(refs)
+			}
+		}
+	}
+	return words
+}
+
+func (c *checker) useArgs(names []string) {
+	for _, name := range names {
+		if arg, ok := c.args[name]; ok {
+			arg.used = true
+		}
+	}
+}
+
+type warningsByLine []types.BuildWarning
+
+func (w warningsByLine) Len() int           { return len(w) }
+func (w warningsByLine) Less(i, j int) bool { return w[i].Line < w[j].Line }
+func (w warningsByLine) Swap(i, j int)      { w[i], w[j] = w[j], w[i] }
@@ -0,0 +1,69 @@
+package builder
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/docker/docker/api/types"
+)
+
+func TestCheck(t *testing.T) {
+	dockerfile := `FROM busybox
+MAINTAINER someone
+ARG used
+ARG unused=1
+ARG inrun
+ARG inlabel=2
+ENV dir /$used
+FROOM busybox
+ADD http://example.com/file.tar.gz \
+    /tmp/
+RUN ['echo', "$inrun"]
+CMD [echo, hello]
+LABEL dir=${dir} arg=$inlabel
+ONBUILD RUN ['echo']
+ONBUILD FROM busybox
+ONBUILD BOGUS
+COPY ${missing:?nope} /
+FROM ubuntu:14.04
+FROM scratch
+FROM debian@sha256:cbbf2f9a99b47fc460d422812b6a5adff7dfee951d8fa2e4a98caa0382cfbdbf
+`
+	warnings, err := Check(strings.NewReader(dockerfile), map[string]string{"inrun": "x"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []types.BuildWarning{
+		{Line: 1, Instruction: "FROM", Code: WarnFromWithoutTag, Message: "FROM busybox does not specify a tag, the latest tag will be used"},
+		{Line: 2, Instruction: "MAINTAINER", Code: WarnMaintainer, Message: "MAINTAINER is a candidate for deprecation, use a LABEL instead"},
+		{Line: 4, Instruction: "ARG", Code: WarnUnusedArg, Message: "ARG unused is never used"},
+		{Line: 8, Instruction: "FROOM", Code: WarnUnknownInstruction, Message: "Unknown instruction: FROOM"},
+		{Line: 9, Instruction: "ADD", Code: WarnAddRemoteURL, Message: "ADD of the remote URL http://example.com/file.tar.gz, its content is downloaded on every build; download it in a RUN instruction to control caching and cleanup"},
+		{Line: 11, Instruction: "RUN", Code: WarnJSONForm, Message: "RUN arguments look like a JSON array but are not valid JSON, they are used as a shell command (JSON strings must use double quotes)"},
+		{Line: 12, Instruction: "CMD", Code: WarnJSONForm, Message: "CMD arguments look like a JSON array but are not valid JSON, they are used as a shell command"},
+		{Line: 14, Instruction: "ONBUILD", Code: WarnJSONForm, Message: "RUN arguments look like a JSON array but are not valid JSON, they are used as a shell command (JSON strings must use double quotes)"},
+		{Line: 15, Instruction: "ONBUILD", Code: WarnOnbuildTrigger, Message: "FROM isn't allowed as an ONBUILD trigger"},
+		{Line: 16, Instruction: "ONBUILD", Code: WarnUnknownInstruction, Message: "Unknown instruction: BOGUS"},
+		{Line: 17, Instruction: "COPY", Code: WarnSubstitution, Message: "Unsupported modifier (?) in substitution: ${missing:?nope}"},
+	}
+	if !reflect.DeepEqual(warnings, expected) {
+		t.Fatalf("Expected warnings:\n%v\ngot:\n%v", expected, warnings)
+	}
+}
+
+func TestCheckMissingFrom(t *testing.T) {
+	warnings, err := Check(strings.NewReader("\n\nRUN\nFROM scratch\n"), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []types.BuildWarning{
+		{Line: 3, Instruction: "RUN", Code: WarnMissingFrom, Message: "The first instruction should be FROM, to set the base image"},
+		{Line: 3, Instruction: "RUN", Code: WarnMissingArguments, Message: "RUN requires at least one argument"},
+	}
+	if !reflect.DeepEqual(warnings, expected) {
+		t.Fatalf("Expected warnings:\n%v\ngot:\n%v", expected, warnings)
+	}
+}
@@ -15,6 +15,7 @@ import (
 	"regexp"
 	"runtime"
 	"sort"
+	"strconv"
 	"strings"
 
 	"github.com/Sirupsen/logrus"
@@ -214,10 +215,17 @@ func from(b *builder, args []string, attributes map[string]bool, original string
 		if err != nil {
 			return err
 		}
+	} else if err == nil && !b.matchesPlatform(image) {
+		// A local image built for another platform isn't used, nor is its
+		// tag changed: the image for the platform of the build is.
+		local := image
+		if image, err = b.platformImage(name); err != nil {
+			return fmt.Errorf("%s is built for %s/%s, and no image for %s could be found: %v", name, local.OS, local.Architecture, b.platform, err)
+		}
 	}
 	if err != nil {
 		if b.Daemon.Graph().IsNotExist(err, name) {
-			image, err = b.pullImage(name)
+			image, err = b.platformImage(name)
 		}
 
 		// note that the top level err will still be !nil here if IsNotExist is
@@ -227,7 +235,7 @@ func from(b *builder, args []string, attributes map[string]bool, original string
 		}
 	}
 
-	return b.processImageFrom(image)
+	return b.processImageFrom(name, image)
 }
 
 // ONBUILD RUN echo yo
@@ -605,6 +613,23 @@ func stopSignal(b *builder, args []string, attributes map[string]bool, original
 	return b.commit("", b.Config.Cmd, fmt.Sprintf("STOPSIGNAL %v", args))
 }
 
+// STOPTIMEOUT seconds
+//
+// Set the timeout to stop the container before it is killed.
+func stopTimeout(b *builder, args []string, attributes map[string]bool, original string) error {
+	if len(args) != 1 {
+		return fmt.Errorf("STOPTIMEOUT requires exactly one argument")
+	}
+
+	timeout, err := strconv.Atoi(args[0])
+	if err != nil {
+		return fmt.Errorf("STOPTIMEOUT requires a number of seconds, got %q", args[0])
+	}
+
+	b.Config.StopTimeout = &timeout
+	return b.commit("", b.Config.Cmd, fmt.Sprintf("STOPTIMEOUT %v", args))
+}
+
 // ARG name[=value]
 //
 // Adds the variable foo to the trusted list of variables that can be passed
@@ -25,15 +25,20 @@ import (
 	"os"
 	"path/filepath"
 	"runtime"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/Sirupsen/logrus"
 	"github.com/docker/docker/api"
+	"github.com/docker/docker/api/types"
 	"github.com/docker/docker/builder/command"
 	"github.com/docker/docker/builder/parser"
 	"github.com/docker/docker/cliconfig"
 	"github.com/docker/docker/daemon"
 	"github.com/docker/docker/pkg/fileutils"
+	"github.com/docker/docker/pkg/ioutils"
+	"github.com/docker/docker/pkg/jsonmessage"
 	"github.com/docker/docker/pkg/streamformatter"
 	"github.com/docker/docker/pkg/stringid"
 	"github.com/docker/docker/pkg/symlink"
@@ -45,38 +50,40 @@ import (
 
 // Environment variable interpolation will happen on these statements only.
 var replaceEnvAllowed = map[string]struct{}{
-	command.Env:        {},
-	command.Label:      {},
-	command.Add:        {},
-	command.Copy:       {},
-	command.Workdir:    {},
-	command.Expose:     {},
-	command.Volume:     {},
-	command.User:       {},
-	command.StopSignal: {},
-	command.Arg:        {},
+	command.Env:         {},
+	command.Label:       {},
+	command.Add:         {},
+	command.Copy:        {},
+	command.Workdir:     {},
+	command.Expose:      {},
+	command.Volume:      {},
+	command.User:        {},
+	command.StopSignal:  {},
+	command.StopTimeout: {},
+	command.Arg:         {},
 }
 
 var evaluateTable map[string]func(*builder, []string, map[string]bool, string) error
 
 func init() {
 	evaluateTable = map[string]func(*builder, []string, map[string]bool, string) error{
-		command.Env:        env,
-		command.Label:      label,
-		command.Maintainer: maintainer,
-		command.Add:        add,
-		command.Copy:       dispatchCopy, // copy() is a go builtin
-		command.From:       from,
-		command.Onbuild:    onbuild,
-		command.Workdir:    workdir,
-		command.Run:        run,
-		command.Cmd:        cmd,
-		command.Entrypoint: entrypoint,
-		command.Expose:     expose,
-		command.Volume:     volume,
-		command.User:       user,
-		command.StopSignal: stopSignal,
-		command.Arg:        arg,
+		command.Env:         env,
+		command.Label:       label,
+		command.Maintainer:  maintainer,
+		command.Add:         add,
+		command.Copy:        dispatchCopy, // copy() is a go builtin
+		command.From:        from,
+		command.Onbuild:     onbuild,
+		command.Workdir:     workdir,
+		command.Run:         run,
+		command.Cmd:         cmd,
+		command.Entrypoint:  entrypoint,
+		command.Expose:      expose,
+		command.Volume:      volume,
+		command.User:        user,
+		command.StopSignal:  stopSignal,
+		command.StopTimeout: stopTimeout,
+		command.Arg:         arg,
 	}
 }
 
@@ -111,6 +118,17 @@ type builder struct {
 	OutOld          io.Writer
 	StreamFormatter *streamformatter.StreamFormatter
 
+	// set this to true to send structured progress for the context
+	// transfer and each step, in addition to the human readable output.
+	jsonProgress bool
+	step         *types.BuildStepProgress // progress of the step being dispatched
+
+	// the base image whose ONBUILD triggers are being dispatched, if any.
+	triggerSource string
+
+	// the os/arch[/variant] the image is built for, if not the daemon's.
+	platform string
+
 	Config *runconfig.Config // runconfig for cmd, run, entrypoint etc.
 
 	buildArgs        map[string]string // build-time args received in build context for expansion/substitution and commands in 'run'.
@@ -159,9 +177,18 @@ type builder struct {
 // * Print a happy message and return the image ID.
 //
 func (b *builder) Run(context io.Reader) (string, error) {
-	if err := b.readContext(context); err != nil {
+	contextStart := time.Now()
+	contextCounter := ioutils.NewReadCounter(context)
+	if err := b.readContext(contextCounter); err != nil {
 		return "", err
 	}
+	b.sendProgress(&types.BuildProgress{
+		Context: &types.BuildContextProgress{
+			Size:  contextCounter.Count,
+			Start: contextStart,
+			End:   time.Now(),
+		},
+	})
 
 	defer func() {
 		if err := os.RemoveAll(b.contextPath); err != nil {
@@ -187,11 +214,16 @@ func (b *builder) Run(context io.Reader) (string, error) {
 		default:
 			// Not cancelled yet, keep going...
 		}
-		if err := b.dispatch(i, n); err != nil {
+		if err := b.dispatchStep(i, n); err != nil {
 			if b.ForceRemove {
 				b.clearTmp()
 			}
-			return "", err
+			return "", withErrorCode(&instructionError{
+				dockerfile: b.dockerfileName,
+				startLine:  n.StartLine,
+				endLine:    n.EndLine,
+				err:        err,
+			}, err)
 		}
 		fmt.Fprintf(b.OutStream, " ---> %s\n", stringid.TruncateID(b.image))
 		if b.Remove {
@@ -325,6 +357,9 @@ func (b *builder) dispatch(stepN int, ast *parser.Node) error {
 	flags := ast.Flags
 	strs := []string{}
 	msg := fmt.Sprintf("Step %d : %s", stepN+1, strings.ToUpper(cmd))
+	if b.triggerSource != "" {
+		msg = fmt.Sprintf("Trigger %d from %s : %s", stepN+1, b.triggerSource, strings.ToUpper(cmd))
+	}
 
 	if len(ast.Flags) > 0 {
 		msg += " " + strings.Join(ast.Flags, " ")
@@ -410,6 +445,63 @@ func (b *builder) dispatch(stepN int, ast *parser.Node) error {
 	return fmt.Errorf("Unknown instruction: %s", strings.ToUpper(cmd))
 }
 
+// instructionError is the error of an instruction of the Dockerfile, which
+// spans from startLine to endLine.
+type instructionError struct {
+	dockerfile string
+	startLine  int
+	endLine    int
+	err        error
+}
+
+func (e *instructionError) Error() string {
+	lines := strconv.Itoa(e.startLine)
+	if e.endLine > e.startLine {
+		lines += "-" + strconv.Itoa(e.endLine)
+	}
+	return fmt.Sprintf("%s:%s: %v", e.dockerfile, lines, e.err)
+}
+
+// withErrorCode returns err with the code of cause, if cause is a JSON error
+// carrying one, such as the exit status of a failed RUN, so that wrapping the
+// error doesn't change the exit status of the client.
+func withErrorCode(err, cause error) error {
+	if jerr, ok := cause.(*jsonmessage.JSONError); ok {
+		return &jsonmessage.JSONError{Message: err.Error(), Code: jerr.Code}
+	}
+	return err
+}
+
+// dispatchStep dispatches a single top level node of the Dockerfile and
+// reports it as a completed step when structured progress was requested.
+func (b *builder) dispatchStep(stepN int, ast *parser.Node) error {
+	b.step = &types.BuildStepProgress{
+		Step:        stepN + 1,
+		Instruction: ast.Original,
+		StartLine:   ast.StartLine,
+		EndLine:     ast.EndLine,
+		Start:       time.Now(),
+	}
+	err := b.dispatch(stepN, ast)
+
+	b.step.End = time.Now()
+	b.step.ImageID = b.image
+	if err != nil {
+		b.step.Error = err.Error()
+	}
+	b.sendProgress(&types.BuildProgress{Step: b.step})
+	b.step = nil
+	return err
+}
+
+// sendProgress writes structured progress to the client, if requested.
+func (b *builder) sendProgress(progress *types.BuildProgress) {
+	if !b.jsonProgress {
+		return
+	}
+	b.OutOld.Write(b.StreamFormatter.FormatAux(progress))
+}
+
 // platformSupports is a short-term function to give users a quality error
 // message if a Dockerfile uses a command not supported on the platform.
 func platformSupports(command string) error {
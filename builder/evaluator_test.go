@@ -0,0 +1,45 @@
+package builder
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/docker/docker/pkg/jsonmessage"
+)
+
+func TestInstructionError(t *testing.T) {
+	err := &instructionError{
+		dockerfile: "Dockerfile",
+		startLine:  3,
+		endLine:    3,
+		err:        fmt.Errorf("The command '/bin/sh -c false' returned a non-zero code: 1"),
+	}
+	if expected := "Dockerfile:3: The command '/bin/sh -c false' returned a non-zero code: 1"; err.Error() != expected {
+		t.Fatalf("Expected %q, got %q", expected, err.Error())
+	}
+
+	err.endLine = 5
+	if expected := "Dockerfile:3-5: The command '/bin/sh -c false' returned a non-zero code: 1"; err.Error() != expected {
+		t.Fatalf("Expected %q, got %q", expected, err.Error())
+	}
+}
+
+func TestWithErrorCode(t *testing.T) {
+	cause := &jsonmessage.JSONError{Message: "The command '/bin/sh -c exit 3' returned a non-zero code: 3", Code: 3}
+	err := withErrorCode(&instructionError{dockerfile: "Dockerfile", startLine: 2, endLine: 2, err: cause}, cause)
+	jerr, ok := err.(*jsonmessage.JSONError)
+	if !ok {
+		t.Fatalf("Expected a JSON error, got %T", err)
+	}
+	if jerr.Code != 3 {
+		t.Fatalf("Expected the code 3, got %d", jerr.Code)
+	}
+	if expected := "Dockerfile:2: " + cause.Message; jerr.Message != expected {
+		t.Fatalf("Expected %q, got %q", expected, jerr.Message)
+	}
+
+	err = withErrorCode(fmt.Errorf("wrapped"), fmt.Errorf("cause"))
+	if _, ok := err.(*jsonmessage.JSONError); ok {
+		t.Fatal("Expected errors without a code to be left as is")
+	}
+}
@@ -40,6 +40,7 @@ import (
 	"github.com/docker/docker/pkg/urlutil"
 	"github.com/docker/docker/registry"
 	"github.com/docker/docker/runconfig"
+	"github.com/docker/docker/utils"
 )
 
 func (b *builder) readContext(context io.Reader) (err error) {
@@ -125,6 +126,9 @@ func (b *builder) commit(id string, autoCmd *stringutils.StrSlice, comment strin
 		Pause:  true,
 		Config: &autoConfig,
 	}
+	if b.platform != "" {
+		commitCfg.OS, commitCfg.Architecture, _, _ = parsers.ParsePlatform(b.platform)
+	}
 
 	// Commit the container
 	image, err := b.Daemon.Commit(container, commitCfg)
@@ -505,15 +509,23 @@ func (b *builder) pullImage(name string) (*image.Image, error) {
 		pullRegistryAuth = &resolvedConfig
 	}
 
+	// An image pulled for the platform of the build is used by its ID: the
+	// local tag keeps referencing the image for the daemon's platform.
 	imagePullConfig := &graph.ImagePullConfig{
 		AuthConfig: pullRegistryAuth,
 		OutStream:  ioutils.NopWriteCloser(b.OutOld),
+		Platform:   b.platform,
+		KeepTags:   b.platform != "",
 	}
 
+	previousDigest := b.Daemon.Repositories().PlatformDigest(name, b.platform)
 	if err := b.Daemon.Repositories().Pull(remote, tag, imagePullConfig); err != nil {
 		return nil, err
 	}
 
+	if imagePullConfig.KeepTags {
+		return b.pulledPlatformImage(name, imagePullConfig.PulledID, previousDigest)
+	}
 	image, err := b.Daemon.Repositories().LookupImage(name)
 	if err != nil {
 		return nil, err
@@ -522,7 +534,73 @@ func (b *builder) pullImage(name string) (*image.Image, error) {
 	return image, nil
 }
 
-func (b *builder) processImageFrom(img *image.Image) error {
+// platformImage returns the image for the platform of the build referenced
+// by name. The image pulled for the platform by a previous build is reused if
+// it is still there, otherwise the image is pulled.
+func (b *builder) platformImage(name string) (*image.Image, error) {
+	if b.platform == "" {
+		return b.pullImage(name)
+	}
+	remote, _ := parsers.ParseRepositoryTag(name)
+	if dgst := b.Daemon.Repositories().PlatformDigest(name, b.platform); dgst != "" {
+		if img, err := b.Daemon.Repositories().LookupImage(utils.ImageReference(remote, dgst)); err == nil && b.matchesPlatform(img) {
+			return img, nil
+		}
+	}
+	return b.pullImage(name)
+}
+
+// pulledPlatformImage returns the image with the given ID, pulled by name for
+// the platform of the build without tagging it. The image previously pulled
+// for the platform, whose digest is given, is removed if it was replaced, and
+// so is the pulled image if it is for another platform.
+func (b *builder) pulledPlatformImage(name, id, previousDigest string) (*image.Image, error) {
+	img, err := b.Daemon.Graph().Get(id)
+	if err != nil {
+		return nil, err
+	}
+	remote, _ := parsers.ParseRepositoryTag(name)
+
+	if previousDigest != "" {
+		previous, err := b.Daemon.Repositories().LookupImage(utils.ImageReference(remote, previousDigest))
+		if err == nil && previous.ID != img.ID {
+			b.removePulledImage(utils.ImageReference(remote, previousDigest))
+		}
+	}
+
+	if !b.matchesPlatform(img) {
+		if dgst := b.Daemon.Repositories().PlatformDigest(name, b.platform); dgst != "" {
+			b.removePulledImage(utils.ImageReference(remote, dgst))
+		} else if !b.Daemon.Repositories().HasReferences(img) {
+			b.removePulledImage(img.ID)
+		}
+		return nil, fmt.Errorf("the image pulled is built for %s/%s", img.OS, img.Architecture)
+	}
+	return img, nil
+}
+
+// removePulledImage removes the image pulled for the platform of the build
+// with the given reference, along with its parents, unless it is used.
+func (b *builder) removePulledImage(ref string) {
+	if _, err := b.Daemon.ImageDelete(ref, false, true); err != nil {
+		logrus.Debugf("[BUILDER] not removing pulled image %s: %v", ref, err)
+	}
+}
+
+// matchesPlatform returns whether img was built for the platform of the
+// build. Images which don't record their platform match any platform.
+func (b *builder) matchesPlatform(img *image.Image) bool {
+	if b.platform == "" {
+		return true
+	}
+	platformOS, arch, _, err := parsers.ParsePlatform(b.platform)
+	if err != nil {
+		return false
+	}
+	return (img.OS == "" || img.OS == platformOS) && (img.Architecture == "" || img.Architecture == arch)
+}
+
+func (b *builder) processImageFrom(name string, img *image.Image) error {
 	b.image = img.ID
 
 	if img.Config != nil {
@@ -547,14 +625,22 @@ func (b *builder) processImageFrom(img *image.Image) error {
 	onBuildTriggers := b.Config.OnBuild
 	b.Config.OnBuild = []string{}
 
+	if b.step != nil {
+		b.step.Triggers = onBuildTriggers
+	}
+
+	// Steps run by the triggers are reported as coming from the base image.
+	b.triggerSource = name
+	defer func() { b.triggerSource = "" }()
+
 	// parse the ONBUILD triggers by invoking the parser
-	for _, step := range onBuildTriggers {
+	for i, step := range onBuildTriggers {
 		ast, err := parser.Parse(strings.NewReader(step))
 		if err != nil {
 			return err
 		}
 
-		for i, n := range ast.Children {
+		for _, n := range ast.Children {
 			switch strings.ToUpper(n.Value) {
 			case "ONBUILD":
 				return fmt.Errorf("Chaining ONBUILD via `ONBUILD ONBUILD` isn't allowed")
@@ -563,7 +649,7 @@ func (b *builder) processImageFrom(img *image.Image) error {
 			}
 
 			if err := b.dispatch(i, n); err != nil {
-				return err
+				return withErrorCode(fmt.Errorf("ONBUILD trigger %q from %s: %v", step, name, err), err)
 			}
 		}
 	}
@@ -593,6 +679,9 @@ func (b *builder) probeCache() (bool, error) {
 
 	fmt.Fprintf(b.OutStream, " ---> Using cache\n")
 	logrus.Debugf("[BUILDER] Use cached version")
+	if b.step != nil {
+		b.step.CacheHit = true
+	}
 	b.image = cache.ID
 	b.Daemon.Graph().Retain(b.id, cache.ID)
 	b.activeImages = append(b.activeImages, cache.ID)
@@ -630,6 +719,9 @@ func (b *builder) create() (*daemon.Container, error) {
 
 	b.TmpContainers[c.ID] = struct{}{}
 	fmt.Fprintf(b.OutStream, " ---> Running in %s\n", stringid.TruncateID(c.ID))
+	if b.step != nil {
+		b.step.ContainerID = c.ID
+	}
 
 	if config.Cmd.Len() > 0 {
 		// override the entry point that may have been picked up from the base image
@@ -79,6 +79,18 @@ type Config struct {
 	Ulimits        []*ulimit.Ulimit
 	AuthConfigs    map[string]cliconfig.AuthConfig
 	BuildArgs      map[string]string
+	// JSONProgress sends the context transfer and each step as
+	// types.BuildProgress in the output stream.
+	JSONProgress bool
+	// Session is the ID of the build context session to store the context
+	// in. If IncrementalContext is set, Context only contains the changes
+	// to the context held by the session.
+	Session            string
+	IncrementalContext bool
+	// Platform is the os/arch[/variant] the image is built for, when not
+	// the daemon's. Base images are pulled for it and it is recorded on
+	// the resulting images.
+	Platform string
 
 	Stdout  io.Writer
 	Context io.ReadCloser
@@ -132,7 +144,26 @@ func Build(d *daemon.Daemon, buildConfig *Config) error {
 		}
 	}
 
-	if buildConfig.RemoteURL == "" {
+	if buildConfig.Platform != "" {
+		if _, _, _, err := parsers.ParsePlatform(buildConfig.Platform); err != nil {
+			return err
+		}
+	}
+
+	if buildConfig.RemoteURL == "" && buildConfig.Session != "" {
+		session, err := openContextSession(sessionsRoot(d), buildConfig.Session)
+		if err != nil {
+			return err
+		}
+		defer session.Close()
+
+		if err := session.update(buildConfig.Context, buildConfig.IncrementalContext); err != nil {
+			return err
+		}
+		if context, err = session.tar(); err != nil {
+			return err
+		}
+	} else if buildConfig.RemoteURL == "" {
 		context = ioutil.NopCloser(buildConfig.Context)
 	} else if urlutil.IsGitURL(buildConfig.RemoteURL) {
 		root, err := utils.GitClone(buildConfig.RemoteURL)
@@ -211,6 +242,8 @@ func Build(d *daemon.Daemon, buildConfig *Config) error {
 		Pull:             buildConfig.Pull,
 		OutOld:           buildConfig.Stdout,
 		StreamFormatter:  sf,
+		jsonProgress:     buildConfig.JSONProgress,
+		platform:         buildConfig.Platform,
 		AuthConfigs:      buildConfig.AuthConfigs,
 		dockerfileName:   buildConfig.DockerfileName,
 		cpuShares:        buildConfig.CPUShares,
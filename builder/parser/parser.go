@@ -30,6 +30,8 @@ type Node struct {
 	Attributes map[string]bool // special attributes for this node
 	Original   string          // original line used before parsing
 	Flags      []string        // only top Node should have this set
+	StartLine  int             // the line in the original dockerfile where the node begins
+	EndLine    int             // the line in the original dockerfile where the node ends
 }
 
 var (
@@ -47,22 +49,23 @@ func init() {
 	// functions. Errors are propagated up by Parse() and the resulting AST can
 	// be incorporated directly into the existing AST as a next.
 	dispatch = map[string]func(string) (*Node, map[string]bool, error){
-		command.User:       parseString,
-		command.Onbuild:    parseSubCommand,
-		command.Workdir:    parseString,
-		command.Env:        parseEnv,
-		command.Label:      parseLabel,
-		command.Maintainer: parseString,
-		command.From:       parseString,
-		command.Add:        parseMaybeJSONToList,
-		command.Copy:       parseMaybeJSONToList,
-		command.Run:        parseMaybeJSON,
-		command.Cmd:        parseMaybeJSON,
-		command.Entrypoint: parseMaybeJSON,
-		command.Expose:     parseStringsWhitespaceDelimited,
-		command.Volume:     parseMaybeJSONToList,
-		command.StopSignal: parseString,
-		command.Arg:        parseNameOrNameVal,
+		command.User:        parseString,
+		command.Onbuild:     parseSubCommand,
+		command.Workdir:     parseString,
+		command.Env:         parseEnv,
+		command.Label:       parseLabel,
+		command.Maintainer:  parseString,
+		command.From:        parseString,
+		command.Add:         parseMaybeJSONToList,
+		command.Copy:        parseMaybeJSONToList,
+		command.Run:         parseMaybeJSON,
+		command.Cmd:         parseMaybeJSON,
+		command.Entrypoint:  parseMaybeJSON,
+		command.Expose:      parseStringsWhitespaceDelimited,
+		command.Volume:      parseMaybeJSONToList,
+		command.StopSignal:  parseString,
+		command.StopTimeout: parseString,
+		command.Arg:         parseNameOrNameVal,
 	}
 }
 
@@ -103,8 +106,11 @@ func parseLine(line string) (string, *Node, error) {
 func Parse(rwc io.Reader) (*Node, error) {
 	root := &Node{}
 	scanner := bufio.NewScanner(rwc)
+	currentLine := 0
 
 	for scanner.Scan() {
+		currentLine++
+		startLine := currentLine
 		scannedLine := strings.TrimLeftFunc(scanner.Text(), unicode.IsSpace)
 		line, child, err := parseLine(scannedLine)
 		if err != nil {
@@ -113,6 +119,7 @@ func Parse(rwc io.Reader) (*Node, error) {
 
 		if line != "" && child == nil {
 			for scanner.Scan() {
+				currentLine++
 				newline := scanner.Text()
 
 				if stripComments(strings.TrimSpace(newline)) == "" {
@@ -137,6 +144,13 @@ func Parse(rwc io.Reader) (*Node, error) {
 		}
 
 		if child != nil {
+			child.StartLine = startLine
+			child.EndLine = currentLine
+			// ONBUILD triggers span the same lines as their ONBUILD
+			if child.Next != nil && len(child.Next.Children) > 0 {
+				child.Next.Children[0].StartLine = startLine
+				child.Next.Children[0].EndLine = currentLine
+			}
 			root.Children = append(root.Children, child)
 		}
 	}
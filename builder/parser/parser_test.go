@@ -5,6 +5,7 @@ import (
 	"io/ioutil"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 )
 
@@ -110,3 +111,36 @@ func TestParseWords(t *testing.T) {
 		}
 	}
 }
+
+func TestParseLineNumbers(t *testing.T) {
+	dockerfile := `# comment
+FROM busybox
+
+RUN echo hello \
+    world \
+
+    again
+ENV FOO=bar
+ONBUILD RUN echo \
+    onbuild
+`
+	ast, err := Parse(strings.NewReader(dockerfile))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := [][2]int{{2, 2}, {4, 7}, {8, 8}, {9, 10}}
+	if len(ast.Children) != len(expected) {
+		t.Fatalf("Expected %d instructions, got %d", len(expected), len(ast.Children))
+	}
+	for i, child := range ast.Children {
+		if child.StartLine != expected[i][0] || child.EndLine != expected[i][1] {
+			t.Fatalf("Expected %s to span lines %d-%d, got %d-%d", child.Value, expected[i][0], expected[i][1], child.StartLine, child.EndLine)
+		}
+	}
+
+	trigger := ast.Children[3].Next.Children[0]
+	if trigger.StartLine != 9 || trigger.EndLine != 10 {
+		t.Fatalf("Expected the ONBUILD trigger to span lines 9-10, got %d-%d", trigger.StartLine, trigger.EndLine)
+	}
+}
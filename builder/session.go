@@ -0,0 +1,274 @@
+package builder
+
+import (
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Sirupsen/logrus"
+	"github.com/docker/docker/daemon"
+	derr "github.com/docker/docker/errors"
+	"github.com/docker/docker/pkg/archive"
+	"github.com/docker/docker/pkg/chrootarchive"
+	"github.com/docker/docker/pkg/tarsum"
+)
+
+// A build context session lets a client upload only the parts of its build
+// context which changed since its previous build. The daemon keeps the last
+// context received for each session unpacked under its root directory, along
+// with the tarsum of each file as computed from the client's tar stream, so
+// that the client can compare them with the sums of its local files.
+
+// sessionMaxAge is how long an unused session is kept around.
+const sessionMaxAge = 7 * 24 * time.Hour
+
+var validSessionID = regexp.MustCompile(`^[a-f0-9]{64}$`)
+
+// sessionLock serializes the builds using the same session. refs counts the
+// builds holding or waiting for it, so that it can be dropped once unused.
+type sessionLock struct {
+	sync.Mutex
+	refs int
+}
+
+// sessionLocks holds the locks of the sessions in use.
+var sessionLocks = struct {
+	sync.Mutex
+	m map[string]*sessionLock
+}{m: make(map[string]*sessionLock)}
+
+// contextSession is a locked build context session. Close must be called to
+// release it.
+type contextSession struct {
+	id   string
+	root string
+	lock *sessionLock
+}
+
+func sessionsRoot(d *daemon.Daemon) string {
+	return filepath.Join(d.Root(), "builder", "sessions")
+}
+
+// openContextSession locks the session with the given ID among the sessions
+// kept in root. Sessions unused for longer than sessionMaxAge are removed
+// along the way.
+func openContextSession(root, id string) (*contextSession, error) {
+	if !validSessionID.MatchString(id) {
+		return nil, derr.ErrorCodeInvalidBuildSession.WithArgs(id)
+	}
+
+	sessionLocks.Lock()
+	expired := expiredContextSessions(root)
+	lock := refSessionLock(id)
+	sessionLocks.Unlock()
+
+	// The expired sessions are locked, so they can be removed without
+	// blocking the builds using other sessions.
+	for _, s := range expired {
+		logrus.Debugf("[BUILDER] removing unused build session %s", s.id)
+		if err := os.RemoveAll(s.root); err != nil {
+			logrus.Debugf("[BUILDER] failed to remove build session %s: %s", s.id, err)
+		}
+		s.Close()
+	}
+
+	lock.Lock()
+	return &contextSession{
+		id:   id,
+		root: filepath.Join(root, id),
+		lock: lock,
+	}, nil
+}
+
+// refSessionLock returns the lock of the session with the given ID, and
+// counts a reference to it. It must be called with sessionLocks held.
+func refSessionLock(id string) *sessionLock {
+	lock, ok := sessionLocks.m[id]
+	if !ok {
+		lock = &sessionLock{}
+		sessionLocks.m[id] = lock
+	}
+	lock.refs++
+	return lock
+}
+
+// expiredContextSessions locks and returns the sessions which are not in use
+// and whose context was last updated more than sessionMaxAge ago. It must be
+// called with sessionLocks held.
+func expiredContextSessions(root string) []*contextSession {
+	dirs, err := ioutil.ReadDir(root)
+	if err != nil {
+		return nil
+	}
+	var expired []*contextSession
+	for _, dir := range dirs {
+		if _, inUse := sessionLocks.m[dir.Name()]; inUse {
+			continue
+		}
+		if time.Since(dir.ModTime()) < sessionMaxAge {
+			continue
+		}
+		lock := refSessionLock(dir.Name())
+		lock.Lock()
+		expired = append(expired, &contextSession{
+			id:   dir.Name(),
+			root: filepath.Join(root, dir.Name()),
+			lock: lock,
+		})
+	}
+	return expired
+}
+
+// ContextSessionSums returns the tarsums of the files in the build context
+// held for a session, keyed by their path in the context.
+func ContextSessionSums(d *daemon.Daemon, id string) (map[string]string, error) {
+	s, err := openContextSession(sessionsRoot(d), id)
+	if err != nil {
+		return nil, err
+	}
+	defer s.Close()
+
+	sums, err := s.sums()
+	if err != nil {
+		return nil, err
+	}
+	if sums == nil {
+		return nil, derr.ErrorCodeNoSuchBuildSession.WithArgs(id)
+	}
+	return sums, nil
+}
+
+func (s *contextSession) contextDir() string {
+	return filepath.Join(s.root, "context")
+}
+
+func (s *contextSession) sumsPath() string {
+	return filepath.Join(s.root, "sums.json")
+}
+
+// sums loads the tarsums of the session's context. It returns nil if the
+// session doesn't hold a complete context.
+func (s *contextSession) sums() (map[string]string, error) {
+	f, err := os.Open(s.sumsPath())
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	defer f.Close()
+
+	var sums map[string]string
+	if err := json.NewDecoder(f).Decode(&sums); err != nil {
+		return nil, err
+	}
+	return sums, nil
+}
+
+// update stores the given build context in the session. If incremental is
+// true, context is a layer of the changes to apply to the session's current
+// context, where deleted files are marked with whiteouts; otherwise it
+// replaces the whole context.
+func (s *contextSession) update(context io.Reader, incremental bool) error {
+	var sums map[string]string
+	if incremental {
+		var err error
+		if sums, err = s.sums(); err != nil {
+			return err
+		}
+		if sums == nil {
+			return derr.ErrorCodeNoSuchBuildSession.WithArgs(s.id)
+		}
+	} else {
+		if err := os.RemoveAll(s.root); err != nil {
+			return err
+		}
+		sums = make(map[string]string)
+	}
+
+	// The context is only valid again once it was fully updated.
+	if err := os.Remove(s.sumsPath()); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	if err := os.MkdirAll(s.contextDir(), 0700); err != nil {
+		return err
+	}
+
+	decompressedStream, err := archive.DecompressStream(context)
+	if err != nil {
+		return err
+	}
+	ts, err := tarsum.NewTarSum(decompressedStream, true, tarsum.Version1)
+	if err != nil {
+		return err
+	}
+	if incremental {
+		_, err = chrootarchive.ApplyUncompressedLayer(s.contextDir(), ts)
+	} else {
+		err = chrootarchive.Untar(ts, s.contextDir(), nil)
+	}
+	if err != nil {
+		return err
+	}
+	// Make sure the sum of the last file was computed.
+	if _, err := io.Copy(ioutil.Discard, ts); err != nil {
+		return err
+	}
+
+	applySums(sums, ts.GetSums())
+
+	f, err := os.OpenFile(s.sumsPath(), os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
+	if err != nil {
+		return err
+	}
+	if err := json.NewEncoder(f).Encode(sums); err != nil {
+		f.Close()
+		os.Remove(s.sumsPath())
+		return err
+	}
+	return f.Close()
+}
+
+// applySums updates the sums of a context with the sums of the files of a
+// tar stream applied to it. Whiteouts in the stream remove the sums of the
+// files they delete.
+func applySums(sums map[string]string, changes tarsum.FileInfoSums) {
+	for _, fis := range changes {
+		name := fis.Name()
+		base := path.Base(name)
+		if !strings.HasPrefix(base, ".wh.") {
+			sums[name] = fis.Sum()
+			continue
+		}
+		removed := path.Join(path.Dir(name), strings.TrimPrefix(base, ".wh."))
+		for file := range sums {
+			if file == removed || strings.HasPrefix(file, removed+"/") {
+				delete(sums, file)
+			}
+		}
+	}
+}
+
+// tar returns the session's context as a tar stream.
+func (s *contextSession) tar() (io.ReadCloser, error) {
+	return archive.Tar(s.contextDir(), archive.Uncompressed)
+}
+
+// Close releases the session.
+func (s *contextSession) Close() {
+	s.lock.Unlock()
+
+	sessionLocks.Lock()
+	s.lock.refs--
+	if s.lock.refs == 0 {
+		delete(sessionLocks.m, s.id)
+	}
+	sessionLocks.Unlock()
+}
@@ -0,0 +1,145 @@
+package builder
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/docker/docker/pkg/tarsum"
+)
+
+type testFileInfoSum struct {
+	name, sum string
+}
+
+func (fis testFileInfoSum) Name() string { return fis.name }
+func (fis testFileInfoSum) Sum() string  { return fis.sum }
+func (fis testFileInfoSum) Pos() int64   { return 0 }
+
+func TestApplySums(t *testing.T) {
+	sums := map[string]string{
+		"a":       "1",
+		"b":       "2",
+		"dir":     "3",
+		"dir/c":   "4",
+		"dir2":    "5",
+		"dir2/d":  "6",
+		"dirname": "7",
+	}
+	applySums(sums, tarsum.FileInfoSums{
+		testFileInfoSum{"a", "8"},
+		testFileInfoSum{"e", "9"},
+		testFileInfoSum{".wh.dir", "10"},
+		testFileInfoSum{"dir2/.wh.d", "11"},
+		testFileInfoSum{".wh.missing", "12"},
+	})
+
+	expected := map[string]string{
+		"a":       "8",
+		"b":       "2",
+		"dir2":    "5",
+		"dirname": "7",
+		"e":       "9",
+	}
+	if !reflect.DeepEqual(sums, expected) {
+		t.Fatalf("Expected %v, got %v", expected, sums)
+	}
+}
+
+func TestValidSessionID(t *testing.T) {
+	valid := "4e07408562bedb8b60ce05c1decfe3ad16b72230967de01f640b7e4729b49fce"
+	if !validSessionID.MatchString(valid) {
+		t.Fatalf("Expected %s to be a valid session ID", valid)
+	}
+	for _, id := range []string{"", "abc", "../" + valid[3:], valid + "0", "4E07408562BEDB8B60CE05C1DECFE3AD16B72230967DE01F640B7E4729B49FCE"} {
+		if validSessionID.MatchString(id) {
+			t.Fatalf("Expected %q to be an invalid session ID", id)
+		}
+	}
+}
+
+func TestContextSessionLocksReleased(t *testing.T) {
+	root, err := ioutil.TempDir("", "docker-build-session-test-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+	id := "4e07408562bedb8b60ce05c1decfe3ad16b72230967de01f640b7e4729b49fce"
+
+	s, err := openContextSession(root, id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	acquired := make(chan *contextSession)
+	go func() {
+		s, err := openContextSession(root, id)
+		if err != nil {
+			t.Error(err)
+		}
+		acquired <- s
+	}()
+	select {
+	case <-acquired:
+		t.Fatal("Expected the session to be locked")
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	s.Close()
+	s = <-acquired
+	sessionLocks.Lock()
+	_, inUse := sessionLocks.m[id]
+	sessionLocks.Unlock()
+	if !inUse {
+		t.Fatal("Expected the session to be in use")
+	}
+
+	s.Close()
+	sessionLocks.Lock()
+	_, inUse = sessionLocks.m[id]
+	sessionLocks.Unlock()
+	if inUse {
+		t.Fatal("Expected the session lock to be released")
+	}
+}
+
+func TestPruneContextSessions(t *testing.T) {
+	root, err := ioutil.TempDir("", "docker-build-session-test-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+	id := "4e07408562bedb8b60ce05c1decfe3ad16b72230967de01f640b7e4729b49fce"
+
+	// a session used once and unused for longer than sessionMaxAge
+	s, err := openContextSession(root, id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.MkdirAll(s.contextDir(), 0700); err != nil {
+		t.Fatal(err)
+	}
+	s.Close()
+	old := time.Now().Add(-sessionMaxAge - time.Hour)
+	if err := os.Chtimes(filepath.Join(root, id), old, old); err != nil {
+		t.Fatal(err)
+	}
+
+	// opening another session prunes the expired ones
+	other, err := openContextSession(root, strings.Repeat("a", 64))
+	if err != nil {
+		t.Fatal(err)
+	}
+	other.Close()
+	if _, err := os.Stat(filepath.Join(root, id)); !os.IsNotExist(err) {
+		t.Fatalf("Expected the unused session to be removed, got %v", err)
+	}
+	sessionLocks.Lock()
+	defer sessionLocks.Unlock()
+	if len(sessionLocks.m) != 0 {
+		t.Fatalf("Expected the session locks to be released, got %d", len(sessionLocks.m))
+	}
+}
@@ -16,6 +16,7 @@ type shellWord struct {
 	word string
 	envs []string
 	pos  int
+	refs []string
 }
 
 // ProcessWord will use the 'env' list of environment variables,
@@ -29,6 +30,18 @@ func ProcessWord(word string, env []string) (string, error) {
 	return sw.process()
 }
 
+// processWordRefs is like ProcessWord but also returns the names of the
+// variables referenced in 'word'.
+func processWordRefs(word string, env []string) (string, []string, error) {
+	sw := &shellWord{
+		word: word,
+		envs: env,
+		pos:  0,
+	}
+	result, err := sw.process()
+	return result, sw.refs, err
+}
+
 func (sw *shellWord) process() (string, error) {
 	return sw.processStopOn('\000')
 }
@@ -224,6 +237,7 @@ func (sw *shellWord) processName() string {
 }
 
 func (sw *shellWord) getEnv(name string) string {
+	sw.refs = append(sw.refs, name)
 	for _, env := range sw.envs {
 		i := strings.Index(env, "=")
 		if i < 0 {
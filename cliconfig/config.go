@@ -52,6 +52,9 @@ type AuthConfig struct {
 	Auth          string `json:"auth"`
 	Email         string `json:"email"`
 	ServerAddress string `json:"serveraddress,omitempty"`
+	// IdentityToken is the refresh token the token server of the registry
+	// issued to the user at login, used instead of the password.
+	IdentityToken string `json:"identitytoken,omitempty"`
 }
 
 // ConfigFile ~/.docker/config.json file info
@@ -59,7 +62,14 @@ type ConfigFile struct {
 	AuthConfigs map[string]AuthConfig `json:"auths"`
 	HTTPHeaders map[string]string     `json:"HttpHeaders,omitempty"`
 	PsFormat    string                `json:"psFormat,omitempty"`
-	filename    string                // Note: not serialized - for internal use only
+	// CredentialsStore is the name of the credentials helper program
+	// (docker-credential-<name>) storing the registry credentials. If
+	// empty, they are stored in this file.
+	CredentialsStore string `json:"credsStore,omitempty"`
+	// CredentialHelpers overrides CredentialsStore for some registries,
+	// keyed by server address.
+	CredentialHelpers map[string]string `json:"credHelpers,omitempty"`
+	filename          string            // Note: not serialized - for internal use only
 }
 
 // NewConfigFile initilizes an empty configuration file for the given filename 'fn'
@@ -122,9 +132,12 @@ func (configFile *ConfigFile) LoadFromReader(configData io.Reader) error {
 	}
 	var err error
 	for addr, ac := range configFile.AuthConfigs {
-		ac.Username, ac.Password, err = DecodeAuth(ac.Auth)
-		if err != nil {
-			return err
+		// Entries of credentials kept by a credentials store have no auth.
+		if ac.Auth != "" {
+			ac.Username, ac.Password, err = DecodeAuth(ac.Auth)
+			if err != nil {
+				return err
+			}
 		}
 		ac.Auth = ""
 		ac.ServerAddress = addr
@@ -203,7 +216,9 @@ func (configFile *ConfigFile) SaveToWriter(writer io.Writer) error {
 	for k, authConfig := range configFile.AuthConfigs {
 		authCopy := authConfig
 		// encode and save the authstring, while blanking out the original fields
-		authCopy.Auth = EncodeAuth(&authCopy)
+		if authCopy.Username != "" || authCopy.Password != "" {
+			authCopy.Auth = EncodeAuth(&authCopy)
+		}
 		authCopy.Username = ""
 		authCopy.Password = ""
 		authCopy.ServerAddress = ""
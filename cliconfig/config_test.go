@@ -1,6 +1,7 @@
 package cliconfig
 
 import (
+	"bytes"
 	"io/ioutil"
 	"os"
 	"path/filepath"
@@ -399,6 +400,38 @@ func TestJsonWithPsFormatNoFile(t *testing.T) {
 
 }
 
+func TestJsonWithCredentialsStoreNoFile(t *testing.T) {
+	js := `{
+		"auths": { "https://index.docker.io/v1/": { "email": "user@example.com" } },
+		"credsStore": "osxkeychain",
+		"credHelpers": { "registry.example.com": "secretservice" }
+}`
+	config, err := LoadFromReader(strings.NewReader(js))
+	if err != nil {
+		t.Fatalf("Failed loading on empty json file: %q", err)
+	}
+
+	if config.CredentialsStore != "osxkeychain" {
+		t.Fatalf("Unknown credentials store: %s\n", config.CredentialsStore)
+	}
+	if helper := config.CredentialHelpers["registry.example.com"]; helper != "secretservice" {
+		t.Fatalf("Unknown credentials helper: %s\n", helper)
+	}
+	ac := config.AuthConfigs["https://index.docker.io/v1/"]
+	if ac.Email != "user@example.com" || ac.Username != "" || ac.Password != "" {
+		t.Fatalf("Unexpected auth config: %v", ac)
+	}
+
+	var buf bytes.Buffer
+	if err := config.SaveToWriter(&buf); err != nil {
+		t.Fatalf("Failed saving: %q", err)
+	}
+	if !strings.Contains(buf.String(), `"auth": ""`) ||
+		!strings.Contains(buf.String(), `"credsStore": "osxkeychain"`) {
+		t.Fatalf("Unexpected saved config: %s", buf.String())
+	}
+}
+
 func TestJsonSaveWithNoFile(t *testing.T) {
 	js := `{
 		"auths": { "https://index.docker.io/v1/": { "auth": "am9lam9lOmhlbGxv", "email": "user@example.com" } },
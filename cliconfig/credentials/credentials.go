@@ -0,0 +1,61 @@
+// Package credentials stores the registry credentials of the docker client,
+// either in its configuration file or through an external credentials helper
+// program.
+package credentials
+
+import (
+	"github.com/docker/docker/cliconfig"
+)
+
+// Store is the interface that any credentials store must implement.
+type Store interface {
+	// Erase removes credentials from the store for a given server.
+	Erase(serverAddress string) error
+	// Get retrieves credentials from the store for a given server. It
+	// returns empty credentials if there are none.
+	Get(serverAddress string) (cliconfig.AuthConfig, error)
+	// GetAll retrieves all the credentials from the store.
+	GetAll() (map[string]cliconfig.AuthConfig, error)
+	// Store saves credentials in the store.
+	Store(authConfig cliconfig.AuthConfig) error
+}
+
+// Helper returns the name of the credentials helper configured for the
+// given server, or an empty string if its credentials are stored in the
+// configuration file.
+func Helper(c *cliconfig.ConfigFile, serverAddress string) string {
+	if helper := c.CredentialHelpers[serverAddress]; helper != "" {
+		return helper
+	}
+	return c.CredentialsStore
+}
+
+// DetectStore returns the credentials store to use for the given server,
+// as configured in the configuration file.
+func DetectStore(c *cliconfig.ConfigFile, serverAddress string) Store {
+	if helper := Helper(c, serverAddress); helper != "" {
+		return NewNativeStore(c, helper)
+	}
+	return NewFileStore(c)
+}
+
+// GetAll retrieves the credentials of all the servers from the stores
+// configured in the configuration file.
+func GetAll(c *cliconfig.ConfigFile) (map[string]cliconfig.AuthConfig, error) {
+	auths, err := DetectStore(c, "").GetAll()
+	if err != nil {
+		return nil, err
+	}
+	for serverAddress := range c.CredentialHelpers {
+		authConfig, err := DetectStore(c, serverAddress).Get(serverAddress)
+		if err != nil {
+			return nil, err
+		}
+		if authConfig.Username != "" {
+			auths[serverAddress] = authConfig
+		} else {
+			delete(auths, serverAddress)
+		}
+	}
+	return auths, nil
+}
@@ -0,0 +1,44 @@
+package credentials
+
+import (
+	"github.com/docker/docker/cliconfig"
+)
+
+// fileStore implements a credentials store using the docker configuration
+// file to keep the credentials in plain text.
+type fileStore struct {
+	file *cliconfig.ConfigFile
+}
+
+// NewFileStore creates a new file credentials store.
+func NewFileStore(file *cliconfig.ConfigFile) Store {
+	return &fileStore{
+		file: file,
+	}
+}
+
+// Erase removes the given credentials from the file store.
+func (c *fileStore) Erase(serverAddress string) error {
+	delete(c.file.AuthConfigs, serverAddress)
+	return c.file.Save()
+}
+
+// Get retrieves credentials for a specific server from the file store.
+func (c *fileStore) Get(serverAddress string) (cliconfig.AuthConfig, error) {
+	return c.file.AuthConfigs[serverAddress], nil
+}
+
+// GetAll retrieves all the credentials from the file store.
+func (c *fileStore) GetAll() (map[string]cliconfig.AuthConfig, error) {
+	auths := make(map[string]cliconfig.AuthConfig, len(c.file.AuthConfigs))
+	for serverAddress, authConfig := range c.file.AuthConfigs {
+		auths[serverAddress] = authConfig
+	}
+	return auths, nil
+}
+
+// Store saves the given credentials in the file store.
+func (c *fileStore) Store(authConfig cliconfig.AuthConfig) error {
+	c.file.AuthConfigs[authConfig.ServerAddress] = authConfig
+	return c.file.Save()
+}
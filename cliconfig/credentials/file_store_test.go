@@ -0,0 +1,71 @@
+package credentials
+
+import (
+	"os"
+	"testing"
+
+	"github.com/docker/docker/cliconfig"
+)
+
+func TestFileStore(t *testing.T) {
+	file := newTestConfigFile(t, map[string]cliconfig.AuthConfig{
+		"https://example.com": {Username: "foo", Password: "bar"},
+	})
+	defer os.Remove(file.Filename())
+	s := NewFileStore(file)
+
+	if err := s.Store(cliconfig.AuthConfig{Username: "baz", Password: "qux", ServerAddress: "https://other.example.com"}); err != nil {
+		t.Fatal(err)
+	}
+
+	loaded, err := cliconfig.LoadFromReader(mustOpen(t, file.Filename()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if a := loaded.AuthConfigs["https://other.example.com"]; a.Username != "baz" || a.Password != "qux" {
+		t.Fatalf("Expected the credentials to be saved, got %+v", a)
+	}
+
+	auths, err := s.GetAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(auths) != 2 {
+		t.Fatalf("Expected 2 credentials, got %d", len(auths))
+	}
+
+	if err := s.Erase("https://example.com"); err != nil {
+		t.Fatal(err)
+	}
+	if a, err := s.Get("https://example.com"); err != nil || a.Username != "" {
+		t.Fatalf("Expected no credentials, got %+v, %v", a, err)
+	}
+}
+
+func TestDetectStore(t *testing.T) {
+	file := cliconfig.NewConfigFile("")
+	if _, ok := DetectStore(file, "https://example.com").(*fileStore); !ok {
+		t.Fatal("Expected the file store by default")
+	}
+
+	file.CredentialsStore = "secretservice"
+	file.CredentialHelpers = map[string]string{"registry.example.com": "pass"}
+	if _, ok := DetectStore(file, "https://example.com").(*nativeStore); !ok {
+		t.Fatal("Expected the native store with credsStore")
+	}
+	s, ok := DetectStore(file, "registry.example.com").(*nativeStore)
+	if !ok {
+		t.Fatal("Expected the native store with credHelpers")
+	}
+	if p := s.programFunc("get").(*shell); p.cmd.Args[0] != "docker-credential-pass" {
+		t.Fatalf("Expected the pass helper, got %s", p.cmd.Args[0])
+	}
+}
+
+func mustOpen(t *testing.T, name string) *os.File {
+	f, err := os.Open(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return f
+}
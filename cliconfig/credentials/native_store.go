@@ -0,0 +1,187 @@
+package credentials
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os/exec"
+	"strings"
+
+	"github.com/docker/docker/cliconfig"
+)
+
+const (
+	// helperPrefix is the prefix of the name of the credentials helper
+	// programs, e.g. docker-credential-osxkeychain.
+	helperPrefix = "docker-credential-"
+	// errCredentialsNotFoundMessage is the output of a helper asked for
+	// credentials it doesn't have.
+	errCredentialsNotFoundMessage = "credentials not found in native keychain"
+	// tokenUsername is the username of the credentials whose secret is an
+	// identity token rather than a password.
+	tokenUsername = "<token>"
+)
+
+// helperCredentials is the representation of credentials in the protocol
+// spoken with the credentials helpers.
+type helperCredentials struct {
+	ServerURL string
+	Username  string
+	Secret    string
+}
+
+// program runs a credentials helper with the given action as argument.
+type program interface {
+	// Input sets the standard input of the program.
+	Input(in io.Reader)
+	// Output runs the program and returns its standard output.
+	Output() ([]byte, error)
+}
+
+// programFunc creates a program for an action.
+type programFunc func(action string) program
+
+// shell runs a credentials helper as an external command.
+type shell struct {
+	cmd *exec.Cmd
+}
+
+func (s *shell) Input(in io.Reader) {
+	s.cmd.Stdin = in
+}
+
+func (s *shell) Output() ([]byte, error) {
+	return s.cmd.Output()
+}
+
+func newShellProgramFunc(name string) programFunc {
+	return func(action string) program {
+		return &shell{cmd: exec.Command(name, action)}
+	}
+}
+
+// nativeStore implements a credentials store using a credentials helper
+// program, e.g. one backed by the keychain of the operating system. The
+// email addresses, which the helpers don't store, are kept in a file store,
+// along with the usernames of the identity tokens.
+type nativeStore struct {
+	programFunc programFunc
+	fileStore   Store
+}
+
+// NewNativeStore creates a new credentials store using the
+// docker-credential-<helper> program.
+func NewNativeStore(file *cliconfig.ConfigFile, helper string) Store {
+	return &nativeStore{
+		programFunc: newShellProgramFunc(helperPrefix + helper),
+		fileStore:   NewFileStore(file),
+	}
+}
+
+// Erase removes the given credentials from the native store.
+func (c *nativeStore) Erase(serverAddress string) error {
+	if _, err := c.run("erase", strings.NewReader(serverAddress)); err != nil {
+		return err
+	}
+	// Fallback to plain text store to remove email
+	return c.fileStore.Erase(serverAddress)
+}
+
+// Get retrieves credentials for a specific server from the native store.
+func (c *nativeStore) Get(serverAddress string) (cliconfig.AuthConfig, error) {
+	// Load the email from the file store.
+	authConfig, _ := c.fileStore.Get(serverAddress)
+
+	out, err := c.run("get", strings.NewReader(serverAddress))
+	if err != nil {
+		if strings.TrimSpace(err.Error()) == errCredentialsNotFoundMessage {
+			return authConfig, nil
+		}
+		return authConfig, err
+	}
+
+	var creds helperCredentials
+	if err := json.NewDecoder(bytes.NewReader(out)).Decode(&creds); err != nil {
+		return authConfig, err
+	}
+	if creds.Username == tokenUsername {
+		authConfig.IdentityToken = creds.Secret
+	} else {
+		authConfig.Username = creds.Username
+		authConfig.Password = creds.Secret
+	}
+	authConfig.ServerAddress = serverAddress
+	return authConfig, nil
+}
+
+// GetAll retrieves all the credentials from the native store.
+func (c *nativeStore) GetAll() (map[string]cliconfig.AuthConfig, error) {
+	out, err := c.run("list", nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var servers map[string]string
+	if err := json.NewDecoder(bytes.NewReader(out)).Decode(&servers); err != nil {
+		return nil, err
+	}
+
+	auths := make(map[string]cliconfig.AuthConfig, len(servers))
+	for serverAddress := range servers {
+		authConfig, err := c.Get(serverAddress)
+		if err != nil {
+			return nil, err
+		}
+		auths[serverAddress] = authConfig
+	}
+	return auths, nil
+}
+
+// Store saves the given credentials in the native store. Only the email,
+// and the username of an identity token, are saved in the file store.
+func (c *nativeStore) Store(authConfig cliconfig.AuthConfig) error {
+	creds := &helperCredentials{
+		ServerURL: authConfig.ServerAddress,
+		Username:  authConfig.Username,
+		Secret:    authConfig.Password,
+	}
+	fileAuthConfig := cliconfig.AuthConfig{
+		Email:         authConfig.Email,
+		ServerAddress: authConfig.ServerAddress,
+	}
+	if authConfig.IdentityToken != "" {
+		creds.Username = tokenUsername
+		creds.Secret = authConfig.IdentityToken
+		fileAuthConfig.Username = authConfig.Username
+	}
+	buf, err := json.Marshal(creds)
+	if err != nil {
+		return err
+	}
+	if _, err := c.run("store", bytes.NewReader(buf)); err != nil {
+		return err
+	}
+
+	return c.fileStore.Store(fileAuthConfig)
+}
+
+// run runs the credentials helper with the given action and input. The
+// output of the helper is the error message if it fails.
+func (c *nativeStore) run(action string, in io.Reader) ([]byte, error) {
+	p := c.programFunc(action)
+	if in != nil {
+		p.Input(in)
+	}
+	out, err := p.Output()
+	if err != nil {
+		if msg := strings.TrimSpace(string(out)); msg != "" {
+			return nil, fmt.Errorf("%s", msg)
+		}
+		if _, ok := err.(*exec.Error); ok {
+			return nil, fmt.Errorf("error running the %s credentials helper: %v", action, err)
+		}
+		return nil, err
+	}
+	return out, nil
+}
@@ -0,0 +1,220 @@
+package credentials
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/docker/docker/cliconfig"
+)
+
+const (
+	validServerAddress   = "https://index.docker.io/v1"
+	invalidServerAddress = "https://foobar.example.com"
+	missingCredsAddress  = "https://missing.example.com"
+	tokenServerAddress   = "https://token.example.com"
+)
+
+// mockProgram simulates a credentials helper knowing the credentials of
+// validServerAddress, and the identity token of tokenServerAddress.
+type mockProgram struct {
+	action string
+	input  io.Reader
+}
+
+func (m *mockProgram) Input(in io.Reader) {
+	m.input = in
+}
+
+func (m *mockProgram) Output() ([]byte, error) {
+	var in []byte
+	if m.input != nil {
+		in, _ = ioutil.ReadAll(m.input)
+	}
+	inS := string(in)
+
+	switch m.action {
+	case "erase", "get":
+		switch inS {
+		case validServerAddress:
+			if m.action == "get" {
+				return []byte(`{"Username": "foo", "Secret": "bar"}`), nil
+			}
+			return nil, nil
+		case tokenServerAddress:
+			if m.action == "get" {
+				return []byte(`{"Username": "<token>", "Secret": "refresh"}`), nil
+			}
+			return nil, nil
+		case missingCredsAddress:
+			return []byte(errCredentialsNotFoundMessage), fmt.Errorf("exit status 1")
+		}
+		return []byte("error with " + inS), fmt.Errorf("exit status 1")
+	case "store":
+		var c helperCredentials
+		if err := json.Unmarshal(in, &c); err != nil {
+			return []byte("error storing credentials"), err
+		}
+		if c.ServerURL == tokenServerAddress && c.Username == tokenUsername && c.Secret == "refresh" {
+			return nil, nil
+		}
+		if c.ServerURL != validServerAddress {
+			return []byte("error storing credentials for " + c.ServerURL), fmt.Errorf("exit status 1")
+		}
+		return nil, nil
+	case "list":
+		return []byte(fmt.Sprintf(`{"%s": "foo"}`, validServerAddress)), nil
+	}
+	return []byte("unknown action " + m.action), fmt.Errorf("exit status 1")
+}
+
+func mockProgramFunc(action string) program {
+	return &mockProgram{action: action}
+}
+
+func newMockNativeStore(file *cliconfig.ConfigFile) *nativeStore {
+	return &nativeStore{
+		programFunc: mockProgramFunc,
+		fileStore:   NewFileStore(file),
+	}
+}
+
+func newTestConfigFile(t *testing.T, auths map[string]cliconfig.AuthConfig) *cliconfig.ConfigFile {
+	tmp, err := ioutil.TempFile("", "docker-credentials-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmp.Close()
+
+	file := cliconfig.NewConfigFile(tmp.Name())
+	for serverAddress, authConfig := range auths {
+		file.AuthConfigs[serverAddress] = authConfig
+	}
+	return file
+}
+
+func TestNativeStoreGet(t *testing.T) {
+	file := newTestConfigFile(t, map[string]cliconfig.AuthConfig{
+		validServerAddress: {Email: "foo@example.com"},
+	})
+	defer os.Remove(file.Filename())
+	s := newMockNativeStore(file)
+
+	a, err := s.Get(validServerAddress)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if a.Username != "foo" || a.Password != "bar" || a.Email != "foo@example.com" || a.ServerAddress != validServerAddress {
+		t.Fatalf("Unexpected credentials: %+v", a)
+	}
+
+	a, err = s.Get(missingCredsAddress)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if a.Username != "" || a.Password != "" {
+		t.Fatalf("Expected no credentials, got %+v", a)
+	}
+
+	if _, err := s.Get(invalidServerAddress); err == nil || err.Error() != "error with "+invalidServerAddress {
+		t.Fatalf("Expected the error of the helper, got %v", err)
+	}
+}
+
+func TestNativeStoreStore(t *testing.T) {
+	file := newTestConfigFile(t, nil)
+	defer os.Remove(file.Filename())
+	s := newMockNativeStore(file)
+
+	err := s.Store(cliconfig.AuthConfig{
+		Username:      "foo",
+		Password:      "bar",
+		Email:         "foo@example.com",
+		ServerAddress: validServerAddress,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	a := file.AuthConfigs[validServerAddress]
+	if a.Username != "" || a.Password != "" || a.Email != "foo@example.com" {
+		t.Fatalf("Expected only the email in the file store, got %+v", a)
+	}
+
+	err = s.Store(cliconfig.AuthConfig{
+		Username:      "foo",
+		Password:      "bar",
+		ServerAddress: invalidServerAddress,
+	})
+	if err == nil || !strings.Contains(err.Error(), invalidServerAddress) {
+		t.Fatalf("Expected the error of the helper, got %v", err)
+	}
+	if _, ok := file.AuthConfigs[invalidServerAddress]; ok {
+		t.Fatal("Expected nothing to be stored in the file store")
+	}
+}
+
+func TestNativeStoreErase(t *testing.T) {
+	file := newTestConfigFile(t, map[string]cliconfig.AuthConfig{
+		validServerAddress: {Email: "foo@example.com"},
+	})
+	defer os.Remove(file.Filename())
+	s := newMockNativeStore(file)
+
+	if err := s.Erase(validServerAddress); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := file.AuthConfigs[validServerAddress]; ok {
+		t.Fatal("Expected the email to be removed from the file store")
+	}
+}
+
+func TestNativeStoreGetAll(t *testing.T) {
+	file := newTestConfigFile(t, map[string]cliconfig.AuthConfig{
+		validServerAddress: {Email: "foo@example.com"},
+	})
+	defer os.Remove(file.Filename())
+	s := newMockNativeStore(file)
+
+	auths, err := s.GetAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(auths) != 1 {
+		t.Fatalf("Expected 1 credentials, got %d", len(auths))
+	}
+	if a := auths[validServerAddress]; a.Username != "foo" || a.Email != "foo@example.com" {
+		t.Fatalf("Unexpected credentials: %+v", a)
+	}
+}
+
+func TestNativeStoreIdentityToken(t *testing.T) {
+	file := newTestConfigFile(t, nil)
+	defer os.Remove(file.Filename())
+	s := newMockNativeStore(file)
+
+	err := s.Store(cliconfig.AuthConfig{
+		Username:      "foo",
+		IdentityToken: "refresh",
+		Email:         "foo@example.com",
+		ServerAddress: tokenServerAddress,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if a := file.AuthConfigs[tokenServerAddress]; a.Username != "foo" || a.IdentityToken != "" || a.Email != "foo@example.com" {
+		t.Fatalf("Expected only the username and the email in the file store, got %+v", a)
+	}
+
+	a, err := s.Get(tokenServerAddress)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if a.Username != "foo" || a.Password != "" || a.IdentityToken != "refresh" {
+		t.Fatalf("Unexpected credentials: %+v", a)
+	}
+}
@@ -1,6 +1,8 @@
 package daemon
 
 import (
+	"runtime"
+
 	"github.com/docker/docker/image"
 	"github.com/docker/docker/runconfig"
 )
@@ -14,6 +16,9 @@ type ContainerCommitConfig struct {
 	Author  string
 	Comment string
 	Config  *runconfig.Config
+	// OS and Architecture of the image, when different from the daemon's.
+	OS           string
+	Architecture string
 }
 
 // Commit creates a new filesystem image from the current state of a container.
@@ -35,7 +40,11 @@ func (daemon *Daemon) Commit(container *Container, c *ContainerCommitConfig) (*i
 	}()
 
 	// Create a new image from the container's base layers + a new layer from container changes
-	img, err := daemon.graph.Create(rwTar, container.ID, container.ImageID, c.Comment, c.Author, container.Config, c.Config)
+	imageOS, arch := runtime.GOOS, runtime.GOARCH
+	if c.OS != "" {
+		imageOS, arch = c.OS, c.Architecture
+	}
+	img, err := daemon.graph.CreateForPlatform(imageOS, arch, rwTar, container.ID, container.ImageID, c.Comment, c.Author, container.Config, c.Config)
 	if err != nil {
 		return nil, err
 	}
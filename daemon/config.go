@@ -1,6 +1,7 @@
 package daemon
 
 import (
+	"github.com/docker/docker/graph"
 	"github.com/docker/docker/opts"
 	flag "github.com/docker/docker/pkg/mflag"
 	"github.com/docker/docker/runconfig"
@@ -34,6 +35,11 @@ type CommonConfig struct {
 	TrustKeyPath   string
 	DefaultNetwork string
 	NetworkKVStore string
+
+	// MaxConcurrentDownloads and MaxConcurrentUploads bound the number of
+	// layers transferred concurrently by pulls and pushes.
+	MaxConcurrentDownloads int
+	MaxConcurrentUploads   int
 }
 
 // InstallCommonFlags adds command-line options to the top-level flag parser for
@@ -57,4 +63,6 @@ func (config *Config) InstallCommonFlags(cmd *flag.FlagSet, usageFn func(string)
 	cmd.Var(opts.NewListOptsRef(&config.Labels, opts.ValidateLabel), []string{"-label"}, usageFn("Set key=value labels to the daemon"))
 	cmd.StringVar(&config.LogConfig.Type, []string{"-log-driver"}, "json-file", usageFn("Default driver for container logs"))
 	cmd.Var(opts.NewMapOpts(config.LogConfig.Config, nil), []string{"-log-opt"}, usageFn("Set log driver options"))
+	cmd.IntVar(&config.MaxConcurrentDownloads, []string{"-max-concurrent-downloads"}, graph.DefaultMaxConcurrentDownloads, usageFn("Set the max concurrent layer downloads"))
+	cmd.IntVar(&config.MaxConcurrentUploads, []string{"-max-concurrent-uploads"}, graph.DefaultMaxConcurrentUploads, usageFn("Set the max concurrent layer uploads"))
 }
@@ -27,6 +27,8 @@ type Config struct {
 	CorsHeaders          string
 	EnableCors           bool
 	EnableSelinuxSupport bool
+	Init                 bool
+	LiveRestore          bool
 	SocketGroup          string
 	Ulimits              map[string]*ulimit.Ulimit
 }
@@ -60,6 +62,8 @@ func (config *Config) InstallFlags(cmd *flag.FlagSet, usageFn func(string) strin
 	// Then platform-specific install flags
 	cmd.BoolVar(&config.EnableSelinuxSupport, []string{"-selinux-enabled"}, false, usageFn("Enable selinux support"))
 	cmd.StringVar(&config.SocketGroup, []string{"G", "-group"}, "docker", usageFn("Group for the unix socket"))
+	cmd.BoolVar(&config.Init, []string{"-init"}, false, usageFn("Run an init inside containers that forwards signals and reaps processes"))
+	cmd.BoolVar(&config.LiveRestore, []string{"-live-restore"}, false, usageFn("Keep containers running when the daemon exits and restore them when it starts"))
 	config.Ulimits = make(map[string]*ulimit.Ulimit)
 	cmd.Var(opts.NewUlimitOpt(&config.Ulimits), []string{"-default-ulimit"}, usageFn("Set default ulimits for containers"))
 	cmd.BoolVar(&config.Bridge.EnableIPTables, []string{"#iptables", "-iptables"}, true, usageFn("Enable addition of iptables rules"))
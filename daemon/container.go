@@ -70,6 +70,8 @@ type CommonContainer struct {
 	MountLabel             string
 	ProcessLabel           string
 	RestartCount           int
+	RestartReason          string // reason of the last automatic restart
+	RestartDelay           int    // delay before the next automatic restart, in milliseconds
 	HasBeenStartedBefore   bool
 	HasBeenManuallyStopped bool // used for unless-stopped restart policy
 	hostConfig             *runconfig.HostConfig
@@ -179,6 +181,18 @@ func (container *Container) logEvent(action string) {
 	)
 }
 
+// logRestartEvent logs the restart event of an automatic restart, with the
+// reason of the restart as attribute.
+func (container *Container) logRestartEvent() {
+	d := container.daemon
+	d.EventsService.LogWithAttributes(
+		"restart",
+		container.ID,
+		container.Config.Image,
+		map[string]string{"reason": container.RestartReason},
+	)
+}
+
 // GetResourcePath evaluates `path` in the scope of the container's basefs, with proper path
 // sanitisation. Symlinks are all scoped to the basefs of the container, as
 // though the container's basefs was `/`.
@@ -283,6 +297,9 @@ func (container *Container) Start() (err error) {
 	if err := container.setupWorkingDirectory(); err != nil {
 		return err
 	}
+	if err := container.setupIpcDirs(); err != nil {
+		return err
+	}
 	env := container.createDaemonEnvironment(linkedEnv)
 	if err := populateCommand(container, env); err != nil {
 		return err
@@ -297,6 +314,42 @@ func (container *Container) Start() (err error) {
 	return container.waitForStart()
 }
 
+// restore resumes the monitoring of a container whose process a previous
+// instance of the daemon left running, with live restore.
+func (container *Container) restore() error {
+	container.Lock()
+	defer container.Unlock()
+
+	if err := container.Mount(); err != nil {
+		return err
+	}
+
+	container.hostConfig = runconfig.SetDefaultNetModeIfBlank(container.hostConfig)
+
+	// The environment is only used to start the process, which is running.
+	if err := populateCommand(container, nil); err != nil {
+		return err
+	}
+	// The containers which a crashed daemon left running aren't all kept
+	// running, such as the containers with a tty or a network to restore.
+	if !container.command.LiveRestore {
+		return fmt.Errorf("container %s can't be restored with live restore", container.ID)
+	}
+
+	container.monitor = newContainerMonitor(container, container.hostConfig.RestartPolicy)
+	container.monitor.restoring = true
+
+	// block until we either receive an error from the driver or until the
+	// monitor re-attached to the process
+	select {
+	case <-container.monitor.startSignal:
+	case err := <-promise.Go(container.monitor.Start):
+		return err
+	}
+
+	return nil
+}
+
 // streamConfig.StdinPipe returns a WriteCloser which can be used to feed data
 // to the standard input of the container's active process.
 // Container.StdoutPipe and Container.StderrPipe each return a ReadCloser
@@ -338,6 +391,7 @@ func (container *Container) cleanup() {
 		container.daemon.unregisterExecCommand(eConfig)
 	}
 
+	container.unmountIpcMounts()
 	container.unmountVolumes(false)
 }
 
@@ -516,6 +570,11 @@ func (container *Container) Restart(seconds int) error {
 		return err
 	}
 
+	// a manual restart resets the delay between the automatic restarts
+	container.Lock()
+	container.RestartDelay = 0
+	container.Unlock()
+
 	if err := container.Start(); err != nil {
 		return err
 	}
@@ -1053,7 +1112,7 @@ func copyEscapable(dst io.Writer, src io.ReadCloser) (written int64, err error)
 func (container *Container) shouldRestart() bool {
 	return container.hostConfig.RestartPolicy.Name == "always" ||
 		(container.hostConfig.RestartPolicy.Name == "unless-stopped" && !container.HasBeenManuallyStopped) ||
-		(container.hostConfig.RestartPolicy.Name == "on-failure" && container.ExitCode != 0)
+		(container.hostConfig.RestartPolicy.Name == "on-failure" && container.ExitCode != 0 && !container.ExitCodeUnknown)
 }
 
 func (container *Container) mountVolumes() error {
@@ -1115,6 +1174,15 @@ func (container *Container) copyImagePathContent(v volume.Volume, destination st
 	return v.Unmount()
 }
 
+// stopTimeout returns the timeout (in seconds) to stop the container, before
+// it is killed.
+func (container *Container) stopTimeout() int {
+	if container.Config.StopTimeout != nil {
+		return *container.Config.StopTimeout
+	}
+	return runconfig.DefaultStopTimeout
+}
+
 func (container *Container) stopSignal() int {
 	var stopSignal syscall.Signal
 	if container.Config.StopSignal != "" {
@@ -64,3 +64,27 @@ func TestContainerStopSignal(t *testing.T) {
 		t.Fatalf("Expected 9, got %v", s)
 	}
 }
+
+func TestContainerStopTimeout(t *testing.T) {
+	c := &Container{
+		CommonContainer: CommonContainer{
+			Config: &runconfig.Config{},
+		},
+	}
+
+	s := c.stopTimeout()
+	if s != runconfig.DefaultStopTimeout {
+		t.Fatalf("Expected %v, got %v", runconfig.DefaultStopTimeout, s)
+	}
+
+	timeout := 30
+	c = &Container{
+		CommonContainer: CommonContainer{
+			Config: &runconfig.Config{StopTimeout: &timeout},
+		},
+	}
+	s = c.stopTimeout()
+	if s != 30 {
+		t.Fatalf("Expected 30, got %v", s)
+	}
+}
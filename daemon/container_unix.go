@@ -16,10 +16,12 @@ import (
 
 	"github.com/Sirupsen/logrus"
 	"github.com/docker/docker/daemon/execdriver"
+	"github.com/docker/docker/daemon/execdriver/initproc"
 	"github.com/docker/docker/daemon/links"
 	"github.com/docker/docker/daemon/network"
 	derr "github.com/docker/docker/errors"
 	"github.com/docker/docker/pkg/directory"
+	"github.com/docker/docker/pkg/mount"
 	"github.com/docker/docker/pkg/nat"
 	"github.com/docker/docker/pkg/stringid"
 	"github.com/docker/docker/pkg/system"
@@ -42,6 +44,9 @@ import (
 // ':' character .
 const DefaultPathEnv = "/usr/local/sbin:/usr/local/bin:/usr/sbin:/usr/bin:/sbin:/bin"
 
+// DefaultSHMSize is the size of the /dev/shm of containers not given one.
+const DefaultSHMSize int64 = 67108864
+
 // Container holds the fields specific to unixen implementations. See
 // CommonContainer for standard fields common to all containers.
 type Container struct {
@@ -50,10 +55,12 @@ type Container struct {
 	// Fields below here are platform specific.
 	activeLinks     map[string]*links.Link
 	AppArmorProfile string
+	SeccompProfile  string
 	HostnamePath    string
 	HostsPath       string
 	MountPoints     map[string]*mountPoint
 	ResolvConfPath  string
+	ShmPath         string
 
 	Volumes   map[string]string // Deprecated since 1.7, kept for backwards compatibility
 	VolumesRW map[string]bool   // Deprecated since 1.7, kept for backwards compatibility
@@ -253,19 +260,48 @@ func populateCommand(c *Container, env []string) error {
 		rlimits = append(rlimits, rl)
 	}
 
+	weightDevices, err := getBlkioWeightDevices(c.hostConfig)
+	if err != nil {
+		return err
+	}
+	readBpsDevices, err := getBlkioThrottleDevices(c.hostConfig.BlkioDeviceReadBps)
+	if err != nil {
+		return err
+	}
+	writeBpsDevices, err := getBlkioThrottleDevices(c.hostConfig.BlkioDeviceWriteBps)
+	if err != nil {
+		return err
+	}
+	readIOpsDevices, err := getBlkioThrottleDevices(c.hostConfig.BlkioDeviceReadIOps)
+	if err != nil {
+		return err
+	}
+	writeIOpsDevices, err := getBlkioThrottleDevices(c.hostConfig.BlkioDeviceWriteIOps)
+	if err != nil {
+		return err
+	}
+
 	resources := &execdriver.Resources{
-		Memory:           c.hostConfig.Memory,
-		MemorySwap:       c.hostConfig.MemorySwap,
-		KernelMemory:     c.hostConfig.KernelMemory,
-		CPUShares:        c.hostConfig.CPUShares,
-		CpusetCpus:       c.hostConfig.CpusetCpus,
-		CpusetMems:       c.hostConfig.CpusetMems,
-		CPUPeriod:        c.hostConfig.CPUPeriod,
-		CPUQuota:         c.hostConfig.CPUQuota,
-		BlkioWeight:      c.hostConfig.BlkioWeight,
-		Rlimits:          rlimits,
-		OomKillDisable:   c.hostConfig.OomKillDisable,
-		MemorySwappiness: -1,
+		Memory:                       c.hostConfig.Memory,
+		MemoryReservation:            c.hostConfig.MemoryReservation,
+		MemorySwap:                   c.hostConfig.MemorySwap,
+		KernelMemory:                 c.hostConfig.KernelMemory,
+		CPUShares:                    c.hostConfig.CPUShares,
+		CpusetCpus:                   c.hostConfig.CpusetCpus,
+		CpusetMems:                   c.hostConfig.CpusetMems,
+		CPUPeriod:                    c.hostConfig.CPUPeriod,
+		CPUQuota:                     c.hostConfig.CPUQuota,
+		BlkioWeight:                  c.hostConfig.BlkioWeight,
+		BlkioWeightDevice:            weightDevices,
+		BlkioThrottleReadBpsDevice:   readBpsDevices,
+		BlkioThrottleWriteBpsDevice:  writeBpsDevices,
+		BlkioThrottleReadIOpsDevice:  readIOpsDevices,
+		BlkioThrottleWriteIOpsDevice: writeIOpsDevices,
+		Rlimits:                      rlimits,
+		OomKillDisable:               c.hostConfig.OomKillDisable,
+		OomScoreAdj:                  c.hostConfig.OomScoreAdj,
+		MemorySwappiness:             -1,
+		PidsLimit:                    c.hostConfig.PidsLimit,
 	}
 
 	if c.hostConfig.MemorySwappiness != nil {
@@ -283,6 +319,15 @@ func populateCommand(c *Container, env []string) error {
 	processConfig.SysProcAttr = &syscall.SysProcAttr{Setsid: true}
 	processConfig.Env = env
 
+	if c.initEnabled() {
+		if c.daemon.systemInitPath() == "" {
+			return derr.ErrorCodeNoDockerInit
+		}
+		// The init runs the command of the container as its child.
+		processConfig.Entrypoint = initproc.Path
+		processConfig.Arguments = append([]string{c.Path}, c.Args...)
+	}
+
 	c.command = &execdriver.Command{
 		ID:                 c.ID,
 		Rootfs:             c.rootfsPath(),
@@ -304,7 +349,11 @@ func populateCommand(c *Container, env []string) error {
 		MountLabel:         c.getMountLabel(),
 		LxcConfig:          lxcConfig,
 		AppArmorProfile:    c.AppArmorProfile,
+		SeccompProfile:     c.SeccompProfile,
 		CgroupParent:       c.hostConfig.CgroupParent,
+		// The terminal of the containers with a tty goes away with the daemon,
+		// and the stdin of the containers with StdinOnce must reach EOF.
+		LiveRestore: c.daemon.liveRestoreEnabled() && !c.Config.Tty && !c.Config.StdinOnce && c.hasRestorableNetwork(),
 	}
 
 	return nil
@@ -979,6 +1028,40 @@ func (container *Container) setNetworkNamespaceKey(pid int) error {
 	return sandbox.SetKey(path)
 }
 
+// hasRestorableNetwork returns whether the network of the container survives
+// the daemon with live restore. The daemon doesn't keep the state of the
+// endpoints of the container across its restarts, so only the containers on
+// the host network or without network are kept running.
+func (container *Container) hasRestorableNetwork() bool {
+	mode := container.hostConfig.NetworkMode
+	return container.Config.NetworkDisabled || mode.IsHost() || mode.IsNone()
+}
+
+// restoreNetwork connects the process of a restored container, with the given
+// pid, to its network again. The container has no endpoint to restore, see
+// hasRestorableNetwork, only the sandbox of its network namespace.
+func (container *Container) restoreNetwork(pid int) error {
+	mode := container.hostConfig.NetworkMode
+	if container.Config.NetworkDisabled {
+		return nil
+	}
+
+	if !mode.IsHost() {
+		if key := container.NetworkSettings.SandboxKey; key != "" {
+			if err := syscall.Unmount(key, syscall.MNT_DETACH); err != nil {
+				logrus.Debugf("Failed to unmount the network namespace %s: %v", key, err)
+			}
+			os.Remove(key)
+		}
+	}
+	container.NetworkSettings = &network.Settings{}
+
+	if err := container.allocateNetwork(); err != nil {
+		return err
+	}
+	return container.setNetworkNamespaceKey(pid)
+}
+
 func (container *Container) getIpcContainer() (*Container, error) {
 	containerID := container.hostConfig.IpcMode.Container()
 	c, err := container.daemon.Get(containerID)
@@ -988,9 +1071,108 @@ func (container *Container) getIpcContainer() (*Container, error) {
 	if !c.IsRunning() {
 		return nil, derr.ErrorCodeIPCRunning
 	}
+	if !c.hostConfig.IpcMode.IsShareable() {
+		return nil, derr.ErrorCodeIPCNotShareable.WithArgs(containerID)
+	}
 	return c, nil
 }
 
+// setupIpcDirs mounts the /dev/shm tmpfs of a container with a private ipc
+// stack under its root, where it outlives the restarts of the daemon.
+// Containers joining the ipc stack of another container share its /dev/shm.
+func (container *Container) setupIpcDirs() error {
+	if container.hostConfig.IpcMode.IsContainer() {
+		ic, err := container.getIpcContainer()
+		if err != nil {
+			return err
+		}
+		container.ShmPath = ic.ShmPath
+		return nil
+	}
+	if !container.hostConfig.IpcMode.IsPrivate() {
+		container.ShmPath = ""
+		return nil
+	}
+
+	shmPath, err := container.getRootResourcePath("shm")
+	if err != nil {
+		return err
+	}
+	if mounted, _ := mount.Mounted(shmPath); !mounted {
+		if err := system.MkdirAll(shmPath, 0700); err != nil {
+			return err
+		}
+		shmSize := DefaultSHMSize
+		if container.hostConfig.ShmSize != 0 {
+			shmSize = container.hostConfig.ShmSize
+		}
+		shmProperty := "mode=1777,size=" + strconv.FormatInt(shmSize, 10)
+		if err := syscall.Mount("shm", shmPath, "tmpfs", uintptr(syscall.MS_NOEXEC|syscall.MS_NOSUID|syscall.MS_NODEV), label.FormatMountLabel(shmProperty, container.MountLabel)); err != nil {
+			return fmt.Errorf("mounting shm tmpfs: %s", err)
+		}
+	}
+	container.ShmPath = shmPath
+	return nil
+}
+
+// unmountIpcMounts unmounts the /dev/shm tmpfs the daemon mounted for the
+// container, if any.
+func (container *Container) unmountIpcMounts() {
+	if !container.hostConfig.IpcMode.IsPrivate() || container.ShmPath == "" {
+		return
+	}
+	if mounted, _ := mount.Mounted(container.ShmPath); !mounted {
+		return
+	}
+	if err := syscall.Unmount(container.ShmPath, syscall.MNT_DETACH); err != nil {
+		logrus.Errorf("%v: Failed to umount /dev/shm: %v", container.ID, err)
+	}
+}
+
+// ipcMounts returns the mount of the /dev/shm of the container, unless the
+// user mounted something else there.
+func (container *Container) ipcMounts() []execdriver.Mount {
+	if container.ShmPath == "" {
+		return nil
+	}
+	if _, ok := container.MountPoints["/dev/shm"]; ok {
+		return nil
+	}
+	if _, ok := container.hostConfig.Tmpfs["/dev/shm"]; ok {
+		return nil
+	}
+	label.Relabel(container.ShmPath, container.MountLabel, true)
+	return []execdriver.Mount{{
+		Source:      container.ShmPath,
+		Destination: "/dev/shm",
+		Writable:    true,
+		Private:     true,
+	}}
+}
+
+// initEnabled returns whether the container runs an init as its PID 1, the
+// daemon default unless the container was created with --init.
+func (container *Container) initEnabled() bool {
+	if container.hostConfig.Init != nil {
+		return *container.hostConfig.Init
+	}
+	return container.daemon.configStore.Init
+}
+
+// initMounts returns the mount of dockerinit where the container runs it as
+// its init.
+func (container *Container) initMounts() []execdriver.Mount {
+	if !container.initEnabled() {
+		return nil
+	}
+	return []execdriver.Mount{{
+		Source:      container.daemon.systemInitPath(),
+		Destination: initproc.Path,
+		Writable:    false,
+		Private:     true,
+	}}
+}
+
 func (container *Container) setupWorkingDirectory() error {
 	if container.Config.WorkingDir != "" {
 		container.Config.WorkingDir = filepath.Clean(container.Config.WorkingDir)
@@ -42,6 +42,13 @@ func (container *Container) setupWorkingDirectory() error {
 	return nil
 }
 
+func (container *Container) setupIpcDirs() error {
+	return nil
+}
+
+func (container *Container) unmountIpcMounts() {
+}
+
 func populateCommand(c *Container, env []string) error {
 	en := &execdriver.Network{
 		Interface: nil,
@@ -143,6 +150,11 @@ func (container *Container) setNetworkNamespaceKey(pid int) error {
 	return nil
 }
 
+// restoreNetwork is a no-op on Windows.
+func (container *Container) restoreNetwork(pid int) error {
+	return nil
+}
+
 // allocateNetwork is a no-op on Windows.
 func (container *Container) allocateNetwork() error {
 	return nil
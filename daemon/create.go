@@ -27,6 +27,10 @@ func (daemon *Daemon) ContainerCreate(name string, config *runconfig.Config, hos
 
 	daemon.adaptContainerSettings(hostConfig, adjustCPUShares)
 
+	if err := daemon.repositories.CheckDigestPolicy(config.Image); err != nil {
+		return nil, warnings, err
+	}
+
 	container, buildWarnings, err := daemon.Create(config, hostConfig, name)
 	if err != nil {
 		if daemon.Graph().IsNotExist(err, config.Image) {
@@ -34,6 +34,10 @@ func createContainerPlatformSpecificSettings(container *Container, config *runco
 		if container.isDestinationMounted(destination) {
 			continue
 		}
+		// Skip volumes mounted over by a tmpfs.
+		if _, ok := hostConfig.Tmpfs[destination]; ok {
+			continue
+		}
 		path, err := container.GetResourcePath(destination)
 		if err != nil {
 			return err
@@ -207,22 +207,9 @@ func (daemon *Daemon) Register(container *Container) error {
 	// we'll waste time if we update it for every container
 	daemon.idIndex.Add(container.ID)
 
-	if container.IsRunning() {
-		logrus.Debugf("killing old running container %s", container.ID)
-		// Set exit code to 128 + SIGKILL (9) to properly represent unsuccessful exit
-		container.setStoppedLocking(&execdriver.ExitStatus{ExitCode: 137})
-		// use the current driver and ensure that the container is dead x.x
-		cmd := &execdriver.Command{
-			ID: container.ID,
-		}
-		daemon.execDriver.Terminate(cmd)
-
-		if err := container.Unmount(); err != nil {
-			logrus.Debugf("unmount error %s", err)
-		}
-		if err := container.toDiskLocking(); err != nil {
-			logrus.Errorf("Error saving stopped state to disk: %v", err)
-		}
+	// With live restore, the containers left running are restored instead
+	if container.IsRunning() && !daemon.liveRestoreEnabled() {
+		daemon.killStale(container)
 	}
 
 	if err := daemon.verifyVolumesInfo(container); err != nil {
@@ -236,6 +223,26 @@ func (daemon *Daemon) Register(container *Container) error {
 	return nil
 }
 
+// killStale kills the process of a container left running by a previous
+// instance of the daemon, and marks the container as stopped.
+func (daemon *Daemon) killStale(container *Container) {
+	logrus.Debugf("killing old running container %s", container.ID)
+	// Set exit code to 128 + SIGKILL (9) to properly represent unsuccessful exit
+	container.setStoppedLocking(&execdriver.ExitStatus{ExitCode: 137})
+	// use the current driver and ensure that the container is dead x.x
+	cmd := &execdriver.Command{
+		ID: container.ID,
+	}
+	daemon.execDriver.Terminate(cmd)
+
+	if err := container.Unmount(); err != nil {
+		logrus.Debugf("unmount error %s", err)
+	}
+	if err := container.toDiskLocking(); err != nil {
+		logrus.Errorf("Error saving stopped state to disk: %v", err)
+	}
+}
+
 func (daemon *Daemon) ensureName(container *Container) error {
 	if container.Name == "" {
 		name, err := daemon.generateNewName(container.ID)
@@ -306,6 +313,10 @@ func (daemon *Daemon) restore() error {
 		}
 	}
 
+	var (
+		runningMu sync.Mutex
+		running   []*Container
+	)
 	group := sync.WaitGroup{}
 	for _, c := range containers {
 		group.Add(1)
@@ -327,19 +338,36 @@ func (daemon *Daemon) restore() error {
 				return
 			}
 
-			// check the restart policy on the containers and restart any container with
-			// the restart policy of "always"
-			if daemon.configStore.AutoRestart && container.shouldRestart() {
-				logrus.Debugf("Starting container %s", container.ID)
-
-				if err := container.Start(); err != nil {
-					logrus.Errorf("Failed to start container %s: %s", container.ID, err)
-				}
+			// the containers left running are restored once all the containers
+			// are registered, as they may join the namespaces of others
+			if container.IsRunning() {
+				runningMu.Lock()
+				running = append(running, container)
+				runningMu.Unlock()
+				return
 			}
+
+			daemon.autoRestart(container)
 		}(c.container, c.registered)
 	}
 	group.Wait()
 
+	for _, c := range running {
+		group.Add(1)
+
+		go func(container *Container) {
+			defer group.Done()
+
+			logrus.Debugf("Restoring container %s", container.ID)
+			if err := container.restore(); err != nil {
+				logrus.Errorf("Failed to restore container %s: %s", container.ID, err)
+				daemon.killStale(container)
+				daemon.autoRestart(container)
+			}
+		}(c)
+	}
+	group.Wait()
+
 	if !debug {
 		if logrus.GetLevel() == logrus.InfoLevel {
 			fmt.Println()
@@ -350,6 +378,23 @@ func (daemon *Daemon) restore() error {
 	return nil
 }
 
+// autoRestart starts a stopped container on daemon startup, if its restart
+// policy says so.
+func (daemon *Daemon) autoRestart(container *Container) {
+	// check the restart policy on the containers and restart any container with
+	// the restart policy of "always"
+	if daemon.configStore.AutoRestart && container.shouldRestart() {
+		logrus.Debugf("Starting container %s", container.ID)
+
+		container.RestartReason = "daemon restart"
+		container.logRestartEvent()
+
+		if err := container.Start(); err != nil {
+			logrus.Errorf("Failed to start container %s: %s", container.ID, err)
+		}
+	}
+}
+
 func (daemon *Daemon) mergeAndVerifyConfig(config *runconfig.Config, img *image.Image) error {
 	if img != nil && img.Config != nil {
 		if err := runconfig.Merge(config, img.Config); err != nil {
@@ -578,6 +623,9 @@ func NewDaemon(config *Config, registryService *registry.Service) (daemon *Daemo
 	if err := checkConfigOptions(config); err != nil {
 		return nil, err
 	}
+	if config.MaxConcurrentDownloads < 1 || config.MaxConcurrentUploads < 1 {
+		return nil, fmt.Errorf("--max-concurrent-downloads and --max-concurrent-uploads must be at least 1")
+	}
 
 	// Do we have a disabled network?
 	config.DisableBridge = isBridgeNetworkDisabled(config)
@@ -704,6 +752,9 @@ func NewDaemon(config *Config, registryService *registry.Service) (daemon *Daemo
 		Registry: registryService,
 		Events:   eventsService,
 		Trust:    trustService,
+
+		MaxConcurrentDownloads: config.MaxConcurrentDownloads,
+		MaxConcurrentUploads:   config.MaxConcurrentUploads,
 	}
 	repositories, err := graph.NewTagStore(filepath.Join(config.Root, "repositories-"+d.driver.String()), tagCfg)
 	if err != nil {
@@ -729,13 +780,9 @@ func NewDaemon(config *Config, registryService *registry.Service) (daemon *Daemo
 
 	d.containerGraphDB = graph
 
-	var sysInitPath string
-	if config.ExecDriver == "lxc" {
-		initPath, err := configureSysInit(config)
-		if err != nil {
-			return nil, err
-		}
-		sysInitPath = initPath
+	sysInitPath, err := lookupSysInit(config)
+	if err != nil {
+		return nil, err
 	}
 
 	sysInfo := sysinfo.New(false)
@@ -775,6 +822,34 @@ func NewDaemon(config *Config, registryService *registry.Service) (daemon *Daemo
 	return d, nil
 }
 
+// ShutdownTimeout returns the timeout (in seconds) for Shutdown to stop the
+// running containers: the longest of their stop timeouts, plus a grace period.
+// It is negative if a container waits forever for a graceful stop.
+func (daemon *Daemon) ShutdownTimeout() int {
+	// By default we allow 5 seconds more than the containers take to stop.
+	graceTimeout := 5
+	timeout := runconfig.DefaultStopTimeout
+	for _, c := range daemon.List() {
+		if !c.IsRunning() || daemon.keepsRunning(c) {
+			continue
+		}
+		stopTimeout := c.stopTimeout()
+		if stopTimeout < 0 {
+			return -1
+		}
+		if stopTimeout > timeout {
+			timeout = stopTimeout
+		}
+	}
+	return timeout + graceTimeout
+}
+
+// keepsRunning returns whether the given container is kept running when the
+// daemon shuts down, to be restored when it starts again.
+func (daemon *Daemon) keepsRunning(c *Container) bool {
+	return daemon.liveRestoreEnabled() && c.command != nil && c.command.LiveRestore
+}
+
 // Shutdown stops the daemon.
 func (daemon *Daemon) Shutdown() error {
 	daemon.shutdown = true
@@ -783,6 +858,10 @@ func (daemon *Daemon) Shutdown() error {
 		logrus.Debug("starting clean shutdown of all containers...")
 		for _, container := range daemon.List() {
 			c := container
+			if c.IsRunning() && daemon.keepsRunning(c) {
+				logrus.Debugf("keeping %s running", c.ID)
+				continue
+			}
 			if c.IsRunning() {
 				logrus.Debugf("stopping %s", c.ID)
 				group.Add(1)
@@ -808,8 +887,8 @@ func (daemon *Daemon) Shutdown() error {
 							logrus.Debugf("Failed to unpause container %s with error: %v", c.ID, err)
 							return
 						}
-						if _, err := c.WaitStop(10 * time.Second); err != nil {
-							logrus.Debugf("container %s failed to exit in 10 second of SIGTERM, sending SIGKILL to force", c.ID)
+						if _, err := c.WaitStop(time.Duration(c.stopTimeout()) * time.Second); err != nil {
+							logrus.Debugf("container %s failed to exit in %d second of SIGTERM, sending SIGKILL to force", c.ID, c.stopTimeout())
 							sig, ok := signal.SignalMap["KILL"]
 							if !ok {
 								logrus.Warnf("System does not support SIGKILL")
@@ -818,8 +897,8 @@ func (daemon *Daemon) Shutdown() error {
 							daemon.kill(c, int(sig))
 						}
 					} else {
-						// If container failed to exit in 10 seconds of SIGTERM, then using the force
-						if err := c.Stop(10); err != nil {
+						// If container failed to exit in its stop timeout of SIGTERM, then using the force
+						if err := c.Stop(c.stopTimeout()); err != nil {
 							logrus.Errorf("Stop container %s with error: %v", c.ID, err)
 						}
 					}
@@ -888,6 +967,20 @@ func (daemon *Daemon) run(c *Container, pipes *execdriver.Pipes, startCallback e
 	return daemon.execDriver.Run(c.command, pipes, hooks)
 }
 
+// reattach re-attaches to the process of a container left running by a
+// previous instance of the daemon, and connects it to its network again.
+func (daemon *Daemon) reattach(c *Container, pipes *execdriver.Pipes, startCallback execdriver.DriverCallback) (execdriver.ExitStatus, error) {
+	hooks := execdriver.Hooks{
+		Start: func(processConfig *execdriver.ProcessConfig, pid int) error {
+			if err := c.restoreNetwork(pid); err != nil {
+				logrus.Errorf("Error restoring the network of container %s: %v", c.ID, err)
+			}
+			return startCallback(processConfig, pid)
+		},
+	}
+	return daemon.execDriver.Restore(c.command, pipes, hooks)
+}
+
 func (daemon *Daemon) kill(c *Container, sig int) error {
 	return daemon.execDriver.Kill(c.command, sig)
 }
@@ -965,6 +1058,11 @@ func (daemon *Daemon) config() *Config {
 	return daemon.configStore
 }
 
+// Root returns the root directory of the daemon.
+func (daemon *Daemon) Root() string {
+	return daemon.root
+}
+
 func (daemon *Daemon) systemInitPath() string {
 	return daemon.sysInitPath
 }
@@ -1118,6 +1216,13 @@ func (daemon *Daemon) verifyContainerSettings(hostConfig *runconfig.HostConfig,
 		}
 	}
 
+	if hostConfig.RestartPolicy.MaximumDelay < 0 {
+		return nil, fmt.Errorf("Invalid restart policy: maximum delay %v can not be negative", hostConfig.RestartPolicy.MaximumDelay)
+	}
+	if hostConfig.RestartPolicy.ResetWindow < 0 {
+		return nil, fmt.Errorf("Invalid restart policy: reset window %v can not be negative", hostConfig.RestartPolicy.ResetWindow)
+	}
+
 	// Now do platform-specific verification
 	return verifyPlatformContainerSettings(daemon, hostConfig, config)
 }
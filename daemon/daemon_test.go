@@ -531,6 +531,22 @@ func TestParseSecurityOpt(t *testing.T) {
 		t.Fatalf("Unexpected AppArmorProfile, expected: \"test_profile\", got %q", container.AppArmorProfile)
 	}
 
+	// test seccomp, with either separator
+	config.SecurityOpt = []string{`seccomp={"defaultAction":"SCMP_ACT_ALLOW"}`}
+	if err := parseSecurityOpt(container, config); err != nil {
+		t.Fatalf("Unexpected parseSecurityOpt error: %v", err)
+	}
+	if container.SeccompProfile != `{"defaultAction":"SCMP_ACT_ALLOW"}` {
+		t.Fatalf("Unexpected SeccompProfile, got %q", container.SeccompProfile)
+	}
+	config.SecurityOpt = []string{"seccomp:unconfined"}
+	if err := parseSecurityOpt(container, config); err != nil {
+		t.Fatalf("Unexpected parseSecurityOpt error: %v", err)
+	}
+	if container.SeccompProfile != "unconfined" {
+		t.Fatalf("Unexpected SeccompProfile, expected: \"unconfined\", got %q", container.SeccompProfile)
+	}
+
 	// test valid label
 	config.SecurityOpt = []string{"label:user:USER"}
 	if err := parseSecurityOpt(container, config); err != nil {
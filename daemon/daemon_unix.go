@@ -14,6 +14,8 @@ import (
 	"github.com/Sirupsen/logrus"
 	"github.com/docker/docker/autogen/dockerversion"
 	"github.com/docker/docker/daemon/graphdriver"
+	derr "github.com/docker/docker/errors"
+	"github.com/docker/docker/pkg/blkiodev"
 	"github.com/docker/docker/pkg/fileutils"
 	"github.com/docker/docker/pkg/parsers"
 	"github.com/docker/docker/pkg/parsers/kernel"
@@ -26,6 +28,7 @@ import (
 	nwconfig "github.com/docker/libnetwork/config"
 	"github.com/docker/libnetwork/netlabel"
 	"github.com/docker/libnetwork/options"
+	"github.com/opencontainers/runc/libcontainer/devices"
 	"github.com/opencontainers/runc/libcontainer/label"
 )
 
@@ -43,15 +46,17 @@ func parseSecurityOpt(container *Container, config *runconfig.HostConfig) error
 	)
 
 	for _, opt := range config.SecurityOpt {
-		con := strings.SplitN(opt, ":", 2)
-		if len(con) == 1 {
+		key, value, ok := runconfig.SplitSecurityOpt(opt)
+		if !ok {
 			return fmt.Errorf("Invalid --security-opt: %q", opt)
 		}
-		switch con[0] {
+		switch key {
 		case "label":
-			labelOpts = append(labelOpts, con[1])
+			labelOpts = append(labelOpts, value)
 		case "apparmor":
-			container.AppArmorProfile = con[1]
+			container.AppArmorProfile = value
+		case "seccomp":
+			container.SeccompProfile = value
 		default:
 			return fmt.Errorf("Invalid --security-opt: %q", opt)
 		}
@@ -61,6 +66,44 @@ func parseSecurityOpt(container *Container, config *runconfig.HostConfig) error
 	return err
 }
 
+// blkioDeviceNumbers returns the major:minor numbers of the block device at
+// path, which the blkio cgroup identifies devices with.
+func blkioDeviceNumbers(path string) (string, error) {
+	var stat syscall.Stat_t
+	if err := syscall.Stat(path, &stat); err != nil {
+		return "", fmt.Errorf("Invalid device %s: %v", path, err)
+	}
+	return fmt.Sprintf("%d:%d", devices.Major(int(stat.Rdev)), devices.Minor(int(stat.Rdev))), nil
+}
+
+// getBlkioWeightDevices returns the weights per device of the container in
+// the format of the blkio.weight_device cgroup file, one device per line.
+func getBlkioWeightDevices(config *runconfig.HostConfig) (string, error) {
+	var lines []string
+	for _, d := range config.BlkioWeightDevice {
+		device, err := blkioDeviceNumbers(d.Path)
+		if err != nil {
+			return "", err
+		}
+		lines = append(lines, fmt.Sprintf("%s %d", device, d.Weight))
+	}
+	return strings.Join(lines, "\n"), nil
+}
+
+// getBlkioThrottleDevices returns the rates per device in the format of the
+// blkio.throttle cgroup files, one device per line.
+func getBlkioThrottleDevices(devices []*blkiodev.ThrottleDevice) (string, error) {
+	var lines []string
+	for _, d := range devices {
+		device, err := blkioDeviceNumbers(d.Path)
+		if err != nil {
+			return "", err
+		}
+		lines = append(lines, fmt.Sprintf("%s %d", device, d.Rate))
+	}
+	return strings.Join(lines, "\n"), nil
+}
+
 func checkKernelVersion(k, major, minor int) bool {
 	if v, err := kernel.GetKernelVersion(); err != nil {
 		logrus.Warnf("%s", err)
@@ -110,6 +153,13 @@ func (daemon *Daemon) adaptContainerSettings(hostConfig *runconfig.HostConfig, a
 		// By default, MemorySwap is set to twice the size of Memory.
 		hostConfig.MemorySwap = hostConfig.Memory * 2
 	}
+	if hostConfig.Memory > 0 && hostConfig.MemoryReservation == 0 {
+		// By default, MemoryReservation is set to the size of Memory.
+		hostConfig.MemoryReservation = hostConfig.Memory
+	}
+	if hostConfig.ShmSize == 0 {
+		hostConfig.ShmSize = DefaultSHMSize
+	}
 }
 
 // verifyPlatformContainerSettings performs platform-specific validation of the
@@ -122,6 +172,20 @@ func verifyPlatformContainerSettings(daemon *Daemon, hostConfig *runconfig.HostC
 		return warnings, fmt.Errorf("Cannot use --lxc-conf with execdriver: %s", daemon.ExecutionDriver().Name())
 	}
 
+	if hostConfig.ShmSize < 0 {
+		return warnings, fmt.Errorf("SHM size must be greater than 0")
+	}
+
+	for dest, options := range hostConfig.Tmpfs {
+		spec := dest
+		if options != "" {
+			spec += ":" + options
+		}
+		if _, _, err := runconfig.ParseTmpfs(spec); err != nil {
+			return warnings, err
+		}
+	}
+
 	// memory subsystem checks and adjustments
 	if hostConfig.Memory != 0 && hostConfig.Memory < 4194304 {
 		return warnings, fmt.Errorf("Minimum memory limit allowed is 4MB")
@@ -143,6 +207,17 @@ func verifyPlatformContainerSettings(daemon *Daemon, hostConfig *runconfig.HostC
 	if hostConfig.Memory == 0 && hostConfig.MemorySwap > 0 {
 		return warnings, fmt.Errorf("You should always set the Memory limit when using Memoryswap limit, see usage.")
 	}
+	if hostConfig.MemoryReservation > 0 && !sysInfo.MemoryReservation {
+		warnings = append(warnings, "Your kernel does not support memory soft limit capabilities. Limitation discarded.")
+		logrus.Warnf("Your kernel does not support memory soft limit capabilities. Limitation discarded.")
+		hostConfig.MemoryReservation = 0
+	}
+	if hostConfig.MemoryReservation != 0 && hostConfig.MemoryReservation < 4194304 {
+		return warnings, fmt.Errorf("Minimum memory reservation allowed is 4MB")
+	}
+	if hostConfig.Memory > 0 && hostConfig.MemoryReservation > 0 && hostConfig.Memory < hostConfig.MemoryReservation {
+		return warnings, fmt.Errorf("Minimum memory limit should be larger than memory reservation limit, see usage.")
+	}
 	if hostConfig.MemorySwappiness != nil && *hostConfig.MemorySwappiness != -1 && !sysInfo.MemorySwappiness {
 		warnings = append(warnings, "Your kernel does not support memory swappiness capabilities, memory swappiness discarded.")
 		logrus.Warnf("Your kernel does not support memory swappiness capabilities, memory swappiness discarded.")
@@ -163,6 +238,11 @@ func verifyPlatformContainerSettings(daemon *Daemon, hostConfig *runconfig.HostC
 		warnings = append(warnings, "You specified a kernel memory limit on a kernel older than 4.0. Kernel memory limits are experimental on older kernels, it won't work as expected and can cause your system to be unstable.")
 		logrus.Warnf("You specified a kernel memory limit on a kernel older than 4.0. Kernel memory limits are experimental on older kernels, it won't work as expected and can cause your system to be unstable.")
 	}
+	if hostConfig.PidsLimit != 0 && !sysInfo.PidsLimit {
+		warnings = append(warnings, "Your kernel does not support pids limit capabilities. Pids limit discarded.")
+		logrus.Warnf("Your kernel does not support pids limit capabilities. Pids limit discarded.")
+		hostConfig.PidsLimit = 0
+	}
 	if hostConfig.CPUShares > 0 && !sysInfo.CPUShares {
 		warnings = append(warnings, "Your kernel does not support CPU shares. Shares discarded.")
 		logrus.Warnf("Your kernel does not support CPU shares. Shares discarded.")
@@ -192,10 +272,41 @@ func verifyPlatformContainerSettings(daemon *Daemon, hostConfig *runconfig.HostC
 	if hostConfig.BlkioWeight > 0 && (hostConfig.BlkioWeight < 10 || hostConfig.BlkioWeight > 1000) {
 		return warnings, fmt.Errorf("Range of blkio weight is from 10 to 1000.")
 	}
+	if len(hostConfig.BlkioWeightDevice) > 0 && !sysInfo.BlkioWeightDevice {
+		warnings = append(warnings, "Your kernel does not support Block I/O weight_device. Weight-device discarded.")
+		logrus.Warnf("Your kernel does not support Block I/O weight_device. Weight-device discarded.")
+		hostConfig.BlkioWeightDevice = nil
+	}
+	if len(hostConfig.BlkioDeviceReadBps) > 0 && !sysInfo.BlkioReadBpsDevice {
+		warnings = append(warnings, "Your kernel does not support Block read limit in bytes per second. Device read bps discarded.")
+		logrus.Warnf("Your kernel does not support Block read limit in bytes per second. Device read bps discarded.")
+		hostConfig.BlkioDeviceReadBps = nil
+	}
+	if len(hostConfig.BlkioDeviceWriteBps) > 0 && !sysInfo.BlkioWriteBpsDevice {
+		warnings = append(warnings, "Your kernel does not support Block write limit in bytes per second. Device write bps discarded.")
+		logrus.Warnf("Your kernel does not support Block write limit in bytes per second. Device write bps discarded.")
+		hostConfig.BlkioDeviceWriteBps = nil
+	}
+	if len(hostConfig.BlkioDeviceReadIOps) > 0 && !sysInfo.BlkioReadIOpsDevice {
+		warnings = append(warnings, "Your kernel does not support Block read limit in IO per second. Device read iops discarded.")
+		logrus.Warnf("Your kernel does not support Block read limit in IO per second. Device read iops discarded.")
+		hostConfig.BlkioDeviceReadIOps = nil
+	}
+	if len(hostConfig.BlkioDeviceWriteIOps) > 0 && !sysInfo.BlkioWriteIOpsDevice {
+		warnings = append(warnings, "Your kernel does not support Block write limit in IO per second. Device write iops discarded.")
+		logrus.Warnf("Your kernel does not support Block write limit in IO per second. Device write iops discarded.")
+		hostConfig.BlkioDeviceWriteIOps = nil
+	}
 	if hostConfig.OomKillDisable && !sysInfo.OomKillDisable {
 		hostConfig.OomKillDisable = false
 		return warnings, fmt.Errorf("Your kernel does not support oom kill disable.")
 	}
+	if hostConfig.Init != nil && *hostConfig.Init && daemon.systemInitPath() == "" {
+		return warnings, derr.ErrorCodeNoDockerInit
+	}
+	if hostConfig.OomScoreAdj < -1000 || hostConfig.OomScoreAdj > 1000 {
+		return warnings, fmt.Errorf("Invalid value %d, range for oom score adj is [-1000, 1000].", hostConfig.OomScoreAdj)
+	}
 
 	if sysInfo.IPv4ForwardingDisabled {
 		warnings = append(warnings, "IPv4 forwarding is disabled. Networking will not work.")
@@ -216,9 +327,18 @@ func checkConfigOptions(config *Config) error {
 	if !config.Bridge.EnableIPTables && config.Bridge.EnableIPMasq {
 		config.Bridge.EnableIPMasq = false
 	}
+	if config.LiveRestore && config.ExecDriver == "lxc" {
+		return fmt.Errorf("You specified --live-restore with the lxc exec driver, which cannot restore running containers. Please use the native exec driver.")
+	}
 	return nil
 }
 
+// liveRestoreEnabled returns whether the running containers are kept running
+// when the daemon exits, and restored when it starts.
+func (daemon *Daemon) liveRestoreEnabled() bool {
+	return daemon.configStore != nil && daemon.configStore.LiveRestore
+}
+
 // checkSystem validates platform-specific requirements
 func checkSystem() error {
 	if os.Geteuid() != 0 {
@@ -276,6 +396,18 @@ func configureSysInit(config *Config) (string, error) {
 	return sysInitPath, nil
 }
 
+// lookupSysInit returns the path of the dockerinit binary. The lxc driver and
+// the --init daemon default require it. Otherwise only the containers started
+// with --init need it, so it is fine to do without it.
+func lookupSysInit(config *Config) (string, error) {
+	sysInitPath, err := configureSysInit(config)
+	if err != nil && config.ExecDriver != "lxc" && !config.Init {
+		logrus.Warnf("%v Containers can't be started with --init.", err)
+		return "", nil
+	}
+	return sysInitPath, err
+}
+
 func isBridgeNetworkDisabled(config *Config) bool {
 	return config.Bridge.Iface == disableNetworkBridge
 }
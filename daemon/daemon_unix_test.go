@@ -85,3 +85,63 @@ func TestAdjustCPUSharesNoAdjustment(t *testing.T) {
 		t.Error("Expected CPUShares to be unchanged")
 	}
 }
+
+func TestAdaptMemoryReservation(t *testing.T) {
+	daemon := &Daemon{}
+
+	hostConfig := &runconfig.HostConfig{
+		Memory: 64 * 1024 * 1024,
+	}
+	daemon.adaptContainerSettings(hostConfig, false)
+	if hostConfig.MemoryReservation != hostConfig.Memory {
+		t.Errorf("Expected MemoryReservation to default to Memory, got %d", hostConfig.MemoryReservation)
+	}
+
+	hostConfig = &runconfig.HostConfig{
+		Memory:            64 * 1024 * 1024,
+		MemoryReservation: 32 * 1024 * 1024,
+	}
+	daemon.adaptContainerSettings(hostConfig, false)
+	if hostConfig.MemoryReservation != 32*1024*1024 {
+		t.Errorf("Expected MemoryReservation to be unchanged, got %d", hostConfig.MemoryReservation)
+	}
+}
+
+func TestCheckConfigOptionsLiveRestore(t *testing.T) {
+	config := &Config{}
+	config.Bridge.EnableIPTables = true
+	config.LiveRestore = true
+	config.ExecDriver = "native"
+	if err := checkConfigOptions(config); err != nil {
+		t.Fatal(err)
+	}
+
+	config.ExecDriver = "lxc"
+	if err := checkConfigOptions(config); err == nil {
+		t.Fatal("Expected an error for --live-restore with the lxc exec driver")
+	}
+}
+
+func TestHasRestorableNetwork(t *testing.T) {
+	cases := []struct {
+		mode       runconfig.NetworkMode
+		restorable bool
+	}{
+		{"host", true},
+		{"none", true},
+		{"bridge", false},
+		{"default", false},
+		{"container:other", false},
+	}
+	for _, c := range cases {
+		container := &Container{
+			CommonContainer: CommonContainer{
+				Config:     &runconfig.Config{},
+				hostConfig: &runconfig.HostConfig{NetworkMode: c.mode},
+			},
+		}
+		if restorable := container.hasRestorableNetwork(); restorable != c.restorable {
+			t.Fatalf("Expected the network %s to be restorable: %v, got %v", c.mode, c.restorable, restorable)
+		}
+	}
+}
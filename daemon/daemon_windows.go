@@ -46,6 +46,12 @@ func checkConfigOptions(config *Config) error {
 	return nil
 }
 
+// liveRestoreEnabled returns whether the running containers are kept running
+// when the daemon exits, which they are not on Windows.
+func (daemon *Daemon) liveRestoreEnabled() bool {
+	return false
+}
+
 // checkSystem validates platform-specific requirements
 func checkSystem() error {
 	var dwVersion uint32
@@ -75,7 +81,7 @@ func migrateIfDownlevel(driver graphdriver.Driver, root string) error {
 	return nil
 }
 
-func configureSysInit(config *Config) (string, error) {
+func lookupSysInit(config *Config) (string, error) {
 	// TODO Windows.
 	return os.Getenv("TEMP"), nil
 }
@@ -45,8 +45,14 @@ func (e *Events) Evict(l chan interface{}) {
 // Log broadcasts event to listeners. Each listener has 100 millisecond for
 // receiving event or it will be skipped.
 func (e *Events) Log(action, id, from string) {
+	e.LogWithAttributes(action, id, from, nil)
+}
+
+// LogWithAttributes broadcasts event to listeners like Log, with attributes
+// detailing the event.
+func (e *Events) LogWithAttributes(action, id, from string, attributes map[string]string) {
 	now := time.Now().UTC()
-	jm := &jsonmessage.JSONMessage{Status: action, ID: id, From: from, Time: now.Unix(), TimeNano: now.UnixNano()}
+	jm := &jsonmessage.JSONMessage{Status: action, ID: id, From: from, Time: now.Unix(), TimeNano: now.UnixNano(), Attributes: attributes}
 	e.mu.Lock()
 	if len(e.events) == cap(e.events) {
 		// discard oldest event
@@ -133,3 +133,16 @@ func TestLogEvents(t *testing.T) {
 		t.Fatalf("Last action is %s, must be action_89", lastC.Status)
 	}
 }
+
+func TestLogWithAttributes(t *testing.T) {
+	e := New()
+	e.LogWithAttributes("restart", "cont", "image", map[string]string{"reason": "exit status 1"})
+	current, l := e.Subscribe()
+	defer e.Evict(l)
+	if len(current) != 1 {
+		t.Fatalf("Must be 1 event, got %d", len(current))
+	}
+	if jm := current[0]; jm.Status != "restart" || jm.Attributes["reason"] != "exit status 1" {
+		t.Fatalf("Unexpected event %v", jm)
+	}
+}
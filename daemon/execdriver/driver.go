@@ -22,8 +22,13 @@ var (
 	ErrWaitTimeoutReached      = errors.New("Wait timeout reached")
 	ErrDriverAlreadyRegistered = errors.New("A driver already registered this docker init function")
 	ErrDriverNotFound          = errors.New("The requested docker init has not been found")
+	ErrRestoreNotSupported     = errors.New("The exec driver cannot restore running containers")
 )
 
+// UnknownExitCode is the exit code of the containers whose exit status is
+// lost, such as the restored ones which exit while the daemon is down.
+const UnknownExitCode = 255
+
 // DriverCallback defines a callback function which is used in "Run" and "Exec".
 // This allows work to be done in the parent process when the child is passing
 // through PreStart, Start and PostStop events.
@@ -61,6 +66,9 @@ type ExitStatus struct {
 
 	// Whether the container encountered an OOM.
 	OOMKilled bool
+
+	// Whether the exit code is UnknownExitCode because the actual one is lost.
+	ExitCodeUnknown bool
 }
 
 // Driver is an interface for drivers to implement
@@ -70,6 +78,11 @@ type Driver interface {
 	// the exit code. It's the last stage on Docker side for running a container.
 	Run(c *Command, pipes *Pipes, hooks Hooks) (ExitStatus, error)
 
+	// Restore re-attaches to the process of a container which a previous
+	// instance of the daemon started and left running, blocks until the
+	// process exits and returns the exit code.
+	Restore(c *Command, pipes *Pipes, hooks Hooks) (ExitStatus, error)
+
 	// Exec executes the process in an existing container, blocks until the
 	// process exits and returns the exit code.
 	Exec(c *Command, processConfig *ProcessConfig, pipes *Pipes, hooks Hooks) (int, error)
@@ -138,28 +151,40 @@ type UTS struct {
 
 // Resources contains all resource configs for a driver.
 // Currently these are all for cgroup configs.
+// The per-device blkio settings hold one "major:minor value" line per device.
 // TODO Windows: Factor out ulimit.Rlimit
 type Resources struct {
-	Memory           int64            `json:"memory"`
-	MemorySwap       int64            `json:"memory_swap"`
-	KernelMemory     int64            `json:"kernel_memory"`
-	CPUShares        int64            `json:"cpu_shares"`
-	CpusetCpus       string           `json:"cpuset_cpus"`
-	CpusetMems       string           `json:"cpuset_mems"`
-	CPUPeriod        int64            `json:"cpu_period"`
-	CPUQuota         int64            `json:"cpu_quota"`
-	BlkioWeight      int64            `json:"blkio_weight"`
-	Rlimits          []*ulimit.Rlimit `json:"rlimits"`
-	OomKillDisable   bool             `json:"oom_kill_disable"`
-	MemorySwappiness int64            `json:"memory_swappiness"`
+	Memory                       int64            `json:"memory"`
+	MemoryReservation            int64            `json:"memory_reservation"`
+	MemorySwap                   int64            `json:"memory_swap"`
+	KernelMemory                 int64            `json:"kernel_memory"`
+	CPUShares                    int64            `json:"cpu_shares"`
+	CpusetCpus                   string           `json:"cpuset_cpus"`
+	CpusetMems                   string           `json:"cpuset_mems"`
+	CPUPeriod                    int64            `json:"cpu_period"`
+	CPUQuota                     int64            `json:"cpu_quota"`
+	BlkioWeight                  int64            `json:"blkio_weight"`
+	BlkioWeightDevice            string           `json:"blkio_weight_device"`
+	BlkioThrottleReadBpsDevice   string           `json:"blkio_throttle_read_bps_device"`
+	BlkioThrottleWriteBpsDevice  string           `json:"blkio_throttle_write_bps_device"`
+	BlkioThrottleReadIOpsDevice  string           `json:"blkio_throttle_read_iops_device"`
+	BlkioThrottleWriteIOpsDevice string           `json:"blkio_throttle_write_iops_device"`
+	Rlimits                      []*ulimit.Rlimit `json:"rlimits"`
+	OomKillDisable               bool             `json:"oom_kill_disable"`
+	OomScoreAdj                  int              `json:"oom_score_adj"`
+	MemorySwappiness             int64            `json:"memory_swappiness"`
+	PidsLimit                    int64            `json:"pids_limit"`
 }
 
 // ResourceStats contains information about resource usage by a container.
 type ResourceStats struct {
 	*libcontainer.Stats
-	Read        time.Time `json:"read"`
-	MemoryLimit int64     `json:"memory_limit"`
-	SystemUsage uint64    `json:"system_usage"`
+	Read              time.Time `json:"read"`
+	MemoryLimit       int64     `json:"memory_limit"`
+	MemoryReservation int64     `json:"memory_reservation"`
+	SystemUsage       uint64    `json:"system_usage"`
+	PidsCurrent       uint64    `json:"pids_current"`
+	PidsLimit         uint64    `json:"pids_limit"`
 }
 
 // Mount contains information for a mount operation.
@@ -169,6 +194,7 @@ type Mount struct {
 	Writable    bool   `json:"writable"`
 	Private     bool   `json:"private"`
 	Slave       bool   `json:"slave"`
+	Data        string `json:"data"` // Mount options of tmpfs mounts, whose source is "tmpfs"
 }
 
 // ProcessConfig describes a process that will be run inside a container.
@@ -213,8 +239,10 @@ type Command struct {
 	MountLabel         string            `json:"mount_label"`
 	LxcConfig          []string          `json:"lxc_config"`
 	AppArmorProfile    string            `json:"apparmor_profile"`
-	CgroupParent       string            `json:"cgroup_parent"` // The parent cgroup for this command.
+	SeccompProfile     string            `json:"seccomp_profile"` // "unconfined", or the JSON of the profile to use instead of the default one
+	CgroupParent       string            `json:"cgroup_parent"`   // The parent cgroup for this command.
 	FirstStart         bool              `json:"first_start"`
-	LayerPaths         []string          `json:"layer_paths"` // Windows needs to know the layer paths and folder for a command
+	LiveRestore        bool              `json:"live_restore"` // keep the process running when the daemon exits, so that it can be restored
+	LayerPaths         []string          `json:"layer_paths"`  // Windows needs to know the layer paths and folder for a command
 	LayerFolder        string            `json:"layer_folder"`
 }
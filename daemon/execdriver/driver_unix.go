@@ -64,7 +64,12 @@ func SetupCgroups(container *configs.Config, c *Command) error {
 	if c.Resources != nil {
 		container.Cgroups.CpuShares = c.Resources.CPUShares
 		container.Cgroups.Memory = c.Resources.Memory
-		container.Cgroups.MemoryReservation = c.Resources.Memory
+		container.Cgroups.MemoryReservation = c.Resources.MemoryReservation
+		if container.Cgroups.MemoryReservation == 0 && container.Cgroups.Memory > 0 {
+			// containers created before the reservation defaulted to the
+			// memory limit have none saved
+			container.Cgroups.MemoryReservation = container.Cgroups.Memory
+		}
 		container.Cgroups.MemorySwap = c.Resources.MemorySwap
 		container.Cgroups.CpusetCpus = c.Resources.CpusetCpus
 		container.Cgroups.CpusetMems = c.Resources.CpusetMems
@@ -0,0 +1,10 @@
+// Package initproc implements the minimal init process which runs as PID 1 of
+// the containers started with --init. It starts the command of the container
+// as its only child, forwards the signals it receives to it and reaps the
+// orphaned processes of the container until the child exits.
+package initproc
+
+// Path is where the dockerinit binary is mounted inside the containers
+// started with --init. It is also the name the initializer is registered
+// under with the reexec package.
+const Path = "/dev/init"
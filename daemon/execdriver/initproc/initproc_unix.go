@@ -0,0 +1,101 @@
+// +build !windows
+
+package initproc
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"os/signal"
+	"syscall"
+	"unsafe"
+
+	"github.com/docker/docker/pkg/reexec"
+	"github.com/docker/docker/pkg/term"
+)
+
+func init() {
+	reexec.Register(Path, initializer)
+}
+
+func fatal(err error) {
+	fmt.Fprintf(os.Stderr, "%s: %v\n", Path, err)
+	os.Exit(1)
+}
+
+func initializer() {
+	if len(os.Args) < 2 {
+		fatal(fmt.Errorf("no command to run"))
+	}
+
+	signals := make(chan os.Signal, 32)
+	signal.Notify(signals)
+
+	cmd := exec.Command(os.Args[1], os.Args[2:]...)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	// Run the child in its own process group, in the foreground of the
+	// terminal if there is one, so that the signals sent by the terminal reach
+	// it once and not twice through the init process.
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+	if err := cmd.Start(); err != nil {
+		fatal(err)
+	}
+	if term.IsTerminal(os.Stdin.Fd()) {
+		if err := setForeground(os.Stdin.Fd(), cmd.Process.Pid); err != nil {
+			cmd.Process.Kill()
+			fatal(err)
+		}
+	}
+
+	os.Exit(run(cmd.Process.Pid, signals))
+}
+
+// setForeground makes the process group pgid the foreground process group of
+// the terminal fd. The child may have been stopped by reading from or writing
+// to the terminal before, so it is continued like a shell does for fg.
+func setForeground(fd uintptr, pgid int) error {
+	pgrp := int32(pgid)
+	if _, _, err := syscall.Syscall(syscall.SYS_IOCTL, fd, uintptr(syscall.TIOCSPGRP), uintptr(unsafe.Pointer(&pgrp))); err != 0 {
+		return err
+	}
+	return syscall.Kill(-pgid, syscall.SIGCONT)
+}
+
+// run forwards the signals to the child with the given pid and reaps all the
+// processes which exit, until the child does. It returns the exit code of the
+// child.
+func run(pid int, signals chan os.Signal) int {
+	for sig := range signals {
+		if sig != syscall.SIGCHLD {
+			// The child may have exited already, in which case the next
+			// SIGCHLD reaps it.
+			syscall.Kill(pid, sig.(syscall.Signal))
+			continue
+		}
+		for {
+			var status syscall.WaitStatus
+			p, err := syscall.Wait4(-1, &status, syscall.WNOHANG, nil)
+			if err == syscall.EINTR {
+				continue
+			}
+			if err != nil || p <= 0 {
+				break
+			}
+			if p == pid {
+				return exitCode(status)
+			}
+		}
+	}
+	panic("unreachable")
+}
+
+// exitCode returns the exit code of a process with the given wait status,
+// following the shell convention of 128 + signal for killed processes.
+func exitCode(status syscall.WaitStatus) int {
+	if status.Signaled() {
+		return 128 + int(status.Signal())
+	}
+	return status.ExitStatus()
+}
@@ -0,0 +1,49 @@
+// +build !windows
+
+package initproc
+
+import (
+	"os"
+	"os/exec"
+	"syscall"
+	"testing"
+	"time"
+)
+
+// runChild starts the given command and runs the init loop for it, notifying
+// SIGCHLD periodically as the signal handler isn't installed in tests.
+func runChild(t *testing.T, signals chan os.Signal, name string, args ...string) int {
+	cmd := exec.Command(name, args...)
+	if err := cmd.Start(); err != nil {
+		t.Fatal(err)
+	}
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		ticker := time.NewTicker(10 * time.Millisecond)
+		defer ticker.Stop()
+		for range ticker.C {
+			select {
+			case <-done:
+				return
+			case signals <- syscall.SIGCHLD:
+			}
+		}
+	}()
+	return run(cmd.Process.Pid, signals)
+}
+
+func TestRunReturnsExitCode(t *testing.T) {
+	signals := make(chan os.Signal, 32)
+	if code := runChild(t, signals, "sh", "-c", "exit 3"); code != 3 {
+		t.Fatalf("Expected exit code 3, got %d", code)
+	}
+}
+
+func TestRunForwardsSignals(t *testing.T) {
+	signals := make(chan os.Signal, 32)
+	signals <- syscall.SIGTERM
+	if code := runChild(t, signals, "sleep", "10"); code != 128+int(syscall.SIGTERM) {
+		t.Fatalf("Expected exit code %d, got %d", 128+int(syscall.SIGTERM), code)
+	}
+}
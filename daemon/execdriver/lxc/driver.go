@@ -878,10 +878,22 @@ func (d *Driver) Exec(c *execdriver.Command, processConfig *execdriver.ProcessCo
 // Lxc doesn't implement it's own Stats, it does some trick by implementing
 // execdriver.Stats to get stats info by libcontainer APIs.
 func (d *Driver) Stats(id string) (*execdriver.ResourceStats, error) {
-	if _, ok := d.activeContainers[id]; !ok {
+	active, ok := d.activeContainers[id]
+	if !ok {
 		return nil, fmt.Errorf("%s is not a key in active containers", id)
 	}
-	return execdriver.Stats(d.containerDir(id), d.activeContainers[id].container.Cgroups.Memory, d.machineMemory)
+	stats, err := execdriver.Stats(d.containerDir(id), active.container.Cgroups.Memory, d.machineMemory)
+	if err != nil {
+		return nil, err
+	}
+	stats.MemoryReservation = active.container.Cgroups.MemoryReservation
+	return stats, nil
+}
+
+// Restore implements the exec driver Driver interface.
+// The LXC execdriver does not restore the containers left running by a previous daemon.
+func (d *Driver) Restore(c *execdriver.Command, pipes *execdriver.Pipes, hooks execdriver.Hooks) (execdriver.ExitStatus, error) {
+	return execdriver.ExitStatus{ExitCode: -1}, execdriver.ErrRestoreNotSupported
 }
 
 // SupportsHooks implements the execdriver Driver interface.
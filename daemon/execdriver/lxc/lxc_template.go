@@ -80,7 +80,9 @@ lxc.mount.entry = shm {{escapeFstabSpaces $ROOTFS}}/dev/shm tmpfs {{formatMountL
 
 {{range $value := .Mounts}}
 {{$createVal := isDirectory $value.Source}}
-{{if $value.Writable}}
+{{if eq $value.Source "tmpfs"}}
+lxc.mount.entry = tmpfs {{escapeFstabSpaces $ROOTFS}}/{{escapeFstabSpaces $value.Destination}} tmpfs {{formatMountLabel "size=65536k,nosuid,nodev,noexec,create=dir" ""}}{{if $value.Data}},{{$value.Data}}{{end}} 0 0
+{{else if $value.Writable}}
 lxc.mount.entry = {{$value.Source}} {{escapeFstabSpaces $ROOTFS}}/{{escapeFstabSpaces $value.Destination}} none rbind,rw,create={{$createVal}} 0 0
 {{else}}
 lxc.mount.entry = {{$value.Source}} {{escapeFstabSpaces $ROOTFS}}/{{escapeFstabSpaces $value.Destination}} none rbind,ro,create={{$createVal}} 0 0
@@ -91,11 +93,13 @@ lxc.mount.entry = {{$value.Source}} {{escapeFstabSpaces $ROOTFS}}/{{escapeFstabS
 {{if .Resources}}
 {{if .Resources.Memory}}
 lxc.cgroup.memory.limit_in_bytes = {{.Resources.Memory}}
-lxc.cgroup.memory.soft_limit_in_bytes = {{.Resources.Memory}}
 {{with $memSwap := getMemorySwap .Resources}}
 lxc.cgroup.memory.memsw.limit_in_bytes = {{$memSwap}}
 {{end}}
 {{end}}
+{{if .Resources.MemoryReservation}}
+lxc.cgroup.memory.soft_limit_in_bytes = {{.Resources.MemoryReservation}}
+{{end}}
 {{if gt .Resources.KernelMemory 0}}
 lxc.cgroup.memory.kmem.limit_in_bytes = {{.Resources.KernelMemory}}
 {{end}}
@@ -117,9 +121,27 @@ lxc.cgroup.cpu.cfs_quota_us = {{.Resources.CPUQuota}}
 {{if .Resources.BlkioWeight}}
 lxc.cgroup.blkio.weight = {{.Resources.BlkioWeight}}
 {{end}}
+{{range $device := splitLines .Resources.BlkioWeightDevice}}
+lxc.cgroup.blkio.weight_device = {{$device}}
+{{end}}
+{{range $device := splitLines .Resources.BlkioThrottleReadBpsDevice}}
+lxc.cgroup.blkio.throttle.read_bps_device = {{$device}}
+{{end}}
+{{range $device := splitLines .Resources.BlkioThrottleWriteBpsDevice}}
+lxc.cgroup.blkio.throttle.write_bps_device = {{$device}}
+{{end}}
+{{range $device := splitLines .Resources.BlkioThrottleReadIOpsDevice}}
+lxc.cgroup.blkio.throttle.read_iops_device = {{$device}}
+{{end}}
+{{range $device := splitLines .Resources.BlkioThrottleWriteIOpsDevice}}
+lxc.cgroup.blkio.throttle.write_iops_device = {{$device}}
+{{end}}
 {{if .Resources.OomKillDisable}}
 lxc.cgroup.memory.oom_control = {{.Resources.OomKillDisable}}
 {{end}}
+{{if gt .Resources.PidsLimit 0}}
+lxc.cgroup.pids.max = {{.Resources.PidsLimit}}
+{{end}}
 {{if gt .Resources.MemorySwappiness 0}}
 lxc.cgroup.memory.swappiness = {{.Resources.MemorySwappiness}}
 {{end}}
@@ -192,6 +214,14 @@ func dropList(drops []string) ([]string, error) {
 	return []string{}, nil
 }
 
+// splitLines returns the lines of s, none if s is empty.
+func splitLines(s string) []string {
+	if s == "" {
+		return nil
+	}
+	return strings.Split(s, "\n")
+}
+
 func isDirectory(source string) string {
 	f, err := os.Stat(source)
 	logrus.Debugf("dir: %s\n", source)
@@ -244,6 +274,7 @@ func init() {
 		"escapeFstabSpaces": escapeFstabSpaces,
 		"formatMountLabel":  label.FormatMountLabel,
 		"isDirectory":       isDirectory,
+		"splitLines":        splitLines,
 		"keepCapabilities":  keepCapabilities,
 		"dropList":          dropList,
 		"getHostname":       getHostname,
@@ -46,8 +46,9 @@ func TestLXCConfig(t *testing.T) {
 	command := &execdriver.Command{
 		ID: "1",
 		Resources: &execdriver.Resources{
-			Memory:    int64(mem),
-			CPUShares: int64(cpu),
+			Memory:            int64(mem),
+			MemoryReservation: int64(mem / 2),
+			CPUShares:         int64(cpu),
 		},
 		Network: &execdriver.Network{
 			Mtu: 1500,
@@ -64,6 +65,44 @@ func TestLXCConfig(t *testing.T) {
 
 	grepFile(t, p,
 		fmt.Sprintf("lxc.cgroup.memory.memsw.limit_in_bytes = %d", mem*2))
+
+	grepFile(t, p,
+		fmt.Sprintf("lxc.cgroup.memory.soft_limit_in_bytes = %d", mem/2))
+}
+
+func TestLXCConfigBlkioDevices(t *testing.T) {
+	root, err := ioutil.TempDir("", "TestLXCConfigBlkioDevices")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+
+	os.MkdirAll(path.Join(root, "containers", "1"), 0777)
+
+	driver, err := NewDriver(root, root, "", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	command := &execdriver.Command{
+		ID: "1",
+		Resources: &execdriver.Resources{
+			BlkioWeightDevice:          "8:0 300",
+			BlkioThrottleReadBpsDevice: "8:0 1048576\n8:16 2097152",
+		},
+		Network: &execdriver.Network{
+			Mtu: 1500,
+		},
+		AllowedDevices: make([]*configs.Device, 0),
+		ProcessConfig:  execdriver.ProcessConfig{},
+	}
+	p, err := driver.generateLXCConfig(command)
+	if err != nil {
+		t.Fatal(err)
+	}
+	grepFile(t, p, "lxc.cgroup.blkio.weight_device = 8:0 300")
+	grepFile(t, p, "lxc.cgroup.blkio.throttle.read_bps_device = 8:0 1048576")
+	grepFile(t, p, "lxc.cgroup.blkio.throttle.read_bps_device = 8:16 2097152")
+	grepFileWithReverse(t, p, "lxc.cgroup.blkio.throttle.write_bps_device", true)
 }
 
 func TestCustomLxcConfig(t *testing.T) {
@@ -0,0 +1,91 @@
+// +build linux,cgo
+
+package native
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/docker/docker/daemon/execdriver"
+	"github.com/opencontainers/runc/libcontainer/cgroups"
+	"github.com/opencontainers/runc/libcontainer/configs"
+)
+
+// processCgroupPath returns the path in the hierarchy of subsystem at the
+// same place as the cgroup of the process pid in the hierarchy of like.
+func processCgroupPath(subsystem, like string, pid int) (string, error) {
+	mnt, root, err := cgroups.FindCgroupMountpointAndRoot(subsystem)
+	if err != nil {
+		return "", err
+	}
+	f, err := os.Open(fmt.Sprintf("/proc/%d/cgroup", pid))
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	path, err := cgroups.ParseCgroupFile(like, f)
+	if err != nil {
+		return "", err
+	}
+	rel, err := filepath.Rel(root, path)
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(mnt, rel), nil
+}
+
+// setupBlkioDevices adds a prestart hook writing the per-device blkio
+// settings to the blkio cgroup of the container. libcontainer writes each
+// setting at once, but the kernel only accepts one device per write.
+func setupBlkioDevices(container *configs.Config, c *execdriver.Command) {
+	if c.Resources == nil {
+		return
+	}
+	files := map[string]string{
+		"blkio.weight_device":              c.Resources.BlkioWeightDevice,
+		"blkio.throttle.read_bps_device":   c.Resources.BlkioThrottleReadBpsDevice,
+		"blkio.throttle.write_bps_device":  c.Resources.BlkioThrottleWriteBpsDevice,
+		"blkio.throttle.read_iops_device":  c.Resources.BlkioThrottleReadIOpsDevice,
+		"blkio.throttle.write_iops_device": c.Resources.BlkioThrottleWriteIOpsDevice,
+	}
+	for file, data := range files {
+		if data == "" {
+			delete(files, file)
+		}
+	}
+	if len(files) == 0 {
+		return
+	}
+	if container.Hooks == nil {
+		container.Hooks = &configs.Hooks{}
+	}
+	container.Hooks.Prestart = append(container.Hooks.Prestart, configs.NewFunctionHook(func(s configs.HookState) error {
+		path, err := processCgroupPath("blkio", "blkio", s.Pid)
+		if err != nil {
+			return err
+		}
+		for file, data := range files {
+			if err := writeDeviceFile(path, file, data); err != nil {
+				return err
+			}
+		}
+		return nil
+	}))
+}
+
+// writeDeviceFile writes the lines of data, one per device, to the cgroup
+// file one at a time.
+func writeDeviceFile(dir, file, data string) error {
+	for _, line := range strings.Split(data, "\n") {
+		if line == "" {
+			continue
+		}
+		if err := ioutil.WriteFile(filepath.Join(dir, file), []byte(line), 0700); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -10,6 +10,7 @@ import (
 	"syscall"
 
 	"github.com/docker/docker/daemon/execdriver"
+	"github.com/docker/docker/pkg/mount"
 	"github.com/opencontainers/runc/libcontainer/apparmor"
 	"github.com/opencontainers/runc/libcontainer/configs"
 	"github.com/opencontainers/runc/libcontainer/devices"
@@ -71,9 +72,19 @@ func (d *Driver) createContainer(c *execdriver.Command, hooks execdriver.Hooks)
 		container.AppArmorProfile = c.AppArmorProfile
 	}
 
+	if err := setupSeccomp(container, c); err != nil {
+		return nil, err
+	}
+
 	if err := execdriver.SetupCgroups(container, c); err != nil {
 		return nil, err
 	}
+	setupBlkioDevices(container, c)
+	setupPidsLimit(container, c)
+
+	if c.Resources != nil {
+		container.OomScoreAdj = c.Resources.OomScoreAdj
+	}
 
 	if container.Readonlyfs {
 		for i := range container.Mounts {
@@ -237,6 +248,10 @@ func (d *Driver) setupRlimits(container *configs.Config, c *execdriver.Command)
 	}
 }
 
+// defaultTmpfsOptions are the mount options of tmpfs mounts, which the options
+// given by the user override.
+const defaultTmpfsOptions = "noexec,nosuid,nodev,size=65536k"
+
 func (d *Driver) setupMounts(container *configs.Config, c *execdriver.Command) error {
 	userMounts := make(map[string]struct{})
 	for _, m := range c.Mounts {
@@ -257,6 +272,21 @@ func (d *Driver) setupMounts(container *configs.Config, c *execdriver.Command) e
 	container.Mounts = defaultMounts
 
 	for _, m := range c.Mounts {
+		if m.Source == "tmpfs" {
+			flags, data, err := mount.ParseTmpfsOptions(defaultTmpfsOptions + "," + m.Data)
+			if err != nil {
+				return err
+			}
+			container.Mounts = append(container.Mounts, &configs.Mount{
+				Source:      m.Source,
+				Destination: m.Destination,
+				Device:      "tmpfs",
+				Flags:       flags,
+				Data:        data,
+			})
+			continue
+		}
+
 		flags := syscall.MS_BIND | syscall.MS_REC
 		if !m.Writable {
 			flags |= syscall.MS_RDONLY
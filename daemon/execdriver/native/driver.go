@@ -42,6 +42,8 @@ type Driver struct {
 	activeContainers map[string]libcontainer.Container
 	machineMemory    int64
 	factory          libcontainer.Factory
+	exits            *exitWatcher
+	exitsOnce        sync.Once
 	sync.Mutex
 }
 
@@ -70,6 +72,10 @@ func NewDriver(root, initPath string, options []string) (*Driver, error) {
 		}
 	}
 
+	if !seccompSupported {
+		logrus.Warn("This daemon was built without seccomp support, containers will run without the default seccomp profile")
+	}
+
 	// choose cgroup manager
 	// this makes sure there are no breaking changes to people
 	// who upgrade from versions without native.cgroupdriver opt
@@ -145,8 +151,11 @@ func (d *Driver) Run(c *execdriver.Command, pipes *execdriver.Pipes, hooks execd
 		User: c.ProcessConfig.User,
 	}
 
-	if err := setupPipes(container, &c.ProcessConfig, p, pipes); err != nil {
-		return execdriver.ExitStatus{ExitCode: -1}, err
+	// the process of a container started with live restore uses fifos instead
+	if !c.LiveRestore {
+		if err := setupPipes(container, &c.ProcessConfig, p, pipes); err != nil {
+			return execdriver.ExitStatus{ExitCode: -1}, err
+		}
 	}
 
 	cont, err := d.factory.Create(c.ID, container)
@@ -161,17 +170,39 @@ func (d *Driver) Run(c *execdriver.Command, pipes *execdriver.Pipes, hooks execd
 		d.cleanContainer(c.ID)
 	}()
 
-	if err := cont.Start(p); err != nil {
+	waitOutput := func() {}
+	var fifos []*os.File
+	if c.LiveRestore {
+		if fifos, err = d.createFifos(c.ID, pipes.Stdin != nil); err != nil {
+			return execdriver.ExitStatus{ExitCode: -1}, err
+		}
+		if pipes.Stdin != nil {
+			p.Stdin = fifos[0]
+		}
+		p.Stdout, p.Stderr = fifos[len(fifos)-2], fifos[len(fifos)-1]
+		if waitOutput, err = d.copyFifos(c.ID, pipes); err != nil {
+			closeFifos(fifos)
+			return execdriver.ExitStatus{ExitCode: -1}, err
+		}
+		c.ProcessConfig.Terminal = &execdriver.StdConsole{}
+	}
+
+	err = cont.Start(p)
+	// the process holds its own ends of the fifos once started, the ones of
+	// the daemon would keep its output from reaching EOF when it exits
+	closeFifos(fifos)
+	if err != nil {
 		return execdriver.ExitStatus{ExitCode: -1}, err
 	}
 
+	pid, err := p.Pid()
+	if err != nil {
+		p.Signal(os.Kill)
+		p.Wait()
+		return execdriver.ExitStatus{ExitCode: -1}, err
+	}
+	removePidsCgroup := pidsCgroupCleanup(c, pid)
 	if hooks.Start != nil {
-		pid, err := p.Pid()
-		if err != nil {
-			p.Signal(os.Kill)
-			p.Wait()
-			return execdriver.ExitStatus{ExitCode: -1}, err
-		}
 		hooks.Start(&c.ProcessConfig, pid)
 	}
 
@@ -191,6 +222,8 @@ func (d *Driver) Run(c *execdriver.Command, pipes *execdriver.Pipes, hooks execd
 		ps = execErr.ProcessState
 	}
 	cont.Destroy()
+	removePidsCgroup()
+	waitOutput()
 	_, oomKill := <-oom
 	return execdriver.ExitStatus{ExitCode: utils.ExitStatus(ps.Sys().(syscall.WaitStatus)), OOMKilled: oomKill}, nil
 }
@@ -335,6 +368,12 @@ func (d *Driver) Name() string {
 	return fmt.Sprintf("%s-%s", DriverName, Version)
 }
 
+// SeccompSupported returns whether the driver applies seccomp profiles to
+// the containers, which requires building the daemon with the seccomp tag.
+func (d *Driver) SeccompSupported() bool {
+	return seccompSupported
+}
+
 // GetPidsForContainer implements the exec driver Driver interface.
 func (d *Driver) GetPidsForContainer(id string) ([]int, error) {
 	d.Lock()
@@ -382,11 +421,19 @@ func (d *Driver) Stats(id string) (*execdriver.ResourceStats, error) {
 	if memoryLimit == 0 {
 		memoryLimit = d.machineMemory
 	}
-	return &execdriver.ResourceStats{
-		Stats:       stats,
-		Read:        now,
-		MemoryLimit: memoryLimit,
-	}, nil
+	rs := &execdriver.ResourceStats{
+		Stats:             stats,
+		Read:              now,
+		MemoryLimit:       memoryLimit,
+		MemoryReservation: c.Config().Cgroups.MemoryReservation,
+	}
+	// containers without a process limit have no pids cgroup of their own
+	if state, err := c.State(); err == nil {
+		if path, err := pidsCgroupPath(state.InitProcessPid); err == nil {
+			rs.PidsCurrent, rs.PidsLimit, _ = pidsStats(path)
+		}
+	}
+	return rs, nil
 }
 
 // TtyConsole implements the exec driver Terminal interface.
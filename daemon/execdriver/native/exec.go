@@ -45,13 +45,19 @@ func (d *Driver) Exec(c *execdriver.Command, processConfig *execdriver.ProcessCo
 		return -1, err
 	}
 
+	pid, err := p.Pid()
+	if err != nil {
+		p.Signal(os.Kill)
+		p.Wait()
+		return -1, err
+	}
+	if err := joinPidsCgroup(c, pid); err != nil {
+		p.Signal(os.Kill)
+		p.Wait()
+		return -1, err
+	}
+
 	if hooks.Start != nil {
-		pid, err := p.Pid()
-		if err != nil {
-			p.Signal(os.Kill)
-			p.Wait()
-			return -1, err
-		}
 		hooks.Start(&c.ProcessConfig, pid)
 	}
 
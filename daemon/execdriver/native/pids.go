@@ -0,0 +1,113 @@
+// +build linux,cgo
+
+package native
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/docker/docker/daemon/execdriver"
+	"github.com/opencontainers/runc/libcontainer/cgroups"
+	"github.com/opencontainers/runc/libcontainer/configs"
+)
+
+// libcontainer doesn't manage the pids cgroup, the driver puts the containers
+// with a process limit in one of their own before their process starts.
+
+// setupPidsLimit adds a prestart hook joining the container to a pids cgroup
+// limited to c.Resources.PidsLimit processes.
+func setupPidsLimit(container *configs.Config, c *execdriver.Command) {
+	if c.Resources == nil || c.Resources.PidsLimit == 0 {
+		return
+	}
+	limit := "max"
+	if c.Resources.PidsLimit > 0 {
+		limit = strconv.FormatInt(c.Resources.PidsLimit, 10)
+	}
+	if container.Hooks == nil {
+		container.Hooks = &configs.Hooks{}
+	}
+	container.Hooks.Prestart = append(container.Hooks.Prestart, configs.NewFunctionHook(func(s configs.HookState) error {
+		path, err := pidsCgroupPath(s.Pid)
+		if err != nil {
+			if cgroups.IsNotFound(err) {
+				return nil
+			}
+			return err
+		}
+		if err := os.MkdirAll(path, 0755); err != nil {
+			return err
+		}
+		if err := ioutil.WriteFile(filepath.Join(path, "pids.max"), []byte(limit), 0700); err != nil {
+			return err
+		}
+		return ioutil.WriteFile(filepath.Join(path, "cgroup.procs"), []byte(strconv.Itoa(s.Pid)), 0700)
+	}))
+}
+
+// joinPidsCgroup puts the process pid exec'd in the container in the pids
+// cgroup of the container, so that it counts against its limit. libcontainer
+// only joins the exec'd processes to the cgroups it manages, so this happens
+// right after the process started: the processes it forked meanwhile aren't
+// limited.
+func joinPidsCgroup(c *execdriver.Command, pid int) error {
+	if c.Resources == nil || c.Resources.PidsLimit == 0 {
+		return nil
+	}
+	// The exec'd process is in the cpu cgroup of the container already.
+	path, err := pidsCgroupPath(pid)
+	if err != nil {
+		if cgroups.IsNotFound(err) {
+			return nil
+		}
+		return err
+	}
+	return ioutil.WriteFile(filepath.Join(path, "cgroup.procs"), []byte(strconv.Itoa(pid)), 0700)
+}
+
+// pidsCgroupPath returns the pids cgroup of the process pid, at the same
+// place in the pids hierarchy as its cpu cgroup.
+func pidsCgroupPath(pid int) (string, error) {
+	return processCgroupPath("pids", "cpu", pid)
+}
+
+// pidsStats returns the number of processes in the pids cgroup at path and
+// their limit, 0 meaning no limit.
+func pidsStats(path string) (current, limit uint64, err error) {
+	data, err := ioutil.ReadFile(filepath.Join(path, "pids.current"))
+	if err != nil {
+		return 0, 0, err
+	}
+	if current, err = strconv.ParseUint(strings.TrimSpace(string(data)), 10, 64); err != nil {
+		return 0, 0, fmt.Errorf("failed to parse pids.current - %s", err)
+	}
+	data, err = ioutil.ReadFile(filepath.Join(path, "pids.max"))
+	if err != nil {
+		return 0, 0, err
+	}
+	if max := strings.TrimSpace(string(data)); max != "max" {
+		if limit, err = strconv.ParseUint(max, 10, 64); err != nil {
+			return 0, 0, fmt.Errorf("failed to parse pids.max - %s", err)
+		}
+	}
+	return current, limit, nil
+}
+
+// pidsCgroupCleanup returns a function removing the pids cgroup of the
+// container whose init process is pid, to call once its processes exited.
+func pidsCgroupCleanup(c *execdriver.Command, pid int) func() {
+	if c.Resources == nil || c.Resources.PidsLimit == 0 {
+		return func() {}
+	}
+	path, err := pidsCgroupPath(pid)
+	if err != nil {
+		return func() {}
+	}
+	return func() {
+		os.Remove(path)
+	}
+}
@@ -0,0 +1,66 @@
+// +build linux,cgo
+
+package native
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/docker/docker/daemon/execdriver"
+	"github.com/opencontainers/runc/libcontainer/configs"
+)
+
+func TestSetupPidsLimit(t *testing.T) {
+	container := &configs.Config{}
+	setupPidsLimit(container, &execdriver.Command{Resources: &execdriver.Resources{}})
+	if container.Hooks != nil {
+		t.Fatal("Expected no hook without a pids limit")
+	}
+
+	container.Hooks = &configs.Hooks{Prestart: []configs.Hook{configs.NewFunctionHook(func(configs.HookState) error { return nil })}}
+	setupPidsLimit(container, &execdriver.Command{Resources: &execdriver.Resources{PidsLimit: 10}})
+	if len(container.Hooks.Prestart) != 2 {
+		t.Fatalf("Expected the pids hook to be added to the existing ones, got %d hooks", len(container.Hooks.Prestart))
+	}
+}
+
+func TestPidsStats(t *testing.T) {
+	dir, err := ioutil.TempDir("", "pids-stats")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	for _, c := range []struct {
+		max           string
+		current       uint64
+		limit         uint64
+		expectedError bool
+	}{
+		{"max\n", 4, 0, false},
+		{"10\n", 4, 10, false},
+		{"lots\n", 0, 0, true},
+	} {
+		if err := ioutil.WriteFile(filepath.Join(dir, "pids.current"), []byte("4\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(filepath.Join(dir, "pids.max"), []byte(c.max), 0644); err != nil {
+			t.Fatal(err)
+		}
+		current, limit, err := pidsStats(dir)
+		if c.expectedError {
+			if err == nil {
+				t.Fatalf("Expected an error for pids.max %q", c.max)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		if current != c.current || limit != c.limit {
+			t.Fatalf("Expected %d pids limited to %d for pids.max %q, got %d limited to %d", c.current, c.limit, c.max, current, limit)
+		}
+	}
+}
@@ -0,0 +1,152 @@
+// +build linux,cgo
+
+package native
+
+import (
+	"sync"
+	"syscall"
+
+	"github.com/Sirupsen/logrus"
+	"github.com/opencontainers/runc/libcontainer/utils"
+	"github.com/vishvananda/netlink/nl"
+)
+
+// The restored containers are not children of the daemon, which can't wait
+// for them. Their exit status is read from the process events which the
+// kernel sends through the proc connector instead, see linux/cn_proc.h.
+const (
+	cnIdxProc = 1
+	cnValProc = 1
+
+	procCnMcastListen = 1
+	procCnMcastIgnore = 2
+
+	procEventExit = 0x80000000
+
+	// the size of a struct cn_msg, which starts the process events
+	cnMsgLen = 20
+)
+
+// procConnectorOp is a message which turns the process events on or off.
+type procConnectorOp uint32
+
+func (op procConnectorOp) Len() int {
+	return cnMsgLen + 4
+}
+
+func (op procConnectorOp) Serialize() []byte {
+	native := nl.NativeEndian()
+	b := make([]byte, op.Len())
+	native.PutUint32(b[0:4], cnIdxProc)
+	native.PutUint32(b[4:8], cnValProc)
+	native.PutUint16(b[16:18], 4)
+	native.PutUint32(b[cnMsgLen:], uint32(op))
+	return b
+}
+
+// exitWatcher reports the exit code of the processes it watches. It only
+// listens to the process events while it watches some.
+type exitWatcher struct {
+	sync.Mutex
+	socket  *nl.NetlinkSocket
+	waiters map[int]chan int
+}
+
+func newExitWatcher() (*exitWatcher, error) {
+	s, err := nl.Subscribe(syscall.NETLINK_CONNECTOR, cnIdxProc)
+	if err != nil {
+		return nil, err
+	}
+	w := &exitWatcher{
+		socket:  s,
+		waiters: make(map[int]chan int),
+	}
+	go w.receive()
+	return w, nil
+}
+
+func (w *exitWatcher) send(op procConnectorOp) error {
+	req := nl.NewNetlinkRequest(syscall.NLMSG_DONE, 0)
+	req.AddData(op)
+	return w.socket.Send(req)
+}
+
+// watch returns a channel which receives the exit code of the process with
+// the given pid. It must be called before checking that the process is still
+// running, or its exit may be missed.
+func (w *exitWatcher) watch(pid int) (<-chan int, error) {
+	w.Lock()
+	defer w.Unlock()
+	if len(w.waiters) == 0 {
+		if err := w.send(procCnMcastListen); err != nil {
+			return nil, err
+		}
+	}
+	exited := make(chan int, 1)
+	w.waiters[pid] = exited
+	return exited, nil
+}
+
+// unwatch stops watching the process with the given pid.
+func (w *exitWatcher) unwatch(pid int) {
+	w.Lock()
+	defer w.Unlock()
+	if _, ok := w.waiters[pid]; !ok {
+		return
+	}
+	delete(w.waiters, pid)
+	if len(w.waiters) == 0 {
+		if err := w.send(procCnMcastIgnore); err != nil {
+			logrus.Debugf("Failed to stop listening to process events: %v", err)
+		}
+	}
+}
+
+func (w *exitWatcher) receive() {
+	for {
+		msgs, err := w.socket.Receive()
+		if err != nil {
+			// the events which didn't fit in the socket buffer are lost,
+			// the restored containers check their process anyway
+			if err != syscall.ENOBUFS {
+				logrus.Debugf("Failed to receive process events: %v", err)
+			}
+			continue
+		}
+		for _, m := range msgs {
+			if pid, exitCode, ok := parseExitEvent(m.Data); ok {
+				w.exited(pid, exitCode)
+			}
+		}
+	}
+}
+
+func (w *exitWatcher) exited(pid, exitCode int) {
+	w.Lock()
+	defer w.Unlock()
+	if exited, ok := w.waiters[pid]; ok {
+		select {
+		case exited <- exitCode:
+		default:
+		}
+	}
+}
+
+// parseExitEvent returns the pid and the exit code of the process whose exit
+// the given process event reports, if it is one. The exits of the threads
+// other than the main one of a process are ignored.
+func parseExitEvent(b []byte) (int, int, bool) {
+	native := nl.NativeEndian()
+	// struct proc_event: what, cpu, timestamp_ns, then the exit event:
+	// process_pid, process_tgid, exit_code and exit_signal
+	if len(b) < cnMsgLen+32 || native.Uint32(b[cnMsgLen:]) != procEventExit {
+		return 0, 0, false
+	}
+	event := b[cnMsgLen+16:]
+	pid, tgid := native.Uint32(event[0:4]), native.Uint32(event[4:8])
+	if pid != tgid {
+		return 0, 0, false
+	}
+	status := syscall.WaitStatus(native.Uint32(event[8:12]))
+	return int(pid), utils.ExitStatus(status), true
+}
@@ -0,0 +1,241 @@
+// +build linux,cgo
+
+package native
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/Sirupsen/logrus"
+	"github.com/docker/docker/daemon/execdriver"
+	"github.com/docker/docker/pkg/pools"
+	"github.com/opencontainers/runc/libcontainer/system"
+)
+
+// The containers started with live restore use fifos in their libcontainer
+// state directory, which outlive the daemon, for their stdio instead of pipes
+// to the daemon: a daemon restored later opens them again.
+const (
+	stdinFifo  = "stdin"
+	stdoutFifo = "stdout"
+	stderrFifo = "stderr"
+
+	// restorePollInterval is how often the process of a restored container
+	// is checked, as it isn't a child of the daemon anymore.
+	restorePollInterval = 100 * time.Millisecond
+
+	// fifoBufferSize is the size of the buffers of the output fifos, the
+	// default maximum size of the pipes, instead of the 64KiB they get.
+	fifoBufferSize = 1 << 20
+	// fSetPipeSz is F_SETPIPE_SZ from linux/fcntl.h, missing from syscall.
+	fSetPipeSz = 1031
+)
+
+func (d *Driver) fifoPath(id, name string) string {
+	return filepath.Join(d.root, id, name)
+}
+
+// createFifos creates the fifos for the stdio of the container with the given
+// id and returns the ends to give to its process, with no stdin unless asked
+// for. They are opened for both reading and writing: the process can still
+// write its output while the daemon is down and doesn't get EOF on its stdin.
+// Nothing reads the output fifos while the daemon is down though, so the
+// writes of the process block once their buffer is full, until the daemon is
+// restored; their buffer is enlarged to make room for more output meanwhile.
+func (d *Driver) createFifos(id string, stdin bool) ([]*os.File, error) {
+	names := []string{stdoutFifo, stderrFifo}
+	if stdin {
+		names = append([]string{stdinFifo}, names...)
+	}
+	var fifos []*os.File
+	for _, name := range names {
+		if err := syscall.Mkfifo(d.fifoPath(id, name), 0600); err != nil {
+			closeFifos(fifos)
+			return nil, err
+		}
+		f, err := os.OpenFile(d.fifoPath(id, name), os.O_RDWR, 0)
+		if err != nil {
+			closeFifos(fifos)
+			return nil, err
+		}
+		if name != stdinFifo {
+			if _, _, errno := syscall.Syscall(syscall.SYS_FCNTL, f.Fd(), fSetPipeSz, fifoBufferSize); errno != 0 {
+				logrus.Debugf("Failed to enlarge the buffer of the fifo %s: %v", f.Name(), errno)
+			}
+		}
+		fifos = append(fifos, f)
+	}
+	return fifos, nil
+}
+
+func closeFifos(fifos []*os.File) {
+	for _, f := range fifos {
+		f.Close()
+	}
+}
+
+// openFifo opens the fifo at the given path with the given flag. Opening a
+// fifo blocks until it has both a reader and a writer, and the process of the
+// container may have exited already, so it is opened in non-blocking mode
+// first. The stdin fifo can't be opened for writing if the process exited.
+func openFifo(path string, flag int) (*os.File, error) {
+	f, err := os.OpenFile(path, flag|syscall.O_NONBLOCK, 0)
+	if err != nil {
+		return nil, err
+	}
+	if err := syscall.SetNonblock(int(f.Fd()), false); err != nil {
+		f.Close()
+		return nil, err
+	}
+	return f, nil
+}
+
+// copyFifos copies the given stdin to the stdin fifo of the container with the
+// given id, if it has one, and its output from its other fifos to the given
+// pipes. The returned function waits until all the processes of the container
+// have closed their ends of the output fifos.
+func (d *Driver) copyFifos(id string, pipes *execdriver.Pipes) (func(), error) {
+	if pipes.Stdin != nil {
+		stdin, err := openFifo(d.fifoPath(id, stdinFifo), os.O_WRONLY)
+		if err == nil {
+			go func() {
+				pools.Copy(stdin, pipes.Stdin)
+				stdin.Close()
+			}()
+		} else if perr, ok := err.(*os.PathError); !ok || perr.Err != syscall.ENXIO {
+			return nil, err
+		}
+	}
+
+	stdout, err := openFifo(d.fifoPath(id, stdoutFifo), os.O_RDONLY)
+	if err != nil {
+		return nil, err
+	}
+	stderr, err := openFifo(d.fifoPath(id, stderrFifo), os.O_RDONLY)
+	if err != nil {
+		stdout.Close()
+		return nil, err
+	}
+
+	var wg sync.WaitGroup
+	copyFifo := func(f *os.File, w io.Writer) {
+		defer wg.Done()
+		defer f.Close()
+		pools.Copy(w, f)
+	}
+	wg.Add(2)
+	go copyFifo(stdout, pipes.Stdout)
+	go copyFifo(stderr, pipes.Stderr)
+	return wg.Wait, nil
+}
+
+// watchExit returns a channel which receives the exit code of the process
+// with the given pid, or nil if the process events are not available.
+func (d *Driver) watchExit(pid int) <-chan int {
+	d.exitsOnce.Do(func() {
+		w, err := newExitWatcher()
+		if err != nil {
+			logrus.Warnf("Cannot listen to process events, the exit code of restored containers will be lost: %v", err)
+			return
+		}
+		d.exits = w
+	})
+	if d.exits == nil {
+		return nil
+	}
+	exited, err := d.exits.watch(pid)
+	if err != nil {
+		logrus.Warnf("Cannot watch the exit of process %d: %v", pid, err)
+		return nil
+	}
+	return exited
+}
+
+// waitRestored waits for the process with the given pid and start time to
+// exit, and returns its exit code if known. The process is checked from time
+// to time, as its exit event may not be received.
+func (d *Driver) waitRestored(pid int, startTime string) (int, bool) {
+	exited := d.watchExit(pid)
+	if exited != nil {
+		defer d.exits.unwatch(pid)
+	}
+	running := func() bool {
+		t, err := system.GetProcessStartTime(pid)
+		return err == nil && t == startTime
+	}
+	for running() {
+		select {
+		case exitCode := <-exited:
+			return exitCode, true
+		case <-time.After(restorePollInterval):
+		}
+	}
+	// the exit event may be on its way
+	select {
+	case exitCode := <-exited:
+		return exitCode, true
+	case <-time.After(restorePollInterval):
+		return execdriver.UnknownExitCode, false
+	}
+}
+
+// Restore implements the exec driver Driver interface,
+// it loads the libcontainer container left running by a previous daemon,
+// reconnects its stdio to its fifos and waits for its process to exit.
+func (d *Driver) Restore(c *execdriver.Command, pipes *execdriver.Pipes, hooks execdriver.Hooks) (execdriver.ExitStatus, error) {
+	if _, err := os.Stat(d.fifoPath(c.ID, stdoutFifo)); err != nil {
+		return execdriver.ExitStatus{ExitCode: -1}, fmt.Errorf("container %s was not started with live restore", c.ID)
+	}
+	if _, err := os.Stat(d.fifoPath(c.ID, stdinFifo)); err != nil {
+		pipes.Stdin = nil
+	}
+
+	cont, err := d.factory.Load(c.ID)
+	if err != nil {
+		return execdriver.ExitStatus{ExitCode: -1}, err
+	}
+	state, err := cont.State()
+	if err != nil {
+		return execdriver.ExitStatus{ExitCode: -1}, err
+	}
+	waitOutput, err := d.copyFifos(c.ID, pipes)
+	if err != nil {
+		return execdriver.ExitStatus{ExitCode: -1}, err
+	}
+	c.ProcessConfig.Terminal = &execdriver.StdConsole{}
+
+	d.Lock()
+	d.activeContainers[c.ID] = cont
+	d.Unlock()
+	defer func() {
+		cont.Destroy()
+		d.cleanContainer(c.ID)
+	}()
+
+	pid := state.InitProcessPid
+	removePidsCgroup := pidsCgroupCleanup(c, pid)
+	if hooks.Start != nil {
+		if err := hooks.Start(&c.ProcessConfig, pid); err != nil {
+			// the state of the container goes away with this restore
+			if t, serr := system.GetProcessStartTime(pid); serr == nil && t == state.InitProcessStartTime {
+				syscall.Kill(pid, syscall.SIGKILL)
+			}
+			d.waitRestored(pid, state.InitProcessStartTime)
+			removePidsCgroup()
+			return execdriver.ExitStatus{ExitCode: -1}, err
+		}
+	}
+
+	oom := notifyOnOOM(cont)
+	exitCode, known := d.waitRestored(pid, state.InitProcessStartTime)
+	cont.Destroy()
+	removePidsCgroup()
+	waitOutput()
+	_, oomKill := <-oom
+	return execdriver.ExitStatus{ExitCode: exitCode, OOMKilled: oomKill, ExitCodeUnknown: !known}, nil
+}
@@ -0,0 +1,155 @@
+// +build linux,cgo
+
+package native
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"testing"
+
+	"github.com/docker/docker/daemon/execdriver"
+	"github.com/vishvananda/netlink/nl"
+)
+
+func TestFifosOutliveReader(t *testing.T) {
+	root, err := ioutil.TempDir("", "docker-native-restore-test-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+	if err := os.Mkdir(filepath.Join(root, "id"), 0700); err != nil {
+		t.Fatal(err)
+	}
+	d := &Driver{root: root}
+
+	fifos, err := d.createFifos("id", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	stdout, stderr := fifos[0], fifos[1]
+
+	// the process writes while no daemon reads its output
+	if _, err := stdout.Write([]byte("before ")); err != nil {
+		t.Fatal(err)
+	}
+
+	var outBuf, errBuf bytes.Buffer
+	waitOutput, err := d.copyFifos("id", &execdriver.Pipes{Stdout: &outBuf, Stderr: &errBuf})
+	if err != nil {
+		t.Fatal(err)
+	}
+	stdout.Write([]byte("after"))
+	stderr.Write([]byte("error"))
+	stdout.Close()
+	stderr.Close()
+	waitOutput()
+
+	if outBuf.String() != "before after" {
+		t.Fatalf("Expected stdout %q, got %q", "before after", outBuf.String())
+	}
+	if errBuf.String() != "error" {
+		t.Fatalf("Expected stderr %q, got %q", "error", errBuf.String())
+	}
+}
+
+func TestCopyFifosWithoutWriter(t *testing.T) {
+	root, err := ioutil.TempDir("", "docker-native-restore-test-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+	if err := os.Mkdir(filepath.Join(root, "id"), 0700); err != nil {
+		t.Fatal(err)
+	}
+	d := &Driver{root: root}
+
+	fifos, err := d.createFifos("id", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	stdout, stderr := fifos[0], fifos[1]
+	// the process exited before the daemon got restored
+	stdout.Close()
+	stderr.Close()
+
+	var outBuf, errBuf bytes.Buffer
+	waitOutput, err := d.copyFifos("id", &execdriver.Pipes{Stdout: &outBuf, Stderr: &errBuf})
+	if err != nil {
+		t.Fatal(err)
+	}
+	waitOutput()
+}
+
+func TestStdinFifo(t *testing.T) {
+	root, err := ioutil.TempDir("", "docker-native-restore-test-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+	if err := os.Mkdir(filepath.Join(root, "id"), 0700); err != nil {
+		t.Fatal(err)
+	}
+	d := &Driver{root: root}
+
+	fifos, err := d.createFifos("id", true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(fifos) != 3 {
+		t.Fatalf("Expected 3 fifos with stdin, got %d", len(fifos))
+	}
+	stdin := fifos[0]
+
+	var outBuf, errBuf bytes.Buffer
+	waitOutput, err := d.copyFifos("id", &execdriver.Pipes{Stdin: ioutil.NopCloser(strings.NewReader("input")), Stdout: &outBuf, Stderr: &errBuf})
+	if err != nil {
+		t.Fatal(err)
+	}
+	buf := make([]byte, len("input"))
+	if _, err := io.ReadFull(stdin, buf); err != nil {
+		t.Fatal(err)
+	}
+	if string(buf) != "input" {
+		t.Fatalf("Expected stdin %q, got %q", "input", buf)
+	}
+	closeFifos(fifos)
+	waitOutput()
+
+	// the process exited before the daemon got restored
+	if _, err := d.copyFifos("id", &execdriver.Pipes{Stdin: ioutil.NopCloser(strings.NewReader("input")), Stdout: &outBuf, Stderr: &errBuf}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestParseExitEvent(t *testing.T) {
+	event := func(what, pid, tgid, exitCode uint32) []byte {
+		b := make([]byte, cnMsgLen+32)
+		native := nl.NativeEndian()
+		native.PutUint32(b[cnMsgLen:], what)
+		native.PutUint32(b[cnMsgLen+16:], pid)
+		native.PutUint32(b[cnMsgLen+20:], tgid)
+		native.PutUint32(b[cnMsgLen+24:], exitCode)
+		return b
+	}
+
+	if pid, exitCode, ok := parseExitEvent(event(procEventExit, 42, 42, 3<<8)); !ok || pid != 42 || exitCode != 3 {
+		t.Fatalf("Expected process 42 to exit with 3, got %d, %d, %v", pid, exitCode, ok)
+	}
+	if _, exitCode, ok := parseExitEvent(event(procEventExit, 42, 42, uint32(syscall.SIGKILL))); !ok || exitCode != 137 {
+		t.Fatalf("Expected exit code 137 for a killed process, got %d, %v", exitCode, ok)
+	}
+	if _, _, ok := parseExitEvent(event(procEventExit, 43, 42, 0)); ok {
+		t.Fatal("Expected the exit of a thread to be ignored")
+	}
+	if _, _, ok := parseExitEvent(event(1, 42, 42, 0)); ok {
+		t.Fatal("Expected a fork event to be ignored")
+	}
+	if _, _, ok := parseExitEvent(event(procEventExit, 42, 42, 0)[:cnMsgLen+16]); ok {
+		t.Fatal("Expected a short event to be ignored")
+	}
+}
@@ -0,0 +1,121 @@
+// +build linux,cgo
+
+package native
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/docker/docker/daemon/execdriver"
+	"github.com/opencontainers/runc/libcontainer/configs"
+)
+
+// seccompProfile is the JSON format of the seccomp profiles given with
+// --security-opt seccomp=PROFILE. The actions and comparison operators use
+// the names libseccomp assigns them, e.g. SCMP_ACT_ERRNO and SCMP_CMP_EQ.
+type seccompProfile struct {
+	DefaultAction string            `json:"defaultAction"`
+	Syscalls      []*seccompSyscall `json:"syscalls"`
+}
+
+type seccompSyscall struct {
+	Name   string        `json:"name"`
+	Action string        `json:"action"`
+	Args   []*seccompArg `json:"args"`
+}
+
+type seccompArg struct {
+	Index    uint   `json:"index"`
+	Value    uint64 `json:"value"`
+	ValueTwo uint64 `json:"valueTwo"`
+	Op       string `json:"op"`
+}
+
+var seccompActions = map[string]configs.Action{
+	"SCMP_ACT_KILL":  configs.Kill,
+	"SCMP_ACT_TRAP":  configs.Trap,
+	"SCMP_ACT_ERRNO": configs.Errno,
+	"SCMP_ACT_ALLOW": configs.Allow,
+}
+
+var seccompOperators = map[string]configs.Operator{
+	"SCMP_CMP_NE":        configs.NotEqualTo,
+	"SCMP_CMP_LT":        configs.LessThan,
+	"SCMP_CMP_LE":        configs.LessThanOrEqualTo,
+	"SCMP_CMP_EQ":        configs.EqualTo,
+	"SCMP_CMP_GE":        configs.GreaterThanOrEqualTo,
+	"SCMP_CMP_GT":        configs.GreaterThan,
+	"SCMP_CMP_MASKED_EQ": configs.MaskEqualTo,
+}
+
+// errSeccompNotSupported is returned for the containers given a seccomp
+// profile when the daemon was built without seccomp support.
+var errSeccompNotSupported = errors.New("seccomp profiles are not supported by this daemon, it was built without seccomp support")
+
+// setupSeccomp sets the seccomp filter of the container. Unless it is
+// privileged, a container given no profile gets the default one.
+func setupSeccomp(container *configs.Config, c *execdriver.Command) error {
+	switch c.SeccompProfile {
+	case "unconfined":
+		return nil
+	case "":
+		if c.ProcessConfig.Privileged || !seccompSupported {
+			return nil
+		}
+		container.Seccomp = defaultSeccompProfile
+		return nil
+	}
+
+	if !seccompSupported {
+		return errSeccompNotSupported
+	}
+	profile, err := loadSeccompProfile(c.SeccompProfile)
+	if err != nil {
+		return err
+	}
+	container.Seccomp = profile
+	return nil
+}
+
+// loadSeccompProfile converts the JSON seccomp profile body to the seccomp
+// config of libcontainer.
+func loadSeccompProfile(body string) (*configs.Seccomp, error) {
+	var profile seccompProfile
+	if err := json.Unmarshal([]byte(body), &profile); err != nil {
+		return nil, fmt.Errorf("Decoding seccomp profile failed: %v", err)
+	}
+
+	defaultAction, ok := seccompActions[profile.DefaultAction]
+	if !ok {
+		return nil, fmt.Errorf("Invalid seccomp default action %q", profile.DefaultAction)
+	}
+	config := &configs.Seccomp{DefaultAction: defaultAction}
+	for _, call := range profile.Syscalls {
+		if call == nil || call.Name == "" {
+			return nil, fmt.Errorf("Invalid seccomp syscall without name")
+		}
+		action, ok := seccompActions[call.Action]
+		if !ok {
+			return nil, fmt.Errorf("Invalid seccomp action %q for syscall %s", call.Action, call.Name)
+		}
+		syscall := &configs.Syscall{Name: call.Name, Action: action}
+		for _, arg := range call.Args {
+			if arg == nil {
+				return nil, fmt.Errorf("Invalid seccomp argument for syscall %s", call.Name)
+			}
+			op, ok := seccompOperators[arg.Op]
+			if !ok {
+				return nil, fmt.Errorf("Invalid seccomp operator %q for syscall %s", arg.Op, call.Name)
+			}
+			syscall.Args = append(syscall.Args, &configs.Arg{
+				Index:    arg.Index,
+				Value:    arg.Value,
+				ValueTwo: arg.ValueTwo,
+				Op:       op,
+			})
+		}
+		config.Syscalls = append(config.Syscalls, syscall)
+	}
+	return config, nil
+}
@@ -0,0 +1,215 @@
+// +build linux,cgo
+
+package native
+
+import "github.com/opencontainers/runc/libcontainer/configs"
+
+// defaultSeccompProfile is the seccomp profile of the non-privileged
+// containers given no other profile. It allows all syscalls but those
+// reaching kernel subsystems that aren't namespaced, or that have been
+// the source of kernel vulnerabilities, which fail with EPERM.
+var defaultSeccompProfile = &configs.Seccomp{
+	DefaultAction: configs.Allow,
+	Syscalls: []*configs.Syscall{
+		{
+			// Switch process accounting on or off
+			Name:   "acct",
+			Action: configs.Errno,
+		},
+		{
+			// Use the kernel keyring, which isn't namespaced
+			Name:   "add_key",
+			Action: configs.Errno,
+		},
+		{
+			// Load eBPF programs into the kernel
+			Name:   "bpf",
+			Action: configs.Errno,
+		},
+		{
+			// Adjust the clock of the host
+			Name:   "clock_adjtime",
+			Action: configs.Errno,
+		},
+		{
+			// Set the clock of the host
+			Name:   "clock_settime",
+			Action: configs.Errno,
+		},
+		{
+			// Load kernel modules
+			Name:   "create_module",
+			Action: configs.Errno,
+		},
+		{
+			// Unload kernel modules
+			Name:   "delete_module",
+			Action: configs.Errno,
+		},
+		{
+			// Load kernel modules
+			Name:   "finit_module",
+			Action: configs.Errno,
+		},
+		{
+			// Retrieve kernel symbols
+			Name:   "get_kernel_syms",
+			Action: configs.Errno,
+		},
+		{
+			// Load kernel modules
+			Name:   "init_module",
+			Action: configs.Errno,
+		},
+		{
+			// Access the I/O ports of the host
+			Name:   "ioperm",
+			Action: configs.Errno,
+		},
+		{
+			// Access the I/O ports of the host
+			Name:   "iopl",
+			Action: configs.Errno,
+		},
+		{
+			// Inspect the kernel resources of other processes
+			Name:   "kcmp",
+			Action: configs.Errno,
+		},
+		{
+			// Load a new kernel
+			Name:   "kexec_file_load",
+			Action: configs.Errno,
+		},
+		{
+			// Load a new kernel
+			Name:   "kexec_load",
+			Action: configs.Errno,
+		},
+		{
+			// Use the kernel keyring, which isn't namespaced
+			Name:   "keyctl",
+			Action: configs.Errno,
+		},
+		{
+			// Profile the host
+			Name:   "lookup_dcookie",
+			Action: configs.Errno,
+		},
+		{
+			// Open files by handle, out of the root of the container
+			Name:   "name_to_handle_at",
+			Action: configs.Errno,
+		},
+		{
+			// Control the NFS daemon of the host
+			Name:   "nfsservctl",
+			Action: configs.Errno,
+		},
+		{
+			// Open files by handle, out of the root of the container
+			Name:   "open_by_handle_at",
+			Action: configs.Errno,
+		},
+		{
+			// Trace and profile the host
+			Name:   "perf_event_open",
+			Action: configs.Errno,
+		},
+		{
+			// Read the memory of other processes
+			Name:   "process_vm_readv",
+			Action: configs.Errno,
+		},
+		{
+			// Write the memory of other processes
+			Name:   "process_vm_writev",
+			Action: configs.Errno,
+		},
+		{
+			// Trace other processes, which bypasses seccomp before kernel 4.8
+			Name:   "ptrace",
+			Action: configs.Errno,
+		},
+		{
+			// Query kernel modules
+			Name:   "query_module",
+			Action: configs.Errno,
+		},
+		{
+			// Manage the disk quotas of the host
+			Name:   "quotactl",
+			Action: configs.Errno,
+		},
+		{
+			// Reboot the host
+			Name:   "reboot",
+			Action: configs.Errno,
+		},
+		{
+			// Use the kernel keyring, which isn't namespaced
+			Name:   "request_key",
+			Action: configs.Errno,
+		},
+		{
+			// Set the clock of the host
+			Name:   "settimeofday",
+			Action: configs.Errno,
+		},
+		{
+			// Set the clock of the host
+			Name:   "stime",
+			Action: configs.Errno,
+		},
+		{
+			// Disable swap on the host
+			Name:   "swapoff",
+			Action: configs.Errno,
+		},
+		{
+			// Enable swap on the host
+			Name:   "swapon",
+			Action: configs.Errno,
+		},
+		{
+			// Set kernel parameters, obsoleted by /proc/sys
+			Name:   "_sysctl",
+			Action: configs.Errno,
+		},
+		{
+			// Obsolete syscall
+			Name:   "sysfs",
+			Action: configs.Errno,
+		},
+		{
+			// Create namespaces, notably user namespaces which exposed many kernel bugs
+			Name:   "unshare",
+			Action: configs.Errno,
+		},
+		{
+			// Obsolete syscall
+			Name:   "uselib",
+			Action: configs.Errno,
+		},
+		{
+			// Handle page faults in userspace
+			Name:   "userfaultfd",
+			Action: configs.Errno,
+		},
+		{
+			// Obsolete syscall
+			Name:   "ustat",
+			Action: configs.Errno,
+		},
+		{
+			// Run in virtual 8086 mode
+			Name:   "vm86",
+			Action: configs.Errno,
+		},
+		{
+			// Run in virtual 8086 mode
+			Name:   "vm86old",
+			Action: configs.Errno,
+		},
+	},
+}
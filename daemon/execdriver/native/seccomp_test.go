@@ -0,0 +1,106 @@
+// +build linux,cgo
+
+package native
+
+import (
+	"testing"
+
+	"github.com/docker/docker/daemon/execdriver"
+	"github.com/opencontainers/runc/libcontainer/configs"
+)
+
+func TestLoadSeccompProfile(t *testing.T) {
+	profile, err := loadSeccompProfile(`{
+		"defaultAction": "SCMP_ACT_ERRNO",
+		"syscalls": [
+			{"name": "read", "action": "SCMP_ACT_ALLOW"},
+			{"name": "personality", "action": "SCMP_ACT_ALLOW", "args": [{"index": 0, "value": 8, "op": "SCMP_CMP_GE"}]}
+		]
+	}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if profile.DefaultAction != configs.Errno {
+		t.Fatalf("Expected the default action to be Errno, got %v", profile.DefaultAction)
+	}
+	if len(profile.Syscalls) != 2 {
+		t.Fatalf("Expected 2 syscalls, got %d", len(profile.Syscalls))
+	}
+	call := profile.Syscalls[1]
+	if call.Name != "personality" || call.Action != configs.Allow || len(call.Args) != 1 {
+		t.Fatalf("Unexpected syscall %+v", call)
+	}
+	if arg := call.Args[0]; arg.Index != 0 || arg.Value != 8 || arg.Op != configs.GreaterThanOrEqualTo {
+		t.Fatalf("Unexpected argument %+v", arg)
+	}
+
+	for _, invalid := range []string{
+		`{"defaultAction": "SCMP_ACT_ERRNO"`,
+		`{"defaultAction": "SCMP_ACT_TRACE"}`,
+		`{"defaultAction": "SCMP_ACT_ERRNO", "syscalls": [{"action": "SCMP_ACT_ALLOW"}]}`,
+		`{"defaultAction": "SCMP_ACT_ERRNO", "syscalls": [{"name": "read", "action": "ALLOW"}]}`,
+		`{"defaultAction": "SCMP_ACT_ERRNO", "syscalls": [{"name": "read", "action": "SCMP_ACT_ALLOW", "args": [{"op": "SCMP_CMP_ANY"}]}]}`,
+	} {
+		if _, err := loadSeccompProfile(invalid); err == nil {
+			t.Fatalf("Expected an error loading the profile %s", invalid)
+		}
+	}
+}
+
+func TestSetupSeccomp(t *testing.T) {
+	container := &configs.Config{}
+	c := &execdriver.Command{}
+	if err := setupSeccomp(container, c); err != nil {
+		t.Fatal(err)
+	}
+	if seccompSupported && container.Seccomp != defaultSeccompProfile {
+		t.Fatal("Expected the default seccomp profile")
+	}
+	if !seccompSupported && container.Seccomp != nil {
+		t.Fatal("Expected no seccomp profile without seccomp support")
+	}
+
+	for _, c := range []*execdriver.Command{
+		{SeccompProfile: "unconfined"},
+		{ProcessConfig: execdriver.ProcessConfig{Privileged: true}},
+	} {
+		container := &configs.Config{}
+		if err := setupSeccomp(container, c); err != nil {
+			t.Fatal(err)
+		}
+		if container.Seccomp != nil {
+			t.Fatalf("Expected no seccomp profile for %+v", c)
+		}
+	}
+
+	container = &configs.Config{}
+	c = &execdriver.Command{SeccompProfile: `{"defaultAction": "SCMP_ACT_ALLOW"}`}
+	err := setupSeccomp(container, c)
+	if !seccompSupported {
+		if err != errSeccompNotSupported {
+			t.Fatalf("Expected %v, got %v", errSeccompNotSupported, err)
+		}
+		return
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	if container.Seccomp == nil || container.Seccomp.DefaultAction != configs.Allow {
+		t.Fatalf("Expected the given seccomp profile, got %+v", container.Seccomp)
+	}
+}
+
+func TestDefaultSeccompProfile(t *testing.T) {
+	blocked := make(map[string]bool)
+	for _, call := range defaultSeccompProfile.Syscalls {
+		if call.Action != configs.Errno {
+			t.Fatalf("Expected %s to fail with an errno", call.Name)
+		}
+		blocked[call.Name] = true
+	}
+	for _, name := range []string{"keyctl", "add_key", "request_key", "unshare"} {
+		if !blocked[name] {
+			t.Fatalf("Expected %s to be blocked by the default profile", name)
+		}
+	}
+}
@@ -0,0 +1,7 @@
+// +build linux,cgo,!seccomp
+
+package native
+
+// seccompSupported is whether libcontainer can install the seccomp filters
+// of the containers, which requires building with the seccomp tag.
+const seccompSupported = false
@@ -305,6 +305,12 @@ func (d *Driver) Run(c *execdriver.Command, pipes *execdriver.Pipes, hooks execd
 	return execdriver.ExitStatus{ExitCode: int(exitCode)}, nil
 }
 
+// Restore implements the exec driver Driver interface.
+// The windows driver does not restore the containers left running by a previous daemon.
+func (d *Driver) Restore(c *execdriver.Command, pipes *execdriver.Pipes, hooks execdriver.Hooks) (execdriver.ExitStatus, error) {
+	return execdriver.ExitStatus{ExitCode: -1}, execdriver.ErrRestoreNotSupported
+}
+
 // SupportsHooks implements the execdriver Driver interface.
 // The windows driver does not support the hook mechanism
 func (d *Driver) SupportsHooks() bool {
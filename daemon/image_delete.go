@@ -63,7 +63,7 @@ func (daemon *Daemon) ImageDelete(imageRef string, force, prune bool) ([]types.I
 		// first. We can only remove this reference if either force is
 		// true, there are multiple repository references to this
 		// image, or there are no containers using the given reference.
-		if !(force || daemon.imageHasMultipleRepositoryReferences(img.ID)) {
+		if !(force || daemon.imageHasMultipleRepositoryReferences(img.ID, imageRef)) {
 			if container := daemon.getContainerUsingImage(img.ID); container != nil {
 				// If we removed the repository reference then
 				// this image would remain "dangling" and since
@@ -83,23 +83,28 @@ func (daemon *Daemon) ImageDelete(imageRef string, force, prune bool) ([]types.I
 		daemon.EventsService.Log("untag", img.ID, "")
 		records = append(records, untaggedRecord)
 
+		// The digest references recorded by pulls by tag go with the
+		// last tag.
+		if _, ref := parsers.ParseRepositoryTag(parsedRef); !utils.DigestReference(ref) {
+			if len(tagReferences(daemon.Repositories().ByID()[img.ID])) == 0 {
+				if err := daemon.removeAllReferencesToImageID(img.ID, &records); err != nil {
+					return nil, err
+				}
+			}
+		}
+
 		removedRepositoryRef = true
 	} else {
 		// If an ID reference was given AND there is exactly one
-		// repository reference to the image then we will want to
-		// remove that reference.
+		// repository reference to the image, or one tag along with the
+		// digest references recorded by pulling it, then we will want to
+		// remove these references.
 		// FIXME: Is this the behavior we want?
 		repoRefs := daemon.Repositories().ByID()[img.ID]
-		if len(repoRefs) == 1 {
-			parsedRef, err := daemon.removeImageRef(repoRefs[0])
-			if err != nil {
+		if len(repoRefs) == 1 || len(tagReferences(repoRefs)) == 1 {
+			if err := daemon.removeAllReferencesToImageID(img.ID, &records); err != nil {
 				return nil, err
 			}
-
-			untaggedRecord := types.ImageDelete{Untagged: parsedRef}
-
-			daemon.EventsService.Log("untag", img.ID, "")
-			records = append(records, untaggedRecord)
 		}
 	}
 
@@ -113,9 +118,27 @@ func isImageIDPrefix(imageID, possiblePrefix string) bool {
 }
 
 // imageHasMultipleRepositoryReferences returns whether there are multiple
-// repository references to the given imageID.
-func (daemon *Daemon) imageHasMultipleRepositoryReferences(imageID string) bool {
-	return len(daemon.Repositories().ByID()[imageID]) > 1
+// repository references to the given imageID besides the given one. Digest
+// references don't count when the given one is a tag since they are removed
+// along with the last tag.
+func (daemon *Daemon) imageHasMultipleRepositoryReferences(imageID, repositoryRef string) bool {
+	repoRefs := daemon.Repositories().ByID()[imageID]
+	if _, ref := parsers.ParseRepositoryTag(repositoryRef); !utils.DigestReference(ref) {
+		repoRefs = tagReferences(repoRefs)
+	}
+	return len(repoRefs) > 1
+}
+
+// tagReferences returns the tag references among the given repository
+// references.
+func tagReferences(repoRefs []string) []string {
+	var tagRefs []string
+	for _, repoRef := range repoRefs {
+		if _, ref := parsers.ParseRepositoryTag(repoRef); !utils.DigestReference(ref) {
+			tagRefs = append(tagRefs, repoRef)
+		}
+	}
+	return tagRefs
 }
 
 // getContainerUsingImage returns a container that was created using the given
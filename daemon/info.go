@@ -17,6 +17,12 @@ import (
 	"github.com/docker/docker/utils"
 )
 
+// seccompDriver is implemented by the exec drivers which may apply seccomp
+// profiles to the containers.
+type seccompDriver interface {
+	SeccompSupported() bool
+}
+
 // SystemInfo returns information about the host server the daemon is running on.
 func (daemon *Daemon) SystemInfo() (*types.Info, error) {
 	images := daemon.Graph().Map()
@@ -93,6 +99,10 @@ func (daemon *Daemon) SystemInfo() (*types.Info, error) {
 		ServerVersion:      dockerversion.VERSION,
 	}
 
+	if d, ok := daemon.ExecutionDriver().(seccompDriver); ok {
+		v.Seccomp = d.SeccompSupported()
+	}
+
 	// TODO Windows. Refactor this more once sysinfo is refactored into
 	// platform specific code. On Windows, sysinfo.cgroupMemInfo and
 	// sysinfo.cgroupCpuInfo will be nil otherwise and cause a SIGSEGV if
@@ -101,6 +111,7 @@ func (daemon *Daemon) SystemInfo() (*types.Info, error) {
 		v.MemoryLimit = sysInfo.MemoryLimit
 		v.SwapLimit = sysInfo.SwapLimit
 		v.OomKillDisable = sysInfo.OomKillDisable
+		v.PidsLimit = sysInfo.PidsLimit
 		v.CPUCfsPeriod = sysInfo.CPUCfsPeriod
 		v.CPUCfsQuota = sysInfo.CPUCfsQuota
 	}
@@ -97,6 +97,7 @@ func (daemon *Daemon) getInspectData(container *Container) (*types.ContainerJSON
 		LogPath:         container.LogPath,
 		Name:            container.Name,
 		RestartCount:    container.RestartCount,
+		RestartReason:   container.RestartReason,
 		Driver:          container.Driver,
 		ExecDriver:      container.ExecDriver,
 		MountLabel:      container.MountLabel,
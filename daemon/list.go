@@ -11,7 +11,10 @@ import (
 	"github.com/docker/docker/image"
 	"github.com/docker/docker/pkg/graphdb"
 	"github.com/docker/docker/pkg/nat"
+	"github.com/docker/docker/pkg/parsers"
 	"github.com/docker/docker/pkg/parsers/filters"
+	"github.com/docker/docker/registry"
+	"github.com/docker/docker/utils"
 )
 
 // iterationAction represents possible outcomes happening during the container iteration.
@@ -292,13 +295,15 @@ func (daemon *Daemon) transformContainer(container *Container, ctx *listContext)
 	}
 
 	img, err := daemon.Repositories().LookupImage(container.Config.Image)
-	if err != nil {
-		// If the image can no longer be found by its original reference,
-		// it makes sense to show the ID instead of a stale reference.
-		newC.Image = container.ImageID
-	} else if container.ImageID == img.ID {
+	if err == nil && container.ImageID == img.ID {
 		newC.Image = container.Config.Image
+	} else if digestRef := daemon.imageDigestReference(container); digestRef != "" {
+		// The original reference was moved to another image, show the
+		// digest the image was pulled with instead.
+		newC.Image = digestRef
 	} else {
+		// If the image can no longer be found by its original reference,
+		// it makes sense to show the ID instead of a stale reference.
 		newC.Image = container.ImageID
 	}
 
@@ -389,6 +394,20 @@ func (daemon *Daemon) Volumes(filter string) ([]*types.Volume, error) {
 	return volumesOut, nil
 }
 
+// imageDigestReference returns a digest reference to the image of the
+// container in the repository it was created from, or an empty string if
+// there is none.
+func (daemon *Daemon) imageDigestReference(container *Container) string {
+	repoName, _ := parsers.ParseRepositoryTag(container.Config.Image)
+	repoName = registry.NormalizeLocalName(repoName)
+	for _, repoRef := range daemon.Repositories().ByID()[container.ImageID] {
+		if name, ref := parsers.ParseRepositoryTag(repoRef); name == repoName && utils.DigestReference(ref) {
+			return repoRef
+		}
+	}
+	return ""
+}
+
 func populateImageFilterByParents(ancestorMap map[string]bool, imageID string, byParents map[string][]*image.Image) {
 	if !ancestorMap[imageID] {
 		if images, ok := byParents[imageID]; ok {
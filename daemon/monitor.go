@@ -1,6 +1,7 @@
 package daemon
 
 import (
+	"fmt"
 	"io"
 	"os/exec"
 	"sync"
@@ -53,6 +54,10 @@ type containerMonitor struct {
 
 	// lastStartTime is the time which the monitor last exec'd the container's process
 	lastStartTime time.Time
+
+	// restoring is set until the monitor re-attached to the process which a
+	// previous instance of the daemon left running
+	restoring bool
 }
 
 // newContainerMonitor returns an initialized containerMonitor for the provided container
@@ -124,11 +129,20 @@ func (m *containerMonitor) Start() error {
 		m.container.HasBeenManuallyStopped = false
 	}
 
-	// reset the restart count
-	m.container.RestartCount = -1
+	// reset the restart count, which goes on for a restored container
+	if !m.restoring {
+		m.container.RestartCount = -1
+	}
+	// resume the delay between the restarts, which goes on across daemon
+	// restarts until the container is started manually
+	if m.container.RestartDelay > 0 {
+		m.timeIncrement = m.container.RestartDelay
+	}
 
 	for {
-		m.container.RestartCount++
+		if !m.restoring {
+			m.container.RestartCount++
+		}
 
 		if err := m.container.startLogging(); err != nil {
 			m.resetContainer(false)
@@ -138,14 +152,18 @@ func (m *containerMonitor) Start() error {
 
 		pipes := execdriver.NewPipes(m.container.stdin, m.container.stdout, m.container.stderr, m.container.Config.OpenStdin)
 
-		m.container.logEvent("start")
-
 		m.lastStartTime = time.Now()
 
-		if exitStatus, err = m.container.daemon.run(m.container, pipes, m.callback); err != nil {
-			// if we receive an internal error from the initial start of a container then lets
-			// return it instead of entering the restart loop
-			if m.container.RestartCount == 0 {
+		if m.restoring {
+			exitStatus, err = m.container.daemon.reattach(m.container, pipes, m.callback)
+		} else {
+			m.container.logEvent("start")
+			exitStatus, err = m.container.daemon.run(m.container, pipes, m.callback)
+		}
+		if err != nil {
+			// if we receive an internal error from the initial start or the restore of a
+			// container then lets return it instead of entering the restart loop
+			if m.container.RestartCount == 0 || m.restoring {
 				m.container.ExitCode = -1
 				m.resetContainer(false)
 
@@ -154,18 +172,22 @@ func (m *containerMonitor) Start() error {
 
 			logrus.Errorf("Error running container: %s", err)
 		}
+		m.restoring = false
 
 		// here container.Lock is already lost
 		afterRun = true
 
-		m.resetMonitor(err == nil && exitStatus.ExitCode == 0)
+		// a lost exit code isn't counted as a failure
+		m.resetMonitor(err == nil && (exitStatus.ExitCode == 0 || exitStatus.ExitCodeUnknown))
 
-		if m.shouldRestart(exitStatus.ExitCode) {
+		if m.shouldRestart(exitStatus) {
 			m.container.setRestarting(&exitStatus)
+			m.container.RestartReason = restartReason(exitStatus, err)
 			if exitStatus.OOMKilled {
 				m.container.logEvent("oom")
 			}
 			m.container.logEvent("die")
+			m.container.logRestartEvent()
 			m.resetContainer(true)
 
 			// sleep with a small time increment between each restart to help avoid issues cased by quickly
@@ -190,17 +212,28 @@ func (m *containerMonitor) Start() error {
 
 // resetMonitor resets the stateful fields on the containerMonitor based on the
 // previous runs success or failure.  Regardless of success, if the container had
-// an execution time of more than 10s then reset the timer back to the default
+// an execution time of more than the reset window of the restart policy then
+// reset the timer back to the default
 func (m *containerMonitor) resetMonitor(successful bool) {
-	executionTime := time.Now().Sub(m.lastStartTime).Seconds()
+	executionTime := time.Now().Sub(m.lastStartTime)
+
+	resetWindow := m.restartPolicy.ResetWindow
+	if resetWindow == 0 {
+		resetWindow = runconfig.DefaultRestartResetWindow
+	}
 
-	if executionTime > 10 {
+	if executionTime > resetWindow {
 		m.timeIncrement = defaultTimeIncrement
 	} else {
 		// otherwise we need to increment the amount of time we wait before restarting
-		// the process.  We will build up by multiplying the increment by 2
+		// the process.  We will build up by multiplying the increment by 2, up to the
+		// maximum delay of the restart policy
 		m.timeIncrement *= 2
+		if max := int(m.restartPolicy.MaximumDelay / time.Millisecond); max > 0 && m.timeIncrement > max {
+			m.timeIncrement = max
+		}
 	}
+	m.container.RestartDelay = m.timeIncrement
 
 	// the container exited successfully so we need to reset the failure counter
 	if successful {
@@ -221,7 +254,7 @@ func (m *containerMonitor) waitForNextRestart() {
 
 // shouldRestart checks the restart policy and applies the rules to determine if
 // the container's process should be restarted
-func (m *containerMonitor) shouldRestart(exitCode int) bool {
+func (m *containerMonitor) shouldRestart(exitStatus execdriver.ExitStatus) bool {
 	m.mux.Lock()
 	defer m.mux.Unlock()
 
@@ -242,12 +275,25 @@ func (m *containerMonitor) shouldRestart(exitCode int) bool {
 			return false
 		}
 
-		return exitCode != 0
+		return exitStatus.ExitCode != 0 && !exitStatus.ExitCodeUnknown
 	}
 
 	return false
 }
 
+// restartReason describes why a container whose process exited with the given
+// status and error is being restarted
+func restartReason(exitStatus execdriver.ExitStatus, err error) string {
+	switch {
+	case err != nil:
+		return err.Error()
+	case exitStatus.OOMKilled:
+		return "out of memory"
+	default:
+		return fmt.Sprintf("exit status %d", exitStatus.ExitCode)
+	}
+}
+
 // callback ensures that the container's state is properly updated after we
 // received ack from the execution drivers
 func (m *containerMonitor) callback(processConfig *execdriver.ProcessConfig, pid int) error {
@@ -260,7 +306,12 @@ func (m *containerMonitor) callback(processConfig *execdriver.ProcessConfig, pid
 		}
 	}
 
+	startedAt := m.container.StartedAt
 	m.container.setRunning(pid)
+	if m.restoring {
+		// the process was started by the previous daemon
+		m.container.StartedAt = startedAt
+	}
 
 	// signal that the process has started
 	// close channel only if not closed
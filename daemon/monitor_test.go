@@ -0,0 +1,90 @@
+package daemon
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/docker/docker/daemon/execdriver"
+	"github.com/docker/docker/runconfig"
+)
+
+func TestMonitorBackoff(t *testing.T) {
+	m := newContainerMonitor(&Container{}, runconfig.RestartPolicy{
+		Name:         "always",
+		MaximumDelay: 500 * time.Millisecond,
+	})
+
+	expected := []int{200, 400, 500, 500}
+	for _, e := range expected {
+		m.lastStartTime = time.Now()
+		m.resetMonitor(false)
+		if m.timeIncrement != e {
+			t.Fatalf("Expected a delay of %dms, got %dms", e, m.timeIncrement)
+		}
+	}
+
+	// the delay is reset once the container has run for longer than the
+	// default reset window
+	m.lastStartTime = time.Now().Add(-runconfig.DefaultRestartResetWindow - time.Second)
+	m.resetMonitor(false)
+	if m.timeIncrement != defaultTimeIncrement {
+		t.Fatalf("Expected a delay of %dms, got %dms", defaultTimeIncrement, m.timeIncrement)
+	}
+}
+
+func TestMonitorBackoffResetWindow(t *testing.T) {
+	m := newContainerMonitor(&Container{}, runconfig.RestartPolicy{
+		Name:        "always",
+		ResetWindow: time.Minute,
+	})
+
+	m.lastStartTime = time.Now().Add(-runconfig.DefaultRestartResetWindow - time.Second)
+	m.resetMonitor(false)
+	if m.timeIncrement != 2*defaultTimeIncrement {
+		t.Fatalf("Expected a delay of %dms, got %dms", 2*defaultTimeIncrement, m.timeIncrement)
+	}
+
+	m.lastStartTime = time.Now().Add(-time.Minute - time.Second)
+	m.resetMonitor(false)
+	if m.timeIncrement != defaultTimeIncrement {
+		t.Fatalf("Expected a delay of %dms, got %dms", defaultTimeIncrement, m.timeIncrement)
+	}
+}
+
+func TestRestartReason(t *testing.T) {
+	cases := []struct {
+		status   execdriver.ExitStatus
+		err      error
+		expected string
+	}{
+		{execdriver.ExitStatus{ExitCode: 1}, nil, "exit status 1"},
+		{execdriver.ExitStatus{ExitCode: 137, OOMKilled: true}, nil, "out of memory"},
+		{execdriver.ExitStatus{ExitCode: -1}, fmt.Errorf("cannot start"), "cannot start"},
+	}
+	for _, c := range cases {
+		if reason := restartReason(c.status, c.err); reason != c.expected {
+			t.Fatalf("Expected reason %q, got %q", c.expected, reason)
+		}
+	}
+}
+
+func TestMonitorBackoffSaved(t *testing.T) {
+	container := &Container{}
+	m := newContainerMonitor(container, runconfig.RestartPolicy{Name: "always"})
+	m.lastStartTime = time.Now()
+	m.resetMonitor(false)
+	if container.RestartDelay != 2*defaultTimeIncrement {
+		t.Fatalf("Expected the container to keep a delay of %dms, got %dms", 2*defaultTimeIncrement, container.RestartDelay)
+	}
+}
+
+func TestMonitorOnFailureUnknownExitCode(t *testing.T) {
+	m := newContainerMonitor(&Container{}, runconfig.RestartPolicy{Name: "on-failure"})
+	if !m.shouldRestart(execdriver.ExitStatus{ExitCode: execdriver.UnknownExitCode}) {
+		t.Fatal("Expected a restart after a failure")
+	}
+	if m.shouldRestart(execdriver.ExitStatus{ExitCode: execdriver.UnknownExitCode, ExitCodeUnknown: true}) {
+		t.Fatal("Expected no restart when the exit code is lost")
+	}
+}
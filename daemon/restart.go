@@ -3,17 +3,22 @@ package daemon
 import "fmt"
 
 // ContainerRestart stops and starts a container. It attempts to
-// gracefully stop the container within the given timeout, forcefully
+// gracefully stop the container within the given timeout, or the stop
+// timeout of the container if seconds is nil, forcefully
 // stopping it if the timeout is exceeded. If given a negative
 // timeout, ContainerRestart will wait forever until a graceful
 // stop. Returns an error if the container cannot be found, or if
 // there is an underlying error at any stage of the restart.
-func (daemon *Daemon) ContainerRestart(name string, seconds int) error {
+func (daemon *Daemon) ContainerRestart(name string, seconds *int) error {
 	container, err := daemon.Get(name)
 	if err != nil {
 		return err
 	}
-	if err := container.Restart(seconds); err != nil {
+	stopTimeout := container.stopTimeout()
+	if seconds != nil {
+		stopTimeout = *seconds
+	}
+	if err := container.Restart(stopTimeout); err != nil {
 		return fmt.Errorf("Cannot restart container %s: %s\n", name, err)
 	}
 	return nil
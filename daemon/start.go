@@ -44,6 +44,11 @@ func (daemon *Daemon) ContainerStart(name string, hostConfig *runconfig.HostConf
 		return err
 	}
 
+	// a manual start resets the delay between the automatic restarts
+	container.Lock()
+	container.RestartDelay = 0
+	container.Unlock()
+
 	if err := container.Start(); err != nil {
 		return derr.ErrorCodeCantStart.WithArgs(name, utils.GetErrorMessage(err))
 	}
@@ -25,6 +25,7 @@ type State struct {
 	Dead              bool
 	Pid               int
 	ExitCode          int
+	ExitCodeUnknown   bool   // the exit code is lost, see execdriver.UnknownExitCode
 	Error             string // contains last known error when starting the container
 	StartedAt         time.Time
 	FinishedAt        time.Time
@@ -190,6 +191,7 @@ func (s *State) setRunning(pid int) {
 	s.Paused = false
 	s.Restarting = false
 	s.ExitCode = 0
+	s.ExitCodeUnknown = false
 	s.Pid = pid
 	s.StartedAt = time.Now().UTC()
 	close(s.waitChan) // fire waiters for start
@@ -208,6 +210,7 @@ func (s *State) setStopped(exitStatus *execdriver.ExitStatus) {
 	s.Pid = 0
 	s.FinishedAt = time.Now().UTC()
 	s.ExitCode = exitStatus.ExitCode
+	s.ExitCodeUnknown = exitStatus.ExitCodeUnknown
 	s.OOMKilled = exitStatus.OOMKilled
 	close(s.waitChan) // fire waiters for stop
 	s.waitChan = make(chan struct{})
@@ -229,6 +232,7 @@ func (s *State) setRestarting(exitStatus *execdriver.ExitStatus) {
 	s.Pid = 0
 	s.FinishedAt = time.Now().UTC()
 	s.ExitCode = exitStatus.ExitCode
+	s.ExitCodeUnknown = exitStatus.ExitCodeUnknown
 	s.OOMKilled = exitStatus.OOMKilled
 	close(s.waitChan) // fire waiters for stop
 	s.waitChan = make(chan struct{})
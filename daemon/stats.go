@@ -56,6 +56,11 @@ func (daemon *Daemon) ContainerStats(prefixOrName string, config *ContainerStats
 		ss := convertStatsToAPITypes(update.Stats)
 		ss.PreCPUStats = preCPUStats
 		ss.MemoryStats.Limit = uint64(update.MemoryLimit)
+		ss.MemoryStats.Reservation = uint64(update.MemoryReservation)
+		ss.PidsStats = types.PidsStats{
+			Current: update.PidsCurrent,
+			Limit:   update.PidsLimit,
+		}
 		ss.Read = update.Read
 		ss.CPUStats.SystemUsage = update.SystemUsage
 		preCPUStats = ss.CPUStats
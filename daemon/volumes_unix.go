@@ -54,10 +54,28 @@ func (container *Container) setupMounts() ([]execdriver.Mount, error) {
 		}
 	}
 
+	mounts = append(mounts, container.ipcMounts()...)
+	mounts = append(mounts, container.tmpfsMounts()...)
+	mounts = append(mounts, container.initMounts()...)
 	mounts = sortMounts(mounts)
 	return append(mounts, container.networkMounts()...), nil
 }
 
+// tmpfsMounts returns the tmpfs mounts of the container, mounted in its
+// mount namespace only: their contents are neither committed nor exported.
+func (container *Container) tmpfsMounts() []execdriver.Mount {
+	var mounts []execdriver.Mount
+	for dest, data := range container.hostConfig.Tmpfs {
+		mounts = append(mounts, execdriver.Mount{
+			Source:      "tmpfs",
+			Destination: dest,
+			Writable:    true,
+			Data:        data,
+		})
+	}
+	return mounts
+}
+
 // parseBindMount validates the configuration of mount information in runconfig is valid.
 func parseBindMount(spec, volumeDriver string) (*mountPoint, error) {
 	bind := &mountPoint{
@@ -338,6 +356,9 @@ func (daemon *Daemon) registerMountPoints(container *Container, hostConfig *runc
 		if binds[bind.Destination] {
 			return derr.ErrorCodeVolumeDup.WithArgs(bind.Destination)
 		}
+		if _, ok := hostConfig.Tmpfs[bind.Destination]; ok {
+			return derr.ErrorCodeVolumeDup.WithArgs(bind.Destination)
+		}
 
 		if len(bind.Name) > 0 && len(bind.Driver) > 0 {
 			// create the volume
@@ -273,7 +273,7 @@ func (cli *DaemonCli) CmdDaemon(args ...string) error {
 	signal.Trap(func() {
 		api.Close()
 		<-serveAPIWait
-		shutdownDaemon(d, 15)
+		shutdownDaemon(d, d.ShutdownTimeout())
 		if pfile != nil {
 			if err := pfile.Remove(); err != nil {
 				logrus.Error(err)
@@ -288,7 +288,7 @@ func (cli *DaemonCli) CmdDaemon(args ...string) error {
 	// Daemon is fully initialized and handling API traffic
 	// Wait for serve API to complete
 	errAPI := <-serveAPIWait
-	shutdownDaemon(d, 15)
+	shutdownDaemon(d, d.ShutdownTimeout())
 	if errAPI != nil {
 		if pfile != nil {
 			if err := pfile.Remove(); err != nil {
@@ -302,17 +302,22 @@ func (cli *DaemonCli) CmdDaemon(args ...string) error {
 
 // shutdownDaemon just wraps daemon.Shutdown() to handle a timeout in case
 // d.Shutdown() is waiting too long to kill container or worst it's
-// blocked there
-func shutdownDaemon(d *daemon.Daemon, timeout time.Duration) {
+// blocked there. A negative timeout waits for d.Shutdown() forever.
+func shutdownDaemon(d *daemon.Daemon, timeout int) {
 	ch := make(chan struct{})
 	go func() {
 		d.Shutdown()
 		close(ch)
 	}()
+	if timeout < 0 {
+		<-ch
+		logrus.Debug("Clean shutdown succeeded")
+		return
+	}
 	select {
 	case <-ch:
 		logrus.Debug("Clean shutdown succeeded")
-	case <-time.After(timeout * time.Second):
+	case <-time.After(time.Duration(timeout) * time.Second):
 		logrus.Error("Force shutdown daemon")
 	}
 }
@@ -1,6 +1,7 @@
 package main
 
 import (
+	_ "github.com/docker/docker/daemon/execdriver/initproc"
 	_ "github.com/docker/docker/daemon/execdriver/lxc"
 	_ "github.com/docker/docker/daemon/execdriver/native"
 	"github.com/docker/docker/pkg/reexec"
@@ -90,4 +90,22 @@ var (
 		Description:    "The specified volume can not be an empty string",
 		HTTPStatusCode: http.StatusInternalServerError,
 	})
+
+	// ErrorCodeInvalidBuildSession is generated when the ID of a build
+	// context session is not a valid ID.
+	ErrorCodeInvalidBuildSession = errcode.Register(errGroup, errcode.ErrorDescriptor{
+		Value:          "INVALIDBUILDSESSION",
+		Message:        "Invalid build session ID: %s",
+		Description:    "The specified build session ID is not a 64 character hexadecimal string",
+		HTTPStatusCode: http.StatusBadRequest,
+	})
+
+	// ErrorCodeNoSuchBuildSession is generated when a build context session
+	// does not hold any build context.
+	ErrorCodeNoSuchBuildSession = errcode.Register(errGroup, errcode.ErrorDescriptor{
+		Value:          "NOSUCHBUILDSESSION",
+		Message:        "No such build session: %s",
+		Description:    "The daemon does not hold a build context for the specified session",
+		HTTPStatusCode: http.StatusNotFound,
+	})
 )
@@ -195,6 +195,22 @@ var (
 		HTTPStatusCode: http.StatusInternalServerError,
 	})
 
+	// ErrorCodeIPCNotShareable is generated when we try to join the IPC of
+	// a container with a private IPC.
+	ErrorCodeIPCNotShareable = errcode.Register(errGroup, errcode.ErrorDescriptor{
+		Value:          "IPCNOTSHAREABLE",
+		Message:        "cannot join IPC of container %s, its IPC is private",
+		HTTPStatusCode: http.StatusInternalServerError,
+	})
+
+	// ErrorCodeNoDockerInit is generated when a container should run an
+	// init but the daemon didn't find the dockerinit binary.
+	ErrorCodeNoDockerInit = errcode.Register(errGroup, errcode.ErrorDescriptor{
+		Value:          "NODOCKERINIT",
+		Message:        "cannot run an init in the container, dockerinit was not found",
+		HTTPStatusCode: http.StatusInternalServerError,
+	})
+
 	// ErrorCodeNotADir is generated when we try to create a directory
 	// but the path isn't a dir.
 	ErrorCodeNotADir = errcode.Register(errGroup, errcode.ErrorDescriptor{
@@ -0,0 +1,265 @@
+package graph
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/Sirupsen/logrus"
+	"github.com/docker/distribution/digest"
+	"github.com/docker/distribution/registry/api/v2"
+	"github.com/docker/docker/pkg/progressreader"
+	"github.com/docker/docker/pkg/stringid"
+	"github.com/docker/docker/pkg/system"
+	"github.com/docker/docker/pkg/units"
+)
+
+const (
+	// maxDownloadAttempts is the number of times the download of a blob is
+	// attempted before giving up on transient errors.
+	maxDownloadAttempts = 5
+	// partialDownloadMaxAge is the age after which the partial downloads
+	// that were never resumed are removed.
+	partialDownloadMaxAge = 7 * 24 * time.Hour
+	// statusTooManyRequests is the status of the responses of the
+	// registries which throttle the client, missing from net/http in Go 1.4.
+	statusTooManyRequests = 429
+)
+
+// downloadRetryDelay is the delay before retrying a failed download. It
+// doubles after each attempt.
+var downloadRetryDelay = time.Second
+
+// partialDownloads tracks the partial downloads being written, so that two
+// pulls never write the same file.
+type partialDownloads struct {
+	digests map[digest.Digest]struct{}
+	sync.Mutex
+}
+
+func (graph *Graph) partialDownloadsDir() string {
+	return filepath.Join(graph.root, "_tmp", "downloads")
+}
+
+// openPartialDownload opens the file keeping the partial download of the
+// blob with the given digest, creating it if needed. If another pull is
+// downloading the same blob, an empty temporary file is returned instead.
+// The returned function closes the file, and removes it unless keep is set
+// to keep a partial download for a later pull to resume.
+func (graph *Graph) openPartialDownload(dgst digest.Digest) (*os.File, func(keep bool), error) {
+	graph.downloads.Lock()
+	_, claimed := graph.downloads.digests[dgst]
+	if !claimed {
+		graph.downloads.digests[dgst] = struct{}{}
+	}
+	graph.downloads.Unlock()
+
+	if claimed {
+		f, err := graph.newTempFile()
+		if err != nil {
+			return nil, nil, err
+		}
+		return f, func(bool) {
+			f.Close()
+			os.RemoveAll(filepath.Dir(f.Name()))
+		}, nil
+	}
+
+	unclaim := func() {
+		graph.downloads.Lock()
+		delete(graph.downloads.digests, dgst)
+		graph.downloads.Unlock()
+	}
+
+	dir := graph.partialDownloadsDir()
+	if err := system.MkdirAll(dir, 0700); err != nil {
+		unclaim()
+		return nil, nil, err
+	}
+	f, err := os.OpenFile(filepath.Join(dir, string(dgst.Algorithm())+"-"+dgst.Hex()), os.O_RDWR|os.O_CREATE, 0600)
+	if err != nil {
+		unclaim()
+		return nil, nil, err
+	}
+	return f, func(keep bool) {
+		if keep {
+			if fi, err := f.Stat(); err == nil && fi.Size() == 0 {
+				keep = false
+			}
+		}
+		f.Close()
+		if !keep {
+			if err := os.Remove(f.Name()); err != nil {
+				logrus.Errorf("Failed to remove partial download %s: %v", f.Name(), err)
+			}
+		}
+		unclaim()
+	}, nil
+}
+
+// cleanupPartialDownloads removes the partial downloads older than maxAge.
+func (graph *Graph) cleanupPartialDownloads(maxAge time.Duration) {
+	dir := graph.partialDownloadsDir()
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return
+	}
+	for _, fi := range files {
+		if time.Since(fi.ModTime()) > maxAge {
+			if err := os.Remove(filepath.Join(dir, fi.Name())); err != nil {
+				logrus.Debugf("Failed to remove partial download %s: %v", fi.Name(), err)
+			}
+		}
+	}
+}
+
+// errBlobStatus is returned when the registry answers a blob download with
+// an unexpected status code.
+type errBlobStatus struct {
+	digest     digest.Digest
+	statusCode int
+}
+
+func (e errBlobStatus) Error() string {
+	return fmt.Sprintf("error downloading blob %s: unexpected status code %d", e.digest, e.statusCode)
+}
+
+// isTransientDownloadError returns whether a failed download is worth
+// retrying: network errors, truncated bodies and server side errors.
+func isTransientDownloadError(err error) bool {
+	switch v := err.(type) {
+	case errBlobStatus:
+		return v.statusCode >= 500 || v.statusCode == statusTooManyRequests
+	case *url.Error:
+		return true
+	case net.Error:
+		return true
+	}
+	return err == io.ErrUnexpectedEOF
+}
+
+// downloadBlob downloads the blob of di to its temporary file. It resumes
+// the download from the data already in the file with range requests, and
+// retries on transient errors with an exponential backoff. The downloaded
+// blob is verified against its digest; if verification fails, the partial
+// download is discarded.
+func (p *v2Puller) downloadBlob(di *downloadInfo) error {
+	offset, err := di.tmpFile.Seek(0, os.SEEK_END)
+	if err != nil {
+		return err
+	}
+	if offset > 0 {
+		logrus.Debugf("Resuming download of %s at %d bytes", di.digest, offset)
+	}
+
+	delay := downloadRetryDelay
+	for attempt := 1; ; attempt++ {
+		offset, err = p.downloadRange(di, offset)
+		if err == nil {
+			break
+		}
+		if attempt == maxDownloadAttempts || !isTransientDownloadError(err) {
+			return err
+		}
+		logrus.Debugf("Error downloading %s at %d bytes, retrying in %s: %v", di.digest, offset, delay, err)
+		di.broadcaster.Write(p.sf.FormatProgress(stringid.TruncateID(di.img.ID), fmt.Sprintf("Retrying in %s", units.HumanDuration(delay)), nil))
+		time.Sleep(delay)
+		delay *= 2
+	}
+
+	di.broadcaster.Write(p.sf.FormatProgress(stringid.TruncateID(di.img.ID), "Verifying Checksum", nil))
+
+	verifier, err := digest.NewDigestVerifier(di.digest)
+	if err != nil {
+		return err
+	}
+	if _, err := di.tmpFile.Seek(0, 0); err != nil {
+		return err
+	}
+	if _, err := io.Copy(verifier, di.tmpFile); err != nil {
+		return err
+	}
+	if !verifier.Verified() {
+		// Don't resume from corrupted data.
+		di.tmpFile.Truncate(0)
+		err := fmt.Errorf("filesystem layer verification failed for digest %s", di.digest)
+		logrus.Error(err)
+		return err
+	}
+	return nil
+}
+
+// downloadRange downloads the blob of di from offset to its end, appending
+// it to the temporary file. It returns the offset reached.
+func (p *v2Puller) downloadRange(di *downloadInfo, offset int64) (int64, error) {
+	ub, err := v2.NewURLBuilderFromString(p.endpoint.URL)
+	if err != nil {
+		return offset, err
+	}
+	u, err := ub.BuildBlobURL(p.repoName, di.digest)
+	if err != nil {
+		return offset, err
+	}
+	req, err := http.NewRequest("GET", u, nil)
+	if err != nil {
+		return offset, err
+	}
+	if offset > 0 {
+		req.Header.Set("Range", fmt.Sprintf("bytes=%d-", offset))
+	}
+
+	resp, err := (&http.Client{Transport: p.transport}).Do(req)
+	if err != nil {
+		return offset, err
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case offset > 0 && resp.StatusCode == http.StatusPartialContent:
+	case offset > 0 && resp.StatusCode == http.StatusRequestedRangeNotSatisfiable:
+		// The partial download already covers the whole blob, the
+		// verification tells whether it is the right one.
+		di.size = offset
+		return offset, nil
+	case resp.StatusCode == http.StatusOK:
+		if offset > 0 {
+			logrus.Debugf("Registry ignored the range request for %s, downloading it from the start", di.digest)
+			offset = 0
+			if err := di.tmpFile.Truncate(0); err != nil {
+				return offset, err
+			}
+		}
+	default:
+		return offset, errBlobStatus{digest: di.digest, statusCode: resp.StatusCode}
+	}
+	if resp.ContentLength >= 0 {
+		di.size = offset + resp.ContentLength
+	}
+
+	if _, err := di.tmpFile.Seek(offset, 0); err != nil {
+		return offset, err
+	}
+	reader := progressreader.New(progressreader.Config{
+		In:        resp.Body,
+		Out:       di.broadcaster,
+		Formatter: p.sf,
+		Size:      di.size,
+		Current:   offset,
+		NewLines:  false,
+		ID:        stringid.TruncateID(di.img.ID),
+		Action:    "Downloading",
+	})
+	n, err := io.Copy(di.tmpFile, reader)
+	offset += n
+	if err == nil && resp.ContentLength >= 0 && offset < di.size {
+		err = io.ErrUnexpectedEOF
+	}
+	return offset, err
+}
@@ -0,0 +1,181 @@
+package graph
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/docker/distribution/digest"
+	"github.com/docker/docker/image"
+	"github.com/docker/docker/pkg/progressreader"
+	"github.com/docker/docker/pkg/streamformatter"
+	"github.com/docker/docker/registry"
+)
+
+// newBlobServer serves blob, failing the first failures requests after
+// sending half of the requested range. It records the Range headers.
+func newBlobServer(blob []byte, failures int, ranges *[]string) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		*ranges = append(*ranges, r.Header.Get("Range"))
+		var start int
+		if rng := r.Header.Get("Range"); rng != "" {
+			start, _ = strconv.Atoi(strings.TrimSuffix(strings.TrimPrefix(rng, "bytes="), "-"))
+		}
+		data := blob[start:]
+		w.Header().Set("Content-Length", strconv.Itoa(len(data)))
+		if start > 0 {
+			w.Header().Set("Content-Range", fmt.Sprintf("bytes %d-%d/%d", start, len(blob)-1, len(blob)))
+			w.WriteHeader(http.StatusPartialContent)
+		}
+		if failures > 0 {
+			failures--
+			w.Write(data[:len(data)/2])
+			// Close the connection before the end of the body.
+			conn, _, _ := w.(http.Hijacker).Hijack()
+			conn.Close()
+			return
+		}
+		w.Write(data)
+	}))
+}
+
+func newTestDownload(t *testing.T, graph *Graph, blob []byte, srv *httptest.Server) (*v2Puller, *downloadInfo, func(bool)) {
+	dgst, err := digest.FromBytes(blob)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := &v2Puller{
+		endpoint:  registry.APIEndpoint{URL: srv.URL},
+		repoName:  "foo/bar",
+		transport: http.DefaultTransport,
+		sf:        streamformatter.NewJSONStreamFormatter(),
+	}
+	di := &downloadInfo{
+		img:         &image.Image{ID: "0123456789ab"},
+		digest:      dgst,
+		broadcaster: progressreader.NewBroadcaster(),
+	}
+	var release func(bool)
+	di.tmpFile, release, err = graph.openPartialDownload(di.digest)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return p, di, release
+}
+
+func TestDownloadBlobRetry(t *testing.T) {
+	graph, _ := tempGraph(t)
+	defer nukeGraph(graph)
+	downloadRetryDelay = 0
+
+	blob := bytes.Repeat([]byte("0123456789"), 10000)
+	var ranges []string
+	srv := newBlobServer(blob, 2, &ranges)
+	defer srv.Close()
+
+	p, di, release := newTestDownload(t, graph, blob, srv)
+	defer release(false)
+
+	if err := p.downloadBlob(di); err != nil {
+		t.Fatal(err)
+	}
+	if len(ranges) != 3 || ranges[0] != "" || ranges[1] != "bytes=50000-" || ranges[2] != "bytes=75000-" {
+		t.Fatalf("Unexpected range requests: %q", ranges)
+	}
+	di.tmpFile.Seek(0, 0)
+	if data, _ := ioutil.ReadAll(di.tmpFile); !bytes.Equal(data, blob) {
+		t.Fatal("Downloaded blob doesn't match")
+	}
+}
+
+func TestDownloadBlobResume(t *testing.T) {
+	graph, _ := tempGraph(t)
+	defer nukeGraph(graph)
+	downloadRetryDelay = 0
+
+	blob := bytes.Repeat([]byte("0123456789"), 10000)
+	var ranges []string
+	srv := newBlobServer(blob, maxDownloadAttempts, &ranges)
+
+	// A download failing on every attempt keeps its partial data.
+	p, di, release := newTestDownload(t, graph, blob, srv)
+	if err := p.downloadBlob(di); err == nil {
+		t.Fatal("Expected the download to fail")
+	}
+	release(true)
+	srv.Close()
+
+	ranges = nil
+	srv = newBlobServer(blob, 0, &ranges)
+	defer srv.Close()
+
+	// Another pull resumes it.
+	p, di, release = newTestDownload(t, graph, blob, srv)
+	defer release(false)
+	if err := p.downloadBlob(di); err != nil {
+		t.Fatal(err)
+	}
+	if len(ranges) != 1 || !strings.HasPrefix(ranges[0], "bytes=") {
+		t.Fatalf("Expected a single range request, got %q", ranges)
+	}
+	di.tmpFile.Seek(0, 0)
+	if data, _ := ioutil.ReadAll(di.tmpFile); !bytes.Equal(data, blob) {
+		t.Fatal("Downloaded blob doesn't match")
+	}
+}
+
+func TestDownloadBlobVerificationFailure(t *testing.T) {
+	graph, _ := tempGraph(t)
+	defer nukeGraph(graph)
+
+	blob := []byte("expected content")
+	var ranges []string
+	srv := newBlobServer([]byte("corrupted content"), 0, &ranges)
+	defer srv.Close()
+
+	p, di, release := newTestDownload(t, graph, blob, srv)
+	defer release(true)
+	if err := p.downloadBlob(di); err == nil || !strings.Contains(err.Error(), "verification failed") {
+		t.Fatalf("Expected a verification error, got %v", err)
+	}
+	if fi, err := di.tmpFile.Stat(); err != nil || fi.Size() != 0 {
+		t.Fatal("Expected the corrupted partial download to be discarded")
+	}
+}
+
+func TestOpenPartialDownloadClaimed(t *testing.T) {
+	graph, _ := tempGraph(t)
+	defer nukeGraph(graph)
+
+	dgst, err := digest.FromBytes([]byte("blob"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	f1, release1, err := graph.openPartialDownload(dgst)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f2, release2, err := graph.openPartialDownload(dgst)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if f1.Name() == f2.Name() {
+		t.Fatal("Expected a different file for a blob already being downloaded")
+	}
+	release2(true)
+	release1(true)
+
+	f3, release3, err := graph.openPartialDownload(dgst)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer release3(false)
+	if f3.Name() != f1.Name() {
+		t.Fatalf("Expected the partial download %s, got %s", f1.Name(), f3.Name())
+	}
+}
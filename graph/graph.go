@@ -81,6 +81,7 @@ type Graph struct {
 	driver           graphdriver.Driver
 	imageMutex       imageMutex // protect images in driver.
 	retained         *retainedLayers
+	downloads        *partialDownloads
 	tarSplitDisabled bool
 }
 
@@ -89,9 +90,23 @@ const (
 	jsonFileName      = "json"
 	layersizeFileName = "layersize"
 	digestFileName    = "checksum"
+	diffIDFileName    = "diffid"
+	sourcesFileName   = "v2sources"
 	tarDataFileName   = "tar-data.json.gz"
 )
 
+// maxBlobSources is the number of repositories recorded for the blob of an
+// image layer.
+const maxBlobSources = 5
+
+// BlobSource is a repository of a registry known to hold the blob of an
+// image layer, which pushes to other repositories of the registry can mount.
+type BlobSource struct {
+	Digest     digest.Digest
+	Registry   string
+	Repository string
+}
+
 var (
 	// ErrDigestNotSet is used when request the digest for a layer
 	// but the layer has no digest value or content to compute the
@@ -112,10 +127,11 @@ func NewGraph(root string, driver graphdriver.Driver) (*Graph, error) {
 	}
 
 	graph := &Graph{
-		root:     abspath,
-		idIndex:  truncindex.NewTruncIndex([]string{}),
-		driver:   driver,
-		retained: &retainedLayers{layerHolders: make(map[string]map[string]struct{})},
+		root:      abspath,
+		idIndex:   truncindex.NewTruncIndex([]string{}),
+		driver:    driver,
+		retained:  &retainedLayers{layerHolders: make(map[string]map[string]struct{})},
+		downloads: &partialDownloads{digests: make(map[digest.Digest]struct{})},
 	}
 
 	// Windows does not currently support tarsplit functionality.
@@ -126,6 +142,7 @@ func NewGraph(root string, driver graphdriver.Driver) (*Graph, error) {
 	if err := graph.restore(); err != nil {
 		return nil, err
 	}
+	graph.cleanupPartialDownloads(partialDownloadMaxAge)
 	return graph, nil
 }
 
@@ -197,8 +214,15 @@ func (graph *Graph) Get(name string) (*image.Image, error) {
 	return img, nil
 }
 
-// Create creates a new image and registers it in the graph.
+// Create creates a new image for the platform of the daemon and registers it
+// in the graph.
 func (graph *Graph) Create(layerData io.Reader, containerID, containerImage, comment, author string, containerConfig, config *runconfig.Config) (*image.Image, error) {
+	return graph.CreateForPlatform(runtime.GOOS, runtime.GOARCH, layerData, containerID, containerImage, comment, author, containerConfig, config)
+}
+
+// CreateForPlatform creates a new image for the given operating system and
+// architecture and registers it in the graph.
+func (graph *Graph) CreateForPlatform(imageOS, arch string, layerData io.Reader, containerID, containerImage, comment, author string, containerConfig, config *runconfig.Config) (*image.Image, error) {
 	img := &image.Image{
 		ID:            stringid.GenerateRandomID(),
 		Comment:       comment,
@@ -206,8 +230,8 @@ func (graph *Graph) Create(layerData io.Reader, containerID, containerImage, com
 		DockerVersion: dockerversion.VERSION,
 		Author:        author,
 		Config:        config,
-		Architecture:  runtime.GOARCH,
-		OS:            runtime.GOOS,
+		Architecture:  arch,
+		OS:            imageOS,
 	}
 
 	if containerID != "" {
@@ -225,7 +249,18 @@ func (graph *Graph) Create(layerData io.Reader, containerID, containerImage, com
 // Register imports a pre-existing image into the graph.
 // Returns nil if the image is already registered.
 func (graph *Graph) Register(img *image.Image, layerData io.Reader) (err error) {
+	return graph.register(img, layerData, "")
+}
 
+// RegisterVerified imports a pre-existing image into the graph, provided that
+// the uncompressed content of its layer has the digest diffID. The layer is
+// discarded if it doesn't, and the diff ID of the image is recorded if it does.
+// Returns nil if the image is already registered.
+func (graph *Graph) RegisterVerified(img *image.Image, layerData io.Reader, diffID digest.Digest) error {
+	return graph.register(img, layerData, diffID)
+}
+
+func (graph *Graph) register(img *image.Image, layerData io.Reader, diffID digest.Digest) (err error) {
 	if err := image.ValidateID(img.ID); err != nil {
 		return err
 	}
@@ -274,10 +309,36 @@ func (graph *Graph) Register(img *image.Image, layerData io.Reader) (err error)
 		return err
 	}
 
+	var verifier digest.Verifier
+	if diffID != "" {
+		if verifier, err = digest.NewDigestVerifier(diffID); err != nil {
+			return err
+		}
+		inflatedLayerData, err := archive.DecompressStream(layerData)
+		if err != nil {
+			return err
+		}
+		defer inflatedLayerData.Close()
+		layerData = io.TeeReader(inflatedLayerData, verifier)
+	}
+
 	// Apply the diff/layer
 	if err := graph.storeImage(img, layerData, tmp); err != nil {
 		return err
 	}
+	if verifier != nil {
+		// The end of the archive may be padded past what the extraction
+		// reads.
+		if _, err := io.Copy(ioutil.Discard, layerData); err != nil {
+			return err
+		}
+		if !verifier.Verified() {
+			return fmt.Errorf("the content of the layer of image %s doesn't match its digest %s", img.ID, diffID)
+		}
+		if err := ioutil.WriteFile(filepath.Join(tmp, diffIDFileName), []byte(diffID.String()), 0600); err != nil {
+			return err
+		}
+	}
 	// Commit
 	if err := os.Rename(tmp, graph.imageRoot(img.ID)); err != nil {
 		return err
@@ -528,6 +589,116 @@ func (graph *Graph) GetDigest(id string) (digest.Digest, error) {
 	return digest.ParseDigest(string(cs))
 }
 
+// SetDiffID sets the digest of the uncompressed content of the image layer
+// to the provided value.
+func (graph *Graph) SetDiffID(id string, dgst digest.Digest) error {
+	graph.imageMutex.Lock(id)
+	defer graph.imageMutex.Unlock(id)
+
+	root := graph.imageRoot(id)
+	if err := ioutil.WriteFile(filepath.Join(root, diffIDFileName), []byte(dgst.String()), 0600); err != nil {
+		return fmt.Errorf("Error storing diff ID in %s/%s: %s", root, diffIDFileName, err)
+	}
+	return nil
+}
+
+// VerifyDiffID returns an error if the uncompressed content of the layer of
+// the image doesn't have the digest diffID. The content is hashed if its
+// digest wasn't recorded yet.
+func (graph *Graph) VerifyDiffID(id string, diffID digest.Digest) error {
+	dgst, err := graph.GetDiffID(id)
+	if err == ErrDigestNotSet {
+		img, err := graph.Get(id)
+		if err != nil {
+			return err
+		}
+		arch, err := graph.TarLayer(img)
+		if err != nil {
+			return err
+		}
+		defer arch.Close()
+		if dgst, err = digest.FromReader(arch); err != nil {
+			return err
+		}
+		if dgst == diffID {
+			return graph.SetDiffID(id, dgst)
+		}
+	} else if err != nil {
+		return err
+	}
+	if dgst != diffID {
+		return fmt.Errorf("the content of the layer of image %s doesn't match its digest %s, remove the image to pull it again", id, diffID)
+	}
+	return nil
+}
+
+// GetDiffID gets the digest of the uncompressed content of the provided
+// image layer id.
+func (graph *Graph) GetDiffID(id string) (digest.Digest, error) {
+	graph.imageMutex.Lock(id)
+	defer graph.imageMutex.Unlock(id)
+
+	root := graph.imageRoot(id)
+	cs, err := ioutil.ReadFile(filepath.Join(root, diffIDFileName))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return "", ErrDigestNotSet
+		}
+		return "", err
+	}
+	return digest.ParseDigest(string(cs))
+}
+
+// AddBlobSource records that the blob of the image layer exists in the
+// given repository. Only the most recently used sources are kept.
+func (graph *Graph) AddBlobSource(id string, source BlobSource) error {
+	graph.imageMutex.Lock(id)
+	defer graph.imageMutex.Unlock(id)
+
+	sources, err := graph.blobSources(id)
+	if err != nil {
+		return err
+	}
+	updated := []BlobSource{source}
+	for _, s := range sources {
+		if s != source && len(updated) < maxBlobSources {
+			updated = append(updated, s)
+		}
+	}
+	data, err := json.Marshal(updated)
+	if err != nil {
+		return err
+	}
+	root := graph.imageRoot(id)
+	if err := ioutil.WriteFile(filepath.Join(root, sourcesFileName), data, 0600); err != nil {
+		return fmt.Errorf("Error storing blob sources in %s/%s: %s", root, sourcesFileName, err)
+	}
+	return nil
+}
+
+// GetBlobSources gets the repositories known to hold the blob of the
+// provided image layer id, the most recently used first.
+func (graph *Graph) GetBlobSources(id string) ([]BlobSource, error) {
+	graph.imageMutex.Lock(id)
+	defer graph.imageMutex.Unlock(id)
+	return graph.blobSources(id)
+}
+
+func (graph *Graph) blobSources(id string) ([]BlobSource, error) {
+	data, err := ioutil.ReadFile(filepath.Join(graph.imageRoot(id), sourcesFileName))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	var sources []BlobSource
+	if err := json.Unmarshal(data, &sources); err != nil {
+		return nil, err
+	}
+	return sources, nil
+}
+
 // RawJSON returns the JSON representation for an image as a byte array.
 func (graph *Graph) RawJSON(id string) ([]byte, error) {
 	root := graph.imageRoot(id)
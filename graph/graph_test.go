@@ -1,14 +1,18 @@
 package graph
 
 import (
+	"bytes"
 	"errors"
+	"fmt"
 	"io"
 	"io/ioutil"
 	"os"
 	"path"
+	"strings"
 	"testing"
 	"time"
 
+	"github.com/docker/distribution/digest"
 	"github.com/docker/docker/autogen/dockerversion"
 	"github.com/docker/docker/daemon/graphdriver"
 	"github.com/docker/docker/image"
@@ -150,6 +154,79 @@ func TestRegister(t *testing.T) {
 	}
 }
 
+func TestRegisterVerified(t *testing.T) {
+	graph, _ := tempGraph(t)
+	defer nukeGraph(graph)
+	layer, err := fakeTar()
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := ioutil.ReadAll(layer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	diffID, err := digest.FromBytes(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	img := &image.Image{ID: stringid.GenerateNonCryptoID(), Created: time.Now()}
+	if err := graph.RegisterVerified(img, bytes.NewReader(data), digest.Digest("sha256:"+strings.Repeat("0", 64))); err == nil {
+		t.Fatal("Expected a layer not matching its diff ID to be rejected")
+	}
+	if graph.Exists(img.ID) {
+		t.Fatal("Expected the rejected image not to be registered")
+	}
+
+	if err := graph.RegisterVerified(img, bytes.NewReader(data), diffID); err != nil {
+		t.Fatal(err)
+	}
+	if dgst, err := graph.GetDiffID(img.ID); err != nil || dgst != diffID {
+		t.Fatalf("Expected the diff ID %s to be recorded, got %s: %v", diffID, dgst, err)
+	}
+	if err := graph.VerifyDiffID(img.ID, diffID); err != nil {
+		t.Fatal(err)
+	}
+	if err := graph.VerifyDiffID(img.ID, digest.Digest("sha256:"+strings.Repeat("0", 64))); err == nil {
+		t.Fatal("Expected the layer not to match another diff ID")
+	}
+}
+
+func TestVerifyDiffIDHashesLayer(t *testing.T) {
+	graph, _ := tempGraph(t)
+	defer nukeGraph(graph)
+	layer, err := fakeTar()
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := ioutil.ReadAll(layer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	diffID, err := digest.FromBytes(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// an image registered without recording its diff ID
+	img := &image.Image{ID: stringid.GenerateNonCryptoID(), Created: time.Now()}
+	if err := graph.Register(img, bytes.NewReader(data)); err != nil {
+		t.Fatal(err)
+	}
+	if err := graph.VerifyDiffID(img.ID, digest.Digest("sha256:"+strings.Repeat("0", 64))); err == nil {
+		t.Fatal("Expected the layer not to match another diff ID")
+	}
+	if _, err := graph.GetDiffID(img.ID); err != ErrDigestNotSet {
+		t.Fatalf("Expected no diff ID to be recorded for a mismatch, got %v", err)
+	}
+	if err := graph.VerifyDiffID(img.ID, diffID); err != nil {
+		t.Fatal(err)
+	}
+	if dgst, err := graph.GetDiffID(img.ID); err != nil || dgst != diffID {
+		t.Fatalf("Expected the diff ID %s to be recorded, got %s: %v", diffID, dgst, err)
+	}
+}
+
 // Test that an image can be deleted by its shorthand prefix
 func TestDeletePrefix(t *testing.T) {
 	graph, _ := tempGraph(t)
@@ -296,3 +373,36 @@ func nukeGraph(graph *Graph) {
 	graph.driver.Cleanup()
 	os.RemoveAll(graph.root)
 }
+
+func TestBlobSources(t *testing.T) {
+	graph, _ := tempGraph(t)
+	defer nukeGraph(graph)
+	img := createTestImage(graph, t)
+
+	if sources, err := graph.GetBlobSources(img.ID); err != nil || len(sources) != 0 {
+		t.Fatalf("Expected no sources, got %v (%v)", sources, err)
+	}
+	for i := 0; i < maxBlobSources+2; i++ {
+		source := BlobSource{Digest: "sha256:abc", Registry: "https://registry.example.com", Repository: fmt.Sprintf("repo%d", i)}
+		if err := graph.AddBlobSource(img.ID, source); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// Adding a known source moves it first.
+	if err := graph.AddBlobSource(img.ID, BlobSource{Digest: "sha256:abc", Registry: "https://registry.example.com", Repository: "repo3"}); err != nil {
+		t.Fatal(err)
+	}
+
+	sources, err := graph.GetBlobSources(img.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(sources) != maxBlobSources {
+		t.Fatalf("Expected %d sources, got %d", maxBlobSources, len(sources))
+	}
+	for i, repo := range []string{"repo3", "repo6", "repo5", "repo4", "repo2"} {
+		if sources[i].Repository != repo {
+			t.Fatalf("Expected %s to be the source %d, got %v", repo, i, sources)
+		}
+	}
+}
@@ -15,7 +15,8 @@ import (
 	"github.com/docker/docker/pkg/chrootarchive"
 )
 
-// Load uploads a set of images into the repository. This is the complementary of ImageExport.
+// Load uploads a set of images into the repository. This is the complementary of ImageExport
+// and ImageExportOCI.
 // The input stream is an uncompressed tar ball containing images and metadata.
 func (s *TagStore) Load(inTar io.ReadCloser, outStream io.Writer) error {
 	tmpImageDir, err := ioutil.TempDir("", "docker-import-")
@@ -42,6 +43,10 @@ func (s *TagStore) Load(inTar io.ReadCloser, outStream io.Writer) error {
 		return err
 	}
 
+	if isOCILayout(repoDir) {
+		return s.loadOCILayout(repoDir, outStream)
+	}
+
 	dirs, err := ioutil.ReadDir(repoDir)
 	if err != nil {
 		return err
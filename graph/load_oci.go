@@ -0,0 +1,223 @@
+// +build linux windows
+
+package graph
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"github.com/Sirupsen/logrus"
+	"github.com/docker/distribution/digest"
+	"github.com/docker/docker/graph/tags"
+	"github.com/docker/docker/image"
+	"github.com/docker/docker/pkg/parsers"
+)
+
+// isOCILayout returns whether the directory holds an OCI image layout.
+func isOCILayout(dir string) bool {
+	_, err := os.Stat(filepath.Join(dir, ociLayoutFileName))
+	return err == nil
+}
+
+// loadOCILayout loads the images of the OCI image layout in root, tagging
+// those whose manifest is annotated with a reference.
+func (s *TagStore) loadOCILayout(root string, outStream io.Writer) error {
+	var layout ociLayout
+	if err := readOCIJSON(filepath.Join(root, ociLayoutFileName), &layout); err != nil {
+		return err
+	}
+	if layout.ImageLayoutVersion != ociLayoutVersion {
+		return fmt.Errorf("unsupported OCI image layout version %q", layout.ImageLayoutVersion)
+	}
+
+	var index ociIndex
+	if err := readOCIJSON(filepath.Join(root, ociIndexFileName), &index); err != nil {
+		return err
+	}
+
+	for _, desc := range index.Manifests {
+		ref := desc.Annotations[ociRefNameAnnotation]
+		m, err := resolveOCIManifest(root, desc)
+		if err != nil {
+			return err
+		}
+		id, err := s.loadOCIImage(root, m)
+		if err != nil {
+			return err
+		}
+
+		// References without a repository only name the image in the
+		// layout.
+		if !strings.ContainsAny(ref, ":/") {
+			fmt.Fprintf(outStream, "Loaded image ID: %s\n", id)
+			continue
+		}
+		repoName, tag := parsers.ParseRepositoryTag(ref)
+		if tag == "" {
+			tag = tags.DefaultTag
+		}
+		if err := s.setLoad(repoName, tag, id, true, outStream); err != nil {
+			return err
+		}
+		fmt.Fprintf(outStream, "Loaded image: %s:%s\n", repoName, tag)
+	}
+	return nil
+}
+
+// resolveOCIManifest reads the image manifest desc references, picking the
+// manifest of the platform of the daemon from nested indexes.
+func resolveOCIManifest(root string, desc ociDescriptor) (*schema2Manifest, error) {
+	for {
+		switch desc.MediaType {
+		case ociManifestMediaType, schema2MediaType:
+			var m schema2Manifest
+			if err := readOCIBlob(root, desc.Digest, desc.Size, &m); err != nil {
+				return nil, err
+			}
+			return &m, nil
+		case ociIndexMediaType, manifestListMediaType:
+			var index ociIndex
+			if err := readOCIBlob(root, desc.Digest, desc.Size, &index); err != nil {
+				return nil, err
+			}
+			var match *ociDescriptor
+			for i, d := range index.Manifests {
+				if d.Platform != nil && d.Platform.OS == runtime.GOOS && d.Platform.Architecture == runtime.GOARCH {
+					match = &index.Manifests[i]
+					break
+				}
+			}
+			if match == nil {
+				return nil, fmt.Errorf("no manifest for %s/%s in index %s", runtime.GOOS, runtime.GOARCH, desc.Digest)
+			}
+			desc = *match
+		default:
+			return nil, fmt.Errorf("unsupported manifest media type %q", desc.MediaType)
+		}
+	}
+}
+
+// loadOCIImage registers the images of the layers of the manifest m, and
+// returns the ID of the top one.
+func (s *TagStore) loadOCIImage(root string, m *schema2Manifest) (string, error) {
+	if m.SchemaVersion != 2 {
+		return "", fmt.Errorf("unsupported schema version %d", m.SchemaVersion)
+	}
+	if len(m.Layers) == 0 {
+		return "", fmt.Errorf("no layers in manifest")
+	}
+
+	var config imageConfig
+	if err := readOCIBlob(root, m.Config.Digest, m.Config.Size, &config); err != nil {
+		return "", err
+	}
+
+	var layers []digest.Digest
+	for _, l := range m.Layers {
+		switch l.MediaType {
+		case ociLayerMediaType, ociLayerGzipMediaType, layerMediaType, uncompressedLayerMediaType:
+		default:
+			return "", fmt.Errorf("unsupported layer media type %q", l.MediaType)
+		}
+		layers = append(layers, l.Digest)
+	}
+	imgs, blobs, diffIDs, err := v1ImagesFromConfig(&config, layers)
+	if err != nil {
+		return "", err
+	}
+
+	for i, img := range imgs {
+		if err := s.loadOCILayer(root, img, blobs[i], diffIDs[i]); err != nil {
+			return "", err
+		}
+	}
+	return imgs[len(imgs)-1].ID, nil
+}
+
+// loadOCILayer registers img with the layer of the blob dgst, unless it is
+// already in the graph. The IDs of the images are derived from the diff IDs
+// of their layers, so the content of the layer is verified against diffID
+// whether it is loaded or already in the graph.
+func (s *TagStore) loadOCILayer(root string, img *image.Image, dgst, diffID digest.Digest) error {
+	poolKey := "layer:" + img.ID
+	broadcaster, found := s.poolAdd("pull", poolKey)
+	if found {
+		logrus.Debugf("Image (id: %s) load is already running, waiting", img.ID)
+		return broadcaster.Wait()
+	}
+	defer s.poolRemove("pull", poolKey)
+
+	if s.graph.Exists(img.ID) {
+		if diffID != "" {
+			return s.graph.VerifyDiffID(img.ID, diffID)
+		}
+		return nil
+	}
+	logrus.Debugf("Loading %s", img.ID)
+
+	if dgst == "" {
+		return s.graph.Register(img, nil)
+	}
+
+	f, err := os.Open(ociBlobPath(root, dgst))
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	// Verify the whole blob before registering it, the layer may end
+	// before the blob does.
+	verifier, err := digest.NewDigestVerifier(dgst)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(verifier, f); err != nil {
+		return err
+	}
+	if !verifier.Verified() {
+		return fmt.Errorf("layer verification failed for digest %s", dgst)
+	}
+	if _, err := f.Seek(0, 0); err != nil {
+		return err
+	}
+
+	return s.graph.RegisterVerified(img, f, diffID)
+}
+
+// readOCIBlob decodes the JSON blob dgst of the layout in root into v,
+// verifying its digest and size.
+func readOCIBlob(root string, dgst digest.Digest, size int64, v interface{}) error {
+	if err := dgst.Validate(); err != nil {
+		return err
+	}
+	data, err := ioutil.ReadFile(ociBlobPath(root, dgst))
+	if err != nil {
+		return err
+	}
+	if int64(len(data)) != size {
+		return fmt.Errorf("blob %s has size %d, expected %d", dgst, len(data), size)
+	}
+	verifier, err := digest.NewDigestVerifier(dgst)
+	if err != nil {
+		return err
+	}
+	verifier.Write(data)
+	if !verifier.Verified() {
+		return fmt.Errorf("blob verification failed for digest %s", dgst)
+	}
+	return json.Unmarshal(data, v)
+}
+
+func readOCIJSON(path string, v interface{}) error {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
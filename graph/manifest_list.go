@@ -0,0 +1,227 @@
+package graph
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"mime"
+	"net/http"
+	"runtime"
+
+	"github.com/Sirupsen/logrus"
+	"github.com/docker/distribution/digest"
+	"github.com/docker/distribution/manifest"
+	"github.com/docker/distribution/registry/api/v2"
+	"github.com/docker/docker/pkg/parsers"
+	"golang.org/x/net/context"
+)
+
+// Media types of the manifests served by v2 registries.
+const (
+	manifestListMediaType  = "application/vnd.docker.distribution.manifest.list.v2+json"
+	schema1MediaType       = "application/vnd.docker.distribution.manifest.v1+json"
+	signedSchema1MediaType = "application/vnd.docker.distribution.manifest.v1+prettyjws"
+)
+
+// manifestList references the image manifests of the same image built for
+// different platforms.
+type manifestList struct {
+	SchemaVersion int                  `json:"schemaVersion"`
+	MediaType     string               `json:"mediaType"`
+	Manifests     []manifestDescriptor `json:"manifests"`
+}
+
+// manifestDescriptor references the image manifest of a platform in a
+// manifest list.
+type manifestDescriptor struct {
+	MediaType string           `json:"mediaType"`
+	Size      int64            `json:"size"`
+	Digest    digest.Digest    `json:"digest"`
+	Platform  manifestPlatform `json:"platform"`
+}
+
+type manifestPlatform struct {
+	Architecture string `json:"architecture"`
+	OS           string `json:"os"`
+	Variant      string `json:"variant,omitempty"`
+}
+
+// forPlatform returns the manifest of the list for the given platform, or
+// nil if there is none. If no variant is given, a manifest without variant
+// is preferred.
+func (l *manifestList) forPlatform(os, arch, variant string) *manifestDescriptor {
+	var match *manifestDescriptor
+	for i, m := range l.Manifests {
+		if m.Platform.OS != os || m.Platform.Architecture != arch {
+			continue
+		}
+		if m.Platform.Variant == variant {
+			return &l.Manifests[i]
+		}
+		if variant == "" && match == nil {
+			match = &l.Manifests[i]
+		}
+	}
+	return match
+}
+
+// fetchManifest fetches the manifest referenced by ref, accepting manifest
+// lists and schema2 manifests besides schema1 ones. It returns the media type
+// and the content of the manifest. The content is nil if the manifest could
+// not be fetched, in which case the regular manifest service reports why.
+// When pulling by digest, the digest of manifest lists and schema2 manifests
+// is verified here, that of schema1 manifests by validateManifest since it
+// only covers their payload.
+func (p *v2Puller) fetchManifest(ref string) (string, []byte, error) {
+	ub, err := v2.NewURLBuilderFromString(p.endpoint.URL)
+	if err != nil {
+		return "", nil, err
+	}
+	u, err := ub.BuildManifestURL(p.repoName, ref)
+	if err != nil {
+		return "", nil, err
+	}
+	req, err := http.NewRequest("GET", u, nil)
+	if err != nil {
+		return "", nil, err
+	}
+	for _, mediaType := range []string{manifestListMediaType, schema2MediaType, signedSchema1MediaType, schema1MediaType} {
+		req.Header.Add("Accept", mediaType)
+	}
+
+	resp, err := (&http.Client{Transport: p.transport}).Do(req)
+	if err != nil {
+		return "", nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", nil, nil
+	}
+	// Old registries serve schema1 manifests with a generic media type.
+	mediaType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	if mediaType != manifestListMediaType && mediaType != schema2MediaType {
+		mediaType = signedSchema1MediaType
+	}
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return "", nil, err
+	}
+	if mediaType == signedSchema1MediaType {
+		return mediaType, body, nil
+	}
+	if manifestDigest, err := digest.ParseDigest(ref); err == nil {
+		verifier, err := digest.NewDigestVerifier(manifestDigest)
+		if err != nil {
+			return "", nil, err
+		}
+		verifier.Write(body)
+		if !verifier.Verified() {
+			return "", nil, fmt.Errorf("image verification failed for digest %s", manifestDigest)
+		}
+	}
+	return mediaType, body, nil
+}
+
+// pulledManifest is the image manifest pulled for a tag.
+type pulledManifest struct {
+	// ref is the reference of the image manifest, the digest of the
+	// manifest for the platform if the tag references a manifest list.
+	ref string
+	// digest is the digest of the manifest list or schema2 manifest
+	// referenced by the tag. It is empty for schema1 manifests, whose
+	// digest is computed from their payload.
+	digest digest.Digest
+	// Either schema1 or schema2 is set.
+	schema1 *manifest.SignedManifest
+	schema2 *schema2Manifest
+}
+
+// resolveManifest fetches the image manifest referenced by tag. A manifest
+// list is resolved to the manifest for the requested platform, or for the
+// platform of the daemon by default.
+func (p *v2Puller) resolveManifest(tag string) (*pulledManifest, error) {
+	pm := &pulledManifest{ref: tag}
+	mediaType, payload, err := p.fetchManifest(tag)
+	if err != nil {
+		return nil, err
+	}
+
+	if mediaType == manifestListMediaType {
+		var list manifestList
+		if err := json.Unmarshal(payload, &list); err != nil {
+			return nil, err
+		}
+		if pm.digest, err = digest.FromBytes(payload); err != nil {
+			return nil, err
+		}
+		m, err := p.manifestForPlatform(&list, tag)
+		if err != nil {
+			return nil, err
+		}
+		pm.ref = m.Digest.String()
+		if mediaType, payload, err = p.fetchManifest(pm.ref); err != nil {
+			return nil, err
+		}
+		if mediaType == manifestListMediaType {
+			return nil, fmt.Errorf("the manifest list of %s:%s references the manifest list %s", p.repoInfo.LocalName, tag, pm.ref)
+		}
+	}
+
+	if payload == nil {
+		// Let the manifest service report why the manifest could not
+		// be fetched.
+		manSvc, err := p.repo.Manifests(context.Background())
+		if err != nil {
+			return nil, err
+		}
+		if pm.schema1, err = manSvc.GetByTag(pm.ref); err != nil {
+			return nil, err
+		}
+		return pm, nil
+	}
+
+	switch mediaType {
+	case schema2MediaType:
+		pm.schema2 = &schema2Manifest{}
+		if err := json.Unmarshal(payload, pm.schema2); err != nil {
+			return nil, err
+		}
+		if pm.digest == "" {
+			if pm.digest, err = digest.FromBytes(payload); err != nil {
+				return nil, err
+			}
+		}
+	default:
+		pm.schema1 = &manifest.SignedManifest{}
+		if err := json.Unmarshal(payload, pm.schema1); err != nil {
+			return nil, err
+		}
+	}
+	return pm, nil
+}
+
+// manifestForPlatform returns the manifest of the list to pull for the
+// requested platform, or for the platform of the daemon by default.
+func (p *v2Puller) manifestForPlatform(list *manifestList, tag string) (*manifestDescriptor, error) {
+	platform := p.config.Platform
+	if platform == "" {
+		platform = runtime.GOOS + "/" + runtime.GOARCH
+	}
+	platformOS, arch, variant, err := parsers.ParsePlatform(platform)
+	if err != nil {
+		return nil, err
+	}
+	m := list.forPlatform(platformOS, arch, variant)
+	if m == nil {
+		return nil, fmt.Errorf("no image for platform %s in the manifest list of %s:%s", platform, p.repoInfo.LocalName, tag)
+	}
+	switch m.MediaType {
+	case schema2MediaType, signedSchema1MediaType, schema1MediaType:
+	default:
+		return nil, fmt.Errorf("the image manifest for platform %s of %s:%s has the unsupported media type %s", platform, p.repoInfo.LocalName, tag, m.MediaType)
+	}
+	logrus.Debugf("Pulling manifest %s of the manifest list of %s:%s for %s", m.Digest, p.repoInfo.LocalName, tag, platform)
+	return m, nil
+}
@@ -0,0 +1,39 @@
+package graph
+
+import "testing"
+
+func TestManifestListForPlatform(t *testing.T) {
+	list := &manifestList{
+		Manifests: []manifestDescriptor{
+			{Digest: "sha256:amd64", Platform: manifestPlatform{OS: "linux", Architecture: "amd64"}},
+			{Digest: "sha256:armv6", Platform: manifestPlatform{OS: "linux", Architecture: "arm", Variant: "v6"}},
+			{Digest: "sha256:armv7", Platform: manifestPlatform{OS: "linux", Architecture: "arm", Variant: "v7"}},
+			{Digest: "sha256:arm", Platform: manifestPlatform{OS: "linux", Architecture: "arm"}},
+			{Digest: "sha256:arm64v8", Platform: manifestPlatform{OS: "linux", Architecture: "arm64", Variant: "v8"}},
+		},
+	}
+
+	tests := []struct {
+		os, arch, variant string
+		expected          string
+	}{
+		{"linux", "amd64", "", "sha256:amd64"},
+		{"linux", "arm", "v7", "sha256:armv7"},
+		{"linux", "arm", "", "sha256:arm"},
+		{"linux", "arm64", "", "sha256:arm64v8"},
+		{"linux", "arm", "v8", ""},
+		{"windows", "amd64", "", ""},
+	}
+	for _, test := range tests {
+		m := list.forPlatform(test.os, test.arch, test.variant)
+		if test.expected == "" {
+			if m != nil {
+				t.Fatalf("Expected no manifest for %s/%s/%s, got %s", test.os, test.arch, test.variant, m.Digest)
+			}
+			continue
+		}
+		if m == nil || string(m.Digest) != test.expected {
+			t.Fatalf("Expected %s for %s/%s/%s, got %v", test.expected, test.os, test.arch, test.variant, m)
+		}
+	}
+}
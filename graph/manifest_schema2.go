@@ -0,0 +1,199 @@
+package graph
+
+import (
+	"encoding/json"
+	"fmt"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/docker/distribution/digest"
+	"github.com/docker/docker/image"
+	"github.com/docker/docker/pkg/stringutils"
+	"github.com/docker/docker/runconfig"
+)
+
+// Media types of schema2 image manifests and of the blobs they reference.
+const (
+	schema2MediaType           = "application/vnd.docker.distribution.manifest.v2+json"
+	imageConfigMediaType       = "application/vnd.docker.container.image.v1+json"
+	layerMediaType             = "application/vnd.docker.image.rootfs.diff.tar.gzip"
+	uncompressedLayerMediaType = "application/vnd.docker.image.rootfs.diff.tar"
+)
+
+// schema2Manifest references the config of an image and the blobs of its
+// layers, from the base layer to the top one.
+type schema2Manifest struct {
+	SchemaVersion int                 `json:"schemaVersion"`
+	MediaType     string              `json:"mediaType"`
+	Config        schema2Descriptor   `json:"config"`
+	Layers        []schema2Descriptor `json:"layers"`
+}
+
+type schema2Descriptor struct {
+	MediaType string        `json:"mediaType"`
+	Size      int64         `json:"size"`
+	Digest    digest.Digest `json:"digest"`
+}
+
+// imageConfig is the config blob of a schema2 image. Unlike the v1 images
+// of a schema1 manifest, it describes the whole image: its layers are
+// identified by the digests of their uncompressed content, and the history
+// records how each of them was created.
+type imageConfig struct {
+	Created         time.Time         `json:"created"`
+	Author          string            `json:"author,omitempty"`
+	Architecture    string            `json:"architecture"`
+	OS              string            `json:"os"`
+	Comment         string            `json:"comment,omitempty"`
+	Container       string            `json:"container,omitempty"`
+	ContainerConfig runconfig.Config  `json:"container_config,omitempty"`
+	Config          *runconfig.Config `json:"config,omitempty"`
+	DockerVersion   string            `json:"docker_version,omitempty"`
+	RootFS          imageRootFS       `json:"rootfs"`
+	History         []imageHistory    `json:"history,omitempty"`
+}
+
+type imageRootFS struct {
+	Type    string          `json:"type"`
+	DiffIDs []digest.Digest `json:"diff_ids"`
+}
+
+// imageHistory describes how a layer of the image was created. Empty layers,
+// such as those of the Dockerfile instructions which only change the config,
+// have no entry in the rootfs.
+type imageHistory struct {
+	Created    time.Time `json:"created"`
+	Author     string    `json:"author,omitempty"`
+	CreatedBy  string    `json:"created_by,omitempty"`
+	Comment    string    `json:"comment,omitempty"`
+	EmptyLayer bool      `json:"empty_layer,omitempty"`
+}
+
+// v1ImagesFromConfig converts a schema2 image to the chain of v1 images the
+// graph stores, from the base image to the top one, given the digests of the
+// blobs of its layers. It also returns the digest of the blob of each image
+// and that of its uncompressed content, empty for the images of empty
+// layers. The IDs of the images are derived from their content, so that
+// pulling the same image twice yields the same images: the content of their
+// layers must be verified against the returned diff IDs before trusting them.
+func v1ImagesFromConfig(config *imageConfig, layers []digest.Digest) ([]*image.Image, []digest.Digest, []digest.Digest, error) {
+	if len(config.RootFS.DiffIDs) != len(layers) {
+		return nil, nil, nil, fmt.Errorf("the image config has %d layers, the manifest %d", len(config.RootFS.DiffIDs), len(layers))
+	}
+
+	history := config.History
+	var nonEmpty int
+	for _, h := range history {
+		if !h.EmptyLayer {
+			nonEmpty++
+		}
+	}
+	if nonEmpty != len(layers) {
+		// The history doesn't match the layers, make up an entry for
+		// each layer.
+		history = make([]imageHistory, len(layers))
+		for i := range history {
+			history[i].Created = config.Created
+		}
+	}
+	if len(history) == 0 {
+		return nil, nil, nil, fmt.Errorf("no layers in the image config")
+	}
+
+	var (
+		imgs    []*image.Image
+		blobs   []digest.Digest
+		diffIDs []digest.Digest
+		parent  string
+		layer   int
+	)
+	for i, h := range history {
+		img := &image.Image{
+			Parent:       parent,
+			Comment:      h.Comment,
+			Created:      h.Created,
+			Author:       h.Author,
+			Architecture: config.Architecture,
+			OS:           config.OS,
+		}
+		if i == len(history)-1 {
+			img.Comment = config.Comment
+			img.Created = config.Created
+			img.Author = config.Author
+			img.Container = config.Container
+			img.ContainerConfig = config.ContainerConfig
+			img.Config = config.Config
+			img.DockerVersion = config.DockerVersion
+		} else if h.CreatedBy != "" {
+			img.ContainerConfig.Cmd = stringutils.NewStrSlice(h.CreatedBy)
+		}
+
+		var blob, diffID digest.Digest
+		if !h.EmptyLayer {
+			blob = layers[layer]
+			diffID = config.RootFS.DiffIDs[layer]
+			layer++
+		}
+
+		data, err := json.Marshal(img)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		id, err := digest.FromBytes([]byte(parent + " " + diffID.String() + " " + string(data)))
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		img.ID = id.Hex()
+		parent = img.ID
+
+		imgs = append(imgs, img)
+		blobs = append(blobs, blob)
+		diffIDs = append(diffIDs, diffID)
+	}
+	return imgs, blobs, diffIDs, nil
+}
+
+// configFromV1Images converts the chain of v1 images of the graph, from the
+// base image to the top one, to the config of a schema2 image, given the
+// digests of the uncompressed content of their layers.
+func configFromV1Images(imgs []*image.Image, diffIDs []digest.Digest) (*imageConfig, error) {
+	if len(imgs) == 0 || len(imgs) != len(diffIDs) {
+		return nil, fmt.Errorf("got %d images for %d layers", len(imgs), len(diffIDs))
+	}
+
+	top := imgs[len(imgs)-1]
+	config := &imageConfig{
+		Created:         top.Created,
+		Author:          top.Author,
+		Architecture:    top.Architecture,
+		OS:              top.OS,
+		Comment:         top.Comment,
+		Container:       top.Container,
+		ContainerConfig: top.ContainerConfig,
+		Config:          top.Config,
+		DockerVersion:   top.DockerVersion,
+		RootFS: imageRootFS{
+			Type:    "layers",
+			DiffIDs: diffIDs,
+		},
+	}
+	// Images built before the platform was recorded were built for the
+	// platform of the daemon.
+	if config.Architecture == "" {
+		config.Architecture = runtime.GOARCH
+	}
+	if config.OS == "" {
+		config.OS = runtime.GOOS
+	}
+
+	for _, img := range imgs {
+		config.History = append(config.History, imageHistory{
+			Created:   img.Created,
+			Author:    img.Author,
+			CreatedBy: strings.Join(img.ContainerConfig.Cmd.Slice(), " "),
+			Comment:   img.Comment,
+		})
+	}
+	return config, nil
+}
@@ -0,0 +1,201 @@
+package graph
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/docker/distribution/digest"
+	"github.com/docker/docker/image"
+	"github.com/docker/docker/pkg/stringutils"
+	"github.com/docker/docker/registry"
+	"github.com/docker/docker/runconfig"
+)
+
+func TestV1ImagesFromConfig(t *testing.T) {
+	created := time.Date(2015, 10, 1, 0, 0, 0, 0, time.UTC)
+	config := &imageConfig{
+		Created:      created,
+		Architecture: "amd64",
+		OS:           "linux",
+		Config:       &runconfig.Config{Cmd: stringutils.NewStrSlice("/bin/sh")},
+		RootFS: imageRootFS{
+			Type:    "layers",
+			DiffIDs: []digest.Digest{"sha256:diff1", "sha256:diff2"},
+		},
+		History: []imageHistory{
+			{Created: created, CreatedBy: "/bin/sh -c #(nop) ADD file:abc in /"},
+			{Created: created, CreatedBy: "/bin/sh -c #(nop) MAINTAINER foo", EmptyLayer: true},
+			{Created: created, CreatedBy: "/bin/sh -c apt-get update"},
+		},
+	}
+	layers := []digest.Digest{"sha256:blob1", "sha256:blob2"}
+
+	imgs, blobs, diffIDs, err := v1ImagesFromConfig(config, layers)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(imgs) != 3 {
+		t.Fatalf("Expected 3 images, got %d", len(imgs))
+	}
+	expectedBlobs := []digest.Digest{"sha256:blob1", "", "sha256:blob2"}
+	expectedDiffIDs := []digest.Digest{"sha256:diff1", "", "sha256:diff2"}
+	for i, img := range imgs {
+		if err := image.ValidateID(img.ID); err != nil {
+			t.Fatal(err)
+		}
+		if blobs[i] != expectedBlobs[i] {
+			t.Fatalf("Expected blob %q for image %d, got %q", expectedBlobs[i], i, blobs[i])
+		}
+		if diffIDs[i] != expectedDiffIDs[i] {
+			t.Fatalf("Expected diff ID %q for image %d, got %q", expectedDiffIDs[i], i, diffIDs[i])
+		}
+		if i > 0 && img.Parent != imgs[i-1].ID {
+			t.Fatalf("Expected image %d to have parent %s, got %s", i, imgs[i-1].ID, img.Parent)
+		}
+	}
+	if cmd := strings.Join(imgs[1].ContainerConfig.Cmd.Slice(), " "); cmd != "/bin/sh -c #(nop) MAINTAINER foo" {
+		t.Fatalf("Unexpected history of the intermediate image: %q", cmd)
+	}
+	if imgs[2].Config == nil || imgs[2].OS != "linux" || imgs[2].Architecture != "amd64" {
+		t.Fatal("Expected the top image to have the config of the image")
+	}
+
+	again, _, _, err := v1ImagesFromConfig(config, layers)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if again[2].ID != imgs[2].ID {
+		t.Fatal("Expected the same image IDs when converting the same config")
+	}
+
+	config.RootFS.DiffIDs[1] = "sha256:otherdiff"
+	other, _, _, err := v1ImagesFromConfig(config, layers)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if other[0].ID != imgs[0].ID || other[2].ID == imgs[2].ID {
+		t.Fatal("Expected the image IDs to depend on the layers below them only")
+	}
+}
+
+func TestV1ImagesFromConfigWithoutHistory(t *testing.T) {
+	config := &imageConfig{
+		RootFS: imageRootFS{
+			Type:    "layers",
+			DiffIDs: []digest.Digest{"sha256:diff1", "sha256:diff2"},
+		},
+	}
+	imgs, blobs, _, err := v1ImagesFromConfig(config, []digest.Digest{"sha256:blob1", "sha256:blob2"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(imgs) != 2 || blobs[0] != "sha256:blob1" || blobs[1] != "sha256:blob2" {
+		t.Fatalf("Expected an image for each layer, got %d", len(imgs))
+	}
+
+	if _, _, _, err := v1ImagesFromConfig(config, []digest.Digest{"sha256:blob1"}); err == nil {
+		t.Fatal("Expected an error for a manifest not matching the config")
+	}
+}
+
+func TestConfigFromV1Images(t *testing.T) {
+	created := time.Date(2015, 10, 1, 0, 0, 0, 0, time.UTC)
+	base := &image.Image{
+		ID:              "base",
+		Created:         created,
+		ContainerConfig: runconfig.Config{Cmd: stringutils.NewStrSlice("/bin/sh", "-c", "#(nop) ADD file:abc in /")},
+	}
+	top := &image.Image{
+		ID:           "top",
+		Parent:       "base",
+		Created:      created.Add(time.Hour),
+		Architecture: "arm",
+		OS:           "linux",
+		Config:       &runconfig.Config{Cmd: stringutils.NewStrSlice("/bin/sh")},
+	}
+	diffIDs := []digest.Digest{"sha256:diff1", "sha256:diff2"}
+
+	config, err := configFromV1Images([]*image.Image{base, top}, diffIDs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if config.Architecture != "arm" || config.OS != "linux" || !config.Created.Equal(top.Created) || config.Config != top.Config {
+		t.Fatalf("Expected the config of the top image, got %+v", config)
+	}
+	if len(config.RootFS.DiffIDs) != 2 || len(config.History) != 2 {
+		t.Fatal("Expected an entry in the rootfs and the history for each layer")
+	}
+	if config.History[0].CreatedBy != "/bin/sh -c #(nop) ADD file:abc in /" {
+		t.Fatalf("Unexpected history: %q", config.History[0].CreatedBy)
+	}
+
+	imgs, _, _, err := v1ImagesFromConfig(config, []digest.Digest{"sha256:blob1", "sha256:blob2"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(imgs) != 2 || imgs[0].ContainerConfig.Cmd.Slice()[0] != "/bin/sh -c #(nop) ADD file:abc in /" {
+		t.Fatal("Expected the history to round trip")
+	}
+}
+
+func TestResolveManifestList(t *testing.T) {
+	m := schema2Manifest{
+		SchemaVersion: 2,
+		MediaType:     schema2MediaType,
+		Config:        schema2Descriptor{MediaType: imageConfigMediaType, Digest: "sha256:config"},
+	}
+	manifestJSON, _ := json.Marshal(m)
+	manifestDigest, _ := digest.FromBytes(manifestJSON)
+
+	list := manifestList{
+		SchemaVersion: 2,
+		MediaType:     manifestListMediaType,
+		Manifests: []manifestDescriptor{
+			{MediaType: schema2MediaType, Digest: "sha256:other", Platform: manifestPlatform{OS: "windows", Architecture: "amd64"}},
+			{MediaType: schema2MediaType, Digest: manifestDigest, Platform: manifestPlatform{OS: "linux", Architecture: "arm", Variant: "v7"}},
+		},
+	}
+	listJSON, _ := json.Marshal(list)
+	listDigest, _ := digest.FromBytes(listJSON)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v2/foo/bar/manifests/latest":
+			w.Header().Set("Content-Type", manifestListMediaType)
+			w.Write(listJSON)
+		case "/v2/foo/bar/manifests/" + manifestDigest.String():
+			w.Header().Set("Content-Type", schema2MediaType)
+			w.Write(manifestJSON)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer srv.Close()
+
+	p := &v2Puller{
+		endpoint:  registry.APIEndpoint{URL: srv.URL},
+		config:    &ImagePullConfig{Platform: "linux/arm/v7"},
+		repoInfo:  &registry.RepositoryInfo{LocalName: "foo/bar"},
+		repoName:  "foo/bar",
+		transport: http.DefaultTransport,
+	}
+	pm, err := p.resolveManifest("latest")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pm.schema2 == nil || pm.schema2.Config.Digest != "sha256:config" {
+		t.Fatal("Expected the schema2 manifest of the platform")
+	}
+	if pm.ref != manifestDigest.String() || pm.digest != listDigest {
+		t.Fatalf("Expected the manifest %s of the list %s, got %s of %s", manifestDigest, listDigest, pm.ref, pm.digest)
+	}
+
+	p.config.Platform = "linux/ppc64le"
+	if _, err := p.resolveManifest("latest"); err == nil {
+		t.Fatal("Expected an error for a platform missing from the list")
+	}
+}
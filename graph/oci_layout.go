@@ -0,0 +1,292 @@
+package graph
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/Sirupsen/logrus"
+	"github.com/docker/distribution/digest"
+	"github.com/docker/docker/image"
+	"github.com/docker/docker/pkg/archive"
+	"github.com/docker/docker/pkg/ioutils"
+	"github.com/docker/docker/pkg/parsers"
+	"github.com/docker/docker/registry"
+	"github.com/docker/docker/utils"
+)
+
+// Media types of the OCI image layout.
+const (
+	ociIndexMediaType     = "application/vnd.oci.image.index.v1+json"
+	ociManifestMediaType  = "application/vnd.oci.image.manifest.v1+json"
+	ociConfigMediaType    = "application/vnd.oci.image.config.v1+json"
+	ociLayerMediaType     = "application/vnd.oci.image.layer.v1.tar"
+	ociLayerGzipMediaType = "application/vnd.oci.image.layer.v1.tar+gzip"
+)
+
+const (
+	// ociLayoutFileName marks the root of an OCI image layout.
+	ociLayoutFileName = "oci-layout"
+	ociLayoutVersion  = "1.0.0"
+	ociIndexFileName  = "index.json"
+	ociBlobsDirName   = "blobs"
+	// ociRefNameAnnotation is the annotation of the manifests of an OCI
+	// index holding the reference of the image.
+	ociRefNameAnnotation = "org.opencontainers.image.ref.name"
+)
+
+type ociLayout struct {
+	ImageLayoutVersion string `json:"imageLayoutVersion"`
+}
+
+// ociIndex is the entry point of an OCI image layout, referencing the
+// manifests of the images, or nested indexes of images built for different
+// platforms. The manifests themselves are the same as schema2 manifests, with
+// OCI media types.
+type ociIndex struct {
+	SchemaVersion int             `json:"schemaVersion"`
+	MediaType     string          `json:"mediaType,omitempty"`
+	Manifests     []ociDescriptor `json:"manifests"`
+}
+
+type ociDescriptor struct {
+	MediaType   string            `json:"mediaType"`
+	Digest      digest.Digest     `json:"digest"`
+	Size        int64             `json:"size"`
+	Platform    *manifestPlatform `json:"platform,omitempty"`
+	Annotations map[string]string `json:"annotations,omitempty"`
+}
+
+// ociBlobPath returns the path of the blob dgst in the OCI image layout root.
+func ociBlobPath(root string, dgst digest.Digest) string {
+	return filepath.Join(root, ociBlobsDirName, string(dgst.Algorithm()), dgst.Hex())
+}
+
+// ImageExportOCI exports the images of the given names to outStream as a tar
+// archive of an OCI image layout. Like ImageExport, a repository name exports
+// all of its tags. The images are converted to OCI manifests and configs,
+// their layers being stored uncompressed.
+func (s *TagStore) ImageExportOCI(names []string, outStream io.Writer) error {
+	tempdir, err := ioutil.TempDir("", "docker-export-")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(tempdir)
+
+	e := &ociExporter{
+		TagStore: s,
+		root:     tempdir,
+		layers:   make(map[string]schema2Descriptor),
+	}
+	index := ociIndex{SchemaVersion: 2}
+	add := func(ref, id string) error {
+		desc, err := e.exportImage(id)
+		if err != nil {
+			return err
+		}
+		if ref != "" {
+			desc.Annotations = map[string]string{ociRefNameAnnotation: ref}
+		}
+		index.Manifests = append(index.Manifests, desc)
+		return nil
+	}
+
+	for _, name := range names {
+		name = registry.NormalizeLocalName(name)
+		logrus.Debugf("Serializing %s", name)
+		if rootRepo := s.Repositories[name]; rootRepo != nil {
+			for tag, id := range rootRepo {
+				// The manifests of the layout aren't those the digests
+				// reference.
+				if utils.DigestReference(tag) {
+					continue
+				}
+				if err := add(utils.ImageReference(name, tag), id); err != nil {
+					return err
+				}
+			}
+			continue
+		}
+		img, err := s.LookupImage(name)
+		if err != nil {
+			return err
+		}
+		if img == nil {
+			return fmt.Errorf("No such image: %s", name)
+		}
+		ref := ""
+		if repoName, tag := parsers.ParseRepositoryTag(name); tag != "" && !utils.DigestReference(tag) {
+			ref = utils.ImageReference(repoName, tag)
+		}
+		if err := add(ref, img.ID); err != nil {
+			return err
+		}
+	}
+
+	if err := writeJSONFile(filepath.Join(tempdir, ociLayoutFileName), ociLayout{ImageLayoutVersion: ociLayoutVersion}); err != nil {
+		return err
+	}
+	if err := writeJSONFile(filepath.Join(tempdir, ociIndexFileName), index); err != nil {
+		return err
+	}
+
+	fs, err := archive.Tar(tempdir, archive.Uncompressed)
+	if err != nil {
+		return err
+	}
+	defer fs.Close()
+
+	if _, err := io.Copy(outStream, fs); err != nil {
+		return err
+	}
+	logrus.Debugf("End export image")
+	return nil
+}
+
+// ociExporter writes images to an OCI image layout.
+type ociExporter struct {
+	*TagStore
+	root string
+	// layers are the descriptors of the exported layers, by image ID.
+	layers map[string]schema2Descriptor
+}
+
+// exportImage writes the layers, the config and the manifest of the image
+// id, and returns the descriptor of its manifest.
+func (e *ociExporter) exportImage(id string) (ociDescriptor, error) {
+	var imgs []*image.Image
+	img, err := e.graph.Get(id)
+	for ; img != nil; img, err = e.graph.GetParent(img) {
+		if err != nil {
+			return ociDescriptor{}, err
+		}
+		imgs = append([]*image.Image{img}, imgs...)
+	}
+	if err != nil {
+		return ociDescriptor{}, err
+	}
+	if len(imgs) == 0 {
+		return ociDescriptor{}, fmt.Errorf("No such image: %s", id)
+	}
+
+	m := schema2Manifest{
+		SchemaVersion: 2,
+		MediaType:     ociManifestMediaType,
+	}
+	var diffIDs []digest.Digest
+	for _, img := range imgs {
+		desc, ok := e.layers[img.ID]
+		if !ok {
+			if desc, err = e.exportLayer(img); err != nil {
+				return ociDescriptor{}, err
+			}
+			e.layers[img.ID] = desc
+		}
+		m.Layers = append(m.Layers, desc)
+		// The layers are uncompressed.
+		diffIDs = append(diffIDs, desc.Digest)
+	}
+
+	config, err := configFromV1Images(imgs, diffIDs)
+	if err != nil {
+		return ociDescriptor{}, err
+	}
+	configJSON, err := json.Marshal(config)
+	if err != nil {
+		return ociDescriptor{}, err
+	}
+	if m.Config, err = e.writeBlob(ociConfigMediaType, configJSON); err != nil {
+		return ociDescriptor{}, err
+	}
+
+	manifestJSON, err := json.MarshalIndent(m, "", "   ")
+	if err != nil {
+		return ociDescriptor{}, err
+	}
+	desc, err := e.writeBlob(ociManifestMediaType, manifestJSON)
+	if err != nil {
+		return ociDescriptor{}, err
+	}
+	return ociDescriptor{
+		MediaType: desc.MediaType,
+		Digest:    desc.Digest,
+		Size:      desc.Size,
+		Platform: &manifestPlatform{
+			Architecture: config.Architecture,
+			OS:           config.OS,
+		},
+	}, nil
+}
+
+// exportLayer writes the uncompressed layer of the image as a blob.
+func (e *ociExporter) exportLayer(img *image.Image) (schema2Descriptor, error) {
+	f, err := ioutil.TempFile(e.root, "layer-")
+	if err != nil {
+		return schema2Descriptor{}, err
+	}
+	defer os.Remove(f.Name())
+	defer f.Close()
+
+	digester := digest.Canonical.New()
+	w := ioutils.NewWriteCounter(io.MultiWriter(f, digester.Hash()))
+	if err := e.ImageTarLayer(img.ID, w); err != nil {
+		return schema2Descriptor{}, err
+	}
+	if err := f.Close(); err != nil {
+		return schema2Descriptor{}, err
+	}
+
+	desc := schema2Descriptor{
+		MediaType: ociLayerMediaType,
+		Size:      w.Count,
+		Digest:    digester.Digest(),
+	}
+	if err := e.moveBlob(f.Name(), desc.Digest); err != nil {
+		return schema2Descriptor{}, err
+	}
+	logrus.Debugf("Exported layer %s as %s", img.ID, desc.Digest)
+	return desc, nil
+}
+
+// writeBlob writes data as a blob of the given media type.
+func (e *ociExporter) writeBlob(mediaType string, data []byte) (schema2Descriptor, error) {
+	dgst, err := digest.FromBytes(data)
+	if err != nil {
+		return schema2Descriptor{}, err
+	}
+	path := ociBlobPath(e.root, dgst)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return schema2Descriptor{}, err
+	}
+	if err := ioutil.WriteFile(path, data, 0644); err != nil {
+		return schema2Descriptor{}, err
+	}
+	return schema2Descriptor{
+		MediaType: mediaType,
+		Size:      int64(len(data)),
+		Digest:    dgst,
+	}, nil
+}
+
+// moveBlob moves the file at path to the blob dgst.
+func (e *ociExporter) moveBlob(path string, dgst digest.Digest) error {
+	blobPath := ociBlobPath(e.root, dgst)
+	if err := os.MkdirAll(filepath.Dir(blobPath), 0755); err != nil {
+		return err
+	}
+	if err := os.Chmod(path, 0644); err != nil {
+		return err
+	}
+	return os.Rename(path, blobPath)
+}
+
+func writeJSONFile(path string, v interface{}) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(path, data, 0644)
+}
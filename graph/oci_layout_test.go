@@ -0,0 +1,265 @@
+// +build linux windows
+
+package graph
+
+import (
+	"archive/tar"
+	"bytes"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/docker/distribution/digest"
+	"github.com/docker/docker/graph/tags"
+	"github.com/docker/docker/utils"
+)
+
+// readOCIArchive returns the files of a tar archive, by name.
+func readOCIArchive(t *testing.T, data []byte) map[string][]byte {
+	files := make(map[string][]byte)
+	tr := tar.NewReader(bytes.NewReader(data))
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		content, err := ioutil.ReadAll(tr)
+		if err != nil {
+			t.Fatal(err)
+		}
+		files[hdr.Name] = content
+	}
+	return files
+}
+
+// writeOCIArchive returns a tar archive of the given files.
+func writeOCIArchive(t *testing.T, files map[string][]byte) *bytes.Buffer {
+	buf := new(bytes.Buffer)
+	tw := tar.NewWriter(buf)
+	for name, content := range files {
+		if strings.HasSuffix(name, "/") {
+			continue
+		}
+		if err := tw.WriteHeader(&tar.Header{Name: name, Mode: 0644, Size: int64(len(content)), Typeflag: tar.TypeReg}); err != nil {
+			t.Fatal(err)
+		}
+		tw.Write(content)
+	}
+	tw.Close()
+	return buf
+}
+
+func TestImageExportOCI(t *testing.T) {
+	tmp, err := utils.TestDirectory("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmp)
+	store := mkTestTagStore(tmp, t)
+	defer store.graph.driver.Cleanup()
+
+	buf := new(bytes.Buffer)
+	if err := store.ImageExportOCI([]string{testOfficialImageName, testPrivateImageID}, buf); err != nil {
+		t.Fatal(err)
+	}
+	files := readOCIArchive(t, buf.Bytes())
+
+	if string(files[ociLayoutFileName]) != `{"imageLayoutVersion":"1.0.0"}` {
+		t.Fatalf("Unexpected oci-layout %q", files[ociLayoutFileName])
+	}
+	var index ociIndex
+	if err := json.Unmarshal(files[ociIndexFileName], &index); err != nil {
+		t.Fatal(err)
+	}
+	if len(index.Manifests) != 2 {
+		t.Fatalf("Expected 2 manifests, got %d", len(index.Manifests))
+	}
+	if ref := index.Manifests[0].Annotations[ociRefNameAnnotation]; ref != testOfficialImageName+":"+tags.DefaultTag {
+		t.Fatalf("Unexpected reference %q", ref)
+	}
+	if len(index.Manifests[1].Annotations) != 0 {
+		t.Fatalf("Expected no reference for an image ID, got %v", index.Manifests[1].Annotations)
+	}
+
+	desc := index.Manifests[0]
+	var m schema2Manifest
+	if err := json.Unmarshal(files[ociBlobPath("", desc.Digest)], &m); err != nil {
+		t.Fatal(err)
+	}
+	if m.MediaType != ociManifestMediaType || m.Config.MediaType != ociConfigMediaType {
+		t.Fatalf("Unexpected media types in manifest %+v", m)
+	}
+	if len(m.Layers) != 1 || m.Layers[0].MediaType != ociLayerMediaType {
+		t.Fatalf("Expected an uncompressed layer, got %+v", m.Layers)
+	}
+	layer := files[ociBlobPath("", m.Layers[0].Digest)]
+	if int64(len(layer)) != m.Layers[0].Size {
+		t.Fatalf("Expected a layer of %d bytes, got %d", m.Layers[0].Size, len(layer))
+	}
+
+	// Both images have the same content, their blobs are only stored once.
+	var blobs int
+	for name := range files {
+		if strings.HasPrefix(name, ociBlobsDirName+"/") && !strings.HasSuffix(name, "/") {
+			blobs++
+		}
+	}
+	if blobs != 3 {
+		t.Fatalf("Expected a layer, a config and a manifest, got %d blobs", blobs)
+	}
+}
+
+func TestLoadOCILayout(t *testing.T) {
+	tmp, err := utils.TestDirectory("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmp)
+	store := mkTestTagStore(tmp, t)
+	defer store.graph.driver.Cleanup()
+
+	buf := new(bytes.Buffer)
+	if err := store.ImageExportOCI([]string{testOfficialImageName}, buf); err != nil {
+		t.Fatal(err)
+	}
+	exported := buf.Bytes()
+
+	tmp2, err := utils.TestDirectory("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmp2)
+	store2 := mkTestTagStore(tmp2, t)
+	defer store2.graph.driver.Cleanup()
+
+	out := new(bytes.Buffer)
+	if err := store2.Load(ioutil.NopCloser(bytes.NewReader(exported)), out); err != nil {
+		t.Fatal(err)
+	}
+	img, err := store2.LookupImage(testOfficialImageName)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if img == nil || img.ID == testOfficialImageID {
+		t.Fatalf("Expected %s to be tagged with the loaded image, got %v", testOfficialImageName, img)
+	}
+	if _, err := store2.graph.GetDiffID(img.ID); err != nil {
+		t.Fatalf("Expected the diff ID of the loaded layer to be set: %v", err)
+	}
+
+	// Loading the image it exported again yields the same one.
+	buf.Reset()
+	if err := store2.ImageExportOCI([]string{testOfficialImageName}, buf); err != nil {
+		t.Fatal(err)
+	}
+	if err := store2.Load(ioutil.NopCloser(bytes.NewReader(buf.Bytes())), out); err != nil {
+		t.Fatal(err)
+	}
+	img2, err := store2.LookupImage(testOfficialImageName)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if img2.ID != img.ID {
+		t.Fatalf("Expected the image %s to be loaded again, got %s", img.ID, img2.ID)
+	}
+
+	// Layers not matching their digest are rejected.
+	files := readOCIArchive(t, exported)
+	var index ociIndex
+	if err := json.Unmarshal(files[ociIndexFileName], &index); err != nil {
+		t.Fatal(err)
+	}
+	var m schema2Manifest
+	if err := json.Unmarshal(files[ociBlobPath("", index.Manifests[0].Digest)], &m); err != nil {
+		t.Fatal(err)
+	}
+	layerPath := ociBlobPath("", m.Layers[0].Digest)
+	files[layerPath] = append(files[layerPath], 0)
+	tampered := writeOCIArchive(t, files)
+
+	tmp3, err := utils.TestDirectory("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmp3)
+	store3 := mkTestTagStore(tmp3, t)
+	defer store3.graph.driver.Cleanup()
+	if err := store3.Load(ioutil.NopCloser(tampered), out); err == nil || !strings.Contains(err.Error(), "verification failed") {
+		t.Fatalf("Expected the verification of the tampered layer to fail, got %v", err)
+	}
+}
+
+func TestLoadOCILayoutWithForeignLayer(t *testing.T) {
+	tmp, err := utils.TestDirectory("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmp)
+	store := mkTestTagStore(tmp, t)
+	defer store.graph.driver.Cleanup()
+
+	buf := new(bytes.Buffer)
+	if err := store.ImageExportOCI([]string{testOfficialImageName}, buf); err != nil {
+		t.Fatal(err)
+	}
+
+	// The layer is replaced by another one, with a matching blob digest,
+	// while the config still gives the diff ID of the original layer.
+	files := readOCIArchive(t, buf.Bytes())
+	var index ociIndex
+	if err := json.Unmarshal(files[ociIndexFileName], &index); err != nil {
+		t.Fatal(err)
+	}
+	var m schema2Manifest
+	if err := json.Unmarshal(files[ociBlobPath("", index.Manifests[0].Digest)], &m); err != nil {
+		t.Fatal(err)
+	}
+	layer := new(bytes.Buffer)
+	tw := tar.NewWriter(layer)
+	tw.WriteHeader(&tar.Header{Name: "foreign", Mode: 0644, Size: 3, Typeflag: tar.TypeReg})
+	tw.Write([]byte("bad"))
+	tw.Close()
+	layerDigest, err := digest.FromBytes(layer.Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+	files[ociBlobPath("", layerDigest)] = layer.Bytes()
+	m.Layers[0].MediaType = uncompressedLayerMediaType
+	m.Layers[0].Digest = layerDigest
+	m.Layers[0].Size = int64(layer.Len())
+	manifestJSON, err := json.Marshal(m)
+	if err != nil {
+		t.Fatal(err)
+	}
+	manifestDigest, err := digest.FromBytes(manifestJSON)
+	if err != nil {
+		t.Fatal(err)
+	}
+	files[ociBlobPath("", manifestDigest)] = manifestJSON
+	index.Manifests[0].Digest = manifestDigest
+	index.Manifests[0].Size = int64(len(manifestJSON))
+	if files[ociIndexFileName], err = json.Marshal(index); err != nil {
+		t.Fatal(err)
+	}
+
+	tmp2, err := utils.TestDirectory("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmp2)
+	store2 := mkTestTagStore(tmp2, t)
+	defer store2.graph.driver.Cleanup()
+	out := new(bytes.Buffer)
+	if err := store2.Load(ioutil.NopCloser(writeOCIArchive(t, files)), out); err == nil || !strings.Contains(err.Error(), "doesn't match") {
+		t.Fatalf("Expected the layer not matching its diff ID to be rejected, got %v", err)
+	}
+	if img, err := store2.LookupImage(testOfficialImageName); err != nil || img.ID != testOfficialImageID {
+		t.Fatalf("Expected %s not to be tagged with the rejected image, got %v: %v", testOfficialImageName, img, err)
+	}
+}
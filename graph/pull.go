@@ -22,6 +22,17 @@ type ImagePullConfig struct {
 	// OutStream is the output writer for showing the status of the pull
 	// operation.
 	OutStream io.Writer
+	// Platform is the os/arch[/variant] to pull the image for. If empty,
+	// the platform of the daemon is pulled from manifest lists.
+	Platform string
+	// KeepTags leaves the tags of the repository untouched, so that an
+	// image pulled for another platform doesn't replace the image other
+	// users of its tag expect. The ID of the pulled image is then stored
+	// in PulledID, and the digest of the image pulled for Platform by tag
+	// is recorded, see TagStore.PlatformDigest.
+	KeepTags bool
+	// PulledID is the ID of the image pulled with KeepTags.
+	PulledID string
 }
 
 // Puller is an interface that abstracts pulling for different API versions.
@@ -76,6 +87,10 @@ func (s *TagStore) Pull(image string, tag string, imagePullConfig *ImagePullConf
 		return err
 	}
 
+	if repoInfo.Index.RequireDigest && !utils.DigestReference(tag) {
+		return errDigestRequired(repoInfo)
+	}
+
 	endpoints, err := s.registryService.LookupPullEndpoints(repoInfo.CanonicalName)
 	if err != nil {
 		return err
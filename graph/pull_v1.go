@@ -155,7 +155,14 @@ func (p *v1Puller) pullRepository(askedTag string) error {
 			success := false
 			var lastErr, err error
 			var isDownloaded bool
-			for _, ep := range p.repoInfo.Index.Mirrors {
+			// The session sends its token to the mirrors, so only the
+			// mirrors allowed to receive the credentials of a private
+			// registry are tried.
+			mirrors := p.repoInfo.Index.Mirrors
+			if !p.repoInfo.Index.Official {
+				mirrors = p.repoInfo.Index.AuthMirrors
+			}
+			for _, ep := range mirrors {
 				ep += "v1/"
 				broadcaster.Write(p.sf.FormatProgress(stringid.TruncateID(img.ID), fmt.Sprintf("Pulling image (%s) from %s, mirror: %s", img.Tag, p.repoInfo.CanonicalName, ep), nil))
 				if isDownloaded, err = p.pullImage(broadcaster, img.ID, ep); err != nil {
@@ -211,6 +218,10 @@ func (p *v1Puller) pullRepository(askedTag string) error {
 		if askedTag != "" && tag != askedTag {
 			continue
 		}
+		p.config.PulledID = id
+		if p.config.KeepTags {
+			continue
+		}
 		if err := p.Tag(p.repoInfo.LocalName, tag, id, true); err != nil {
 			return err
 		}
@@ -1,16 +1,20 @@
 package graph
 
 import (
+	"encoding/json"
 	"fmt"
 	"io"
 	"io/ioutil"
+	"net/http"
 	"os"
 
 	"github.com/Sirupsen/logrus"
 	"github.com/docker/distribution"
 	"github.com/docker/distribution/digest"
 	"github.com/docker/distribution/manifest"
+	"github.com/docker/distribution/registry/client"
 	"github.com/docker/docker/image"
+	"github.com/docker/docker/pkg/parsers"
 	"github.com/docker/docker/pkg/progressreader"
 	"github.com/docker/docker/pkg/streamformatter"
 	"github.com/docker/docker/pkg/stringid"
@@ -28,12 +32,20 @@ type v2Puller struct {
 	sf        *streamformatter.StreamFormatter
 	repoInfo  *registry.RepositoryInfo
 	repo      distribution.Repository
+	repoName  string            // name of the repository on the endpoint
+	transport http.RoundTripper // authenticated transport to the endpoint
 	sessionID string
 }
 
 func (p *v2Puller) Pull(tag string) (fallback bool, err error) {
 	// TODO(tiborvass): was ReceiveTimeout
-	p.repo, err = NewV2Repository(p.repoInfo, p.endpoint, p.config.MetaHeaders, p.config.AuthConfig, "pull")
+	p.repoName = v2RepoName(p.repoInfo, p.endpoint)
+	p.transport, err = newV2Transport(p.endpoint, p.config.MetaHeaders, p.config.AuthConfig, registry.RepositoryScope(p.repoName, "pull"))
+	if err != nil {
+		logrus.Debugf("Error getting v2 registry: %v", err)
+		return true, err
+	}
+	p.repo, err = client.NewRepository(context.Background(), p.repoName, p.endpoint.URL, p.transport)
 	if err != nil {
 		logrus.Debugf("Error getting v2 registry: %v", err)
 		return true, err
@@ -42,7 +54,9 @@ func (p *v2Puller) Pull(tag string) (fallback bool, err error) {
 	p.sessionID = stringid.GenerateRandomID()
 
 	if err := p.pullV2Repository(tag); err != nil {
-		if registry.ContinueOnError(err) {
+		// Any error of a mirror falls back to the next mirror or to the
+		// registry itself.
+		if p.endpoint.Mirror || registry.ContinueOnError(err) {
 			logrus.Debugf("Error trying v2 registry: %v", err)
 			return true, err
 		}
@@ -103,90 +117,102 @@ func (p *v2Puller) pullV2Repository(tag string) (err error) {
 
 // downloadInfo is used to pass information from download to extractor
 type downloadInfo struct {
-	img         *image.Image
-	tmpFile     *os.File
-	digest      digest.Digest
-	layer       distribution.ReadSeekCloser
+	img     *image.Image
+	tmpFile *os.File
+	digest  digest.Digest
+	// diffID is the digest of the uncompressed content of the layer, if
+	// the manifest gives it.
+	diffID      digest.Digest
 	size        int64
-	err         chan error
 	poolKey     string
 	broadcaster *progressreader.Broadcaster
+	// shared is set if the layer is pulled by a different pull.
+	shared bool
+	// transfer is the download of the blob of the layer, nil if the layer
+	// is shared or empty.
+	transfer *transfer
 }
 
 type errVerification struct{}
 
 func (errVerification) Error() string { return "verification failed" }
 
-func (p *v2Puller) download(di *downloadInfo) {
-	logrus.Debugf("pulling blob %q to %s", di.digest, di.img.ID)
+// download returns the transfer downloading the blob of the layer,
+// attaching to the download of the blob if it is in flight.
+func (p *v2Puller) download(img *image.Image, dgst digest.Digest, out io.Writer) *transfer {
+	return p.downloadManager.transfer(dgst.String(), out, func(t *transfer) error {
+		logrus.Debugf("pulling blob %q to %s", dgst, img.ID)
 
-	blobs := p.repo.Blobs(context.Background())
-
-	desc, err := blobs.Stat(context.Background(), di.digest)
-	if err != nil {
-		logrus.Debugf("Error statting layer: %v", err)
-		di.err <- err
-		return
-	}
-	di.size = desc.Size
+		tmpFile, release, err := p.graph.openPartialDownload(dgst)
+		if err != nil {
+			return err
+		}
+		// Keep the partial download of a failed transfer for the next
+		// pull to resume it.
+		t.cleanup = func(err error) { release(err != nil) }
+
+		di := &downloadInfo{
+			img:         img,
+			tmpFile:     tmpFile,
+			digest:      dgst,
+			broadcaster: t.Broadcaster,
+		}
+		if err := p.downloadBlob(di); err != nil {
+			logrus.Debugf("Error fetching layer: %v", err)
+			return err
+		}
 
-	layerDownload, err := blobs.Open(context.Background(), di.digest)
-	if err != nil {
-		logrus.Debugf("Error fetching layer: %v", err)
-		di.err <- err
-		return
-	}
-	defer layerDownload.Close()
+		t.Write(p.sf.FormatProgress(stringid.TruncateID(img.ID), "Download complete", nil))
 
-	verifier, err := digest.NewDigestVerifier(di.digest)
-	if err != nil {
-		di.err <- err
-		return
-	}
-
-	reader := progressreader.New(progressreader.Config{
-		In:        ioutil.NopCloser(io.TeeReader(layerDownload, verifier)),
-		Out:       di.broadcaster,
-		Formatter: p.sf,
-		Size:      di.size,
-		NewLines:  false,
-		ID:        stringid.TruncateID(di.img.ID),
-		Action:    "Downloading",
+		logrus.Debugf("Downloaded %s to tempfile %s", img.ID, tmpFile.Name())
+		t.file = tmpFile
+		t.size = di.size
+		return nil
 	})
-	io.Copy(di.tmpFile, reader)
-
-	di.broadcaster.Write(p.sf.FormatProgress(stringid.TruncateID(di.img.ID), "Verifying Checksum", nil))
-
-	if !verifier.Verified() {
-		err = fmt.Errorf("filesystem layer verification failed for digest %s", di.digest)
-		logrus.Error(err)
-		di.err <- err
-		return
-	}
-
-	di.broadcaster.Write(p.sf.FormatProgress(stringid.TruncateID(di.img.ID), "Download complete", nil))
-
-	logrus.Debugf("Downloaded %s to tempfile %s", di.img.ID, di.tmpFile.Name())
-	di.layer = layerDownload
-
-	di.err <- nil
 }
 
 func (p *v2Puller) pullV2Tag(out io.Writer, tag, taggedName string) (verified bool, err error) {
 	logrus.Debugf("Pulling tag from V2 registry: %q", tag)
 
-	manSvc, err := p.repo.Manifests(context.Background())
+	pm, err := p.resolveManifest(tag)
 	if err != nil {
 		return false, err
 	}
 
-	manifest, err := manSvc.GetByTag(tag)
-	if err != nil {
-		return false, err
-	}
-	verified, err = p.validateManifest(manifest, tag)
-	if err != nil {
-		return false, err
+	// imgs and blobs are the images of the layers to pull, from the base
+	// layer to the top one, and the digests of their blobs. diffIDs are the
+	// digests of their uncompressed content, which schema1 manifests don't
+	// give.
+	var (
+		imgs    []*image.Image
+		blobs   []digest.Digest
+		diffIDs []digest.Digest
+	)
+	if pm.schema2 != nil {
+		if imgs, blobs, diffIDs, err = p.schema2Images(pm.schema2, tag); err != nil {
+			return false, err
+		}
+	} else {
+		manifest := pm.schema1
+		verified, err = p.validateManifest(manifest, pm.ref)
+		if err != nil {
+			return false, err
+		}
+		if p.config.Platform != "" {
+			if err := checkManifestPlatform(manifest, p.config.Platform); err != nil {
+				return false, err
+			}
+		}
+		for i := len(manifest.FSLayers) - 1; i >= 0; i-- {
+			img, err := image.NewImgJSON([]byte(manifest.History[i].V1Compatibility))
+			if err != nil {
+				logrus.Debugf("error getting image v1 json: %v", err)
+				return false, err
+			}
+			imgs = append(imgs, img)
+			blobs = append(blobs, manifest.FSLayers[i].BlobSum)
+			diffIDs = append(diffIDs, "")
+		}
 	}
 	if verified {
 		logrus.Printf("Image manifest for %s has been verified", taggedName)
@@ -202,27 +228,32 @@ func (p *v2Puller) pullV2Tag(out io.Writer, tag, taggedName string) (verified bo
 
 		for _, d := range downloads {
 			p.poolRemoveWithError("pull", d.poolKey, err)
-			if d.tmpFile != nil {
-				d.tmpFile.Close()
-				if err := os.RemoveAll(d.tmpFile.Name()); err != nil {
-					logrus.Errorf("Failed to remove temp file: %s", d.tmpFile.Name())
-				}
+			if d.transfer != nil {
+				d.transfer.release()
 			}
 		}
 	}()
 
-	for i := len(manifest.FSLayers) - 1; i >= 0; i-- {
-		img, err := image.NewImgJSON([]byte(manifest.History[i].V1Compatibility))
-		if err != nil {
-			logrus.Debugf("error getting image v1 json: %v", err)
-			return false, err
-		}
+	for i, img := range imgs {
 		p.graph.Retain(p.sessionID, img.ID)
 		layerIDs = append(layerIDs, img.ID)
 
 		// Check if exists
 		if p.graph.Exists(img.ID) {
 			logrus.Debugf("Image already exists: %s", img.ID)
+			// The IDs of schema2 images are derived from the digests
+			// of their layers, which an image registered by other
+			// means may not match.
+			if diffIDs[i] != "" {
+				if err := p.graph.VerifyDiffID(img.ID, diffIDs[i]); err != nil {
+					return false, err
+				}
+			}
+			if dgst, err := p.graph.GetDigest(img.ID); err == nil && dgst == blobs[i] {
+				if err := p.addBlobSource(img.ID, dgst); err != nil {
+					return false, err
+				}
+			}
 			out.Write(p.sf.FormatProgress(stringid.TruncateID(img.ID), "Already exists", nil))
 			continue
 		}
@@ -231,38 +262,24 @@ func (p *v2Puller) pullV2Tag(out io.Writer, tag, taggedName string) (verified bo
 		d := &downloadInfo{
 			img:     img,
 			poolKey: "layer:" + img.ID,
-			digest:  manifest.FSLayers[i].BlobSum,
-			// TODO: seems like this chan buffer solved hanging problem in go1.5,
-			// this can indicate some deeper problem that somehow we never take
-			// error from channel in loop below
-			err: make(chan error, 1),
+			digest:  blobs[i],
+			diffID:  diffIDs[i],
 		}
 
-		tmpFile, err := ioutil.TempFile("", "GetImageBlob")
-		if err != nil {
-			return false, err
-		}
-		d.tmpFile = tmpFile
-
 		downloads = append(downloads, d)
 
 		broadcaster, found := p.poolAdd("pull", d.poolKey)
 		broadcaster.Add(out)
 		d.broadcaster = broadcaster
-		if found {
-			d.err <- nil
-		} else {
-			go p.download(d)
+		d.shared = found
+		if !found && d.digest != "" {
+			d.transfer = p.download(img, d.digest, broadcaster)
 		}
 	}
 
 	var tagUpdated bool
 	for _, d := range downloads {
-		if err := <-d.err; err != nil {
-			return false, err
-		}
-
-		if d.layer == nil {
+		if d.shared {
 			// Wait for a different pull to download and extract
 			// this layer.
 			err = d.broadcaster.Wait()
@@ -272,24 +289,43 @@ func (p *v2Puller) pullV2Tag(out io.Writer, tag, taggedName string) (verified bo
 			continue
 		}
 
-		d.tmpFile.Seek(0, 0)
-		reader := progressreader.New(progressreader.Config{
-			In:        d.tmpFile,
-			Out:       d.broadcaster,
-			Formatter: p.sf,
-			Size:      d.size,
-			NewLines:  false,
-			ID:        stringid.TruncateID(d.img.ID),
-			Action:    "Extracting",
-		})
-
-		err = p.graph.Register(d.img, reader)
-		if err != nil {
-			return false, err
-		}
+		if d.transfer == nil {
+			// The layer is empty, only its image is stored.
+			if err := p.graph.Register(d.img, nil); err != nil {
+				return false, err
+			}
+		} else {
+			if err := d.transfer.Wait(); err != nil {
+				return false, err
+			}
 
-		if err := p.graph.SetDigest(d.img.ID, d.digest); err != nil {
-			return false, err
+			// The downloaded blob may be shared with other pulls, read it
+			// without moving the offset of the file.
+			reader := progressreader.New(progressreader.Config{
+				In:        ioutil.NopCloser(io.NewSectionReader(d.transfer.file, 0, d.transfer.size)),
+				Out:       d.broadcaster,
+				Formatter: p.sf,
+				Size:      d.transfer.size,
+				NewLines:  false,
+				ID:        stringid.TruncateID(d.img.ID),
+				Action:    "Extracting",
+			})
+
+			if d.diffID != "" {
+				err = p.graph.RegisterVerified(d.img, reader, d.diffID)
+			} else {
+				err = p.graph.Register(d.img, reader)
+			}
+			if err != nil {
+				return false, err
+			}
+
+			if err := p.graph.SetDigest(d.img.ID, d.digest); err != nil {
+				return false, err
+			}
+			if err := p.addBlobSource(d.img.ID, d.digest); err != nil {
+				return false, err
+			}
 		}
 
 		d.broadcaster.Write(p.sf.FormatProgress(stringid.TruncateID(d.img.ID), "Pull complete", nil))
@@ -297,9 +333,11 @@ func (p *v2Puller) pullV2Tag(out io.Writer, tag, taggedName string) (verified bo
 		tagUpdated = true
 	}
 
-	manifestDigest, _, err := digestFromManifest(manifest, p.repoInfo.LocalName)
-	if err != nil {
-		return false, err
+	manifestDigest := pm.digest
+	if manifestDigest == "" {
+		if manifestDigest, _, err = digestFromManifest(pm.schema1, p.repoInfo.LocalName); err != nil {
+			return false, err
+		}
 	}
 
 	// Check for new tag if no layers downloaded
@@ -322,18 +360,36 @@ func (p *v2Puller) pullV2Tag(out io.Writer, tag, taggedName string) (verified bo
 	}
 
 	firstID := layerIDs[len(layerIDs)-1]
+	p.config.PulledID = firstID
 	if utils.DigestReference(tag) {
-		// TODO(stevvooe): Ideally, we should always set the digest so we can
-		// use the digest whether we pull by it or not. Unfortunately, the tag
-		// store treats the digest as a separate tag, meaning there may be an
-		// untagged digest image that would seem to be dangling by a user.
 		if err = p.SetDigest(p.repoInfo.LocalName, tag, firstID); err != nil {
 			return false, err
 		}
 	} else {
 		// only set the repository/tag -> image ID mapping when pulling by tag (i.e. not by digest)
-		if err = p.Tag(p.repoInfo.LocalName, tag, firstID, true); err != nil {
-			return false, err
+		if !p.config.KeepTags {
+			if err = p.Tag(p.repoInfo.LocalName, tag, firstID, true); err != nil {
+				return false, err
+			}
+		}
+		// Also record the digest of the image manifest, so that the
+		// image can be referenced by digest whether it was pulled by
+		// it or not. The digest of a manifest list references the
+		// images of all its platforms, record that of the manifest of
+		// the pulled one instead.
+		imageDigest := manifestDigest.String()
+		if utils.DigestReference(pm.ref) {
+			imageDigest = pm.ref
+		}
+		if imageDigest != "" {
+			if err = p.SetDigest(p.repoInfo.LocalName, imageDigest, firstID); err != nil {
+				return false, err
+			}
+			if p.config.KeepTags {
+				if err = p.SetPlatformDigest(utils.ImageReference(p.repoInfo.LocalName, tag), p.config.Platform, imageDigest); err != nil {
+					return false, err
+				}
+			}
 		}
 	}
 
@@ -344,6 +400,73 @@ func (p *v2Puller) pullV2Tag(out io.Writer, tag, taggedName string) (verified bo
 	return tagUpdated, nil
 }
 
+// schema2Images fetches the config of a schema2 image and returns the v1
+// images of its layers, from the base layer to the top one, along with the
+// digests of their blobs and of their uncompressed content.
+func (p *v2Puller) schema2Images(m *schema2Manifest, tag string) ([]*image.Image, []digest.Digest, []digest.Digest, error) {
+	if m.SchemaVersion != 2 {
+		return nil, nil, nil, fmt.Errorf("unsupported schema version %d for tag %q", m.SchemaVersion, tag)
+	}
+	if len(m.Layers) == 0 {
+		return nil, nil, nil, fmt.Errorf("no layers in manifest for tag %q", tag)
+	}
+
+	data, err := p.repo.Blobs(context.Background()).Get(context.Background(), m.Config.Digest)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	verifier, err := digest.NewDigestVerifier(m.Config.Digest)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	verifier.Write(data)
+	if !verifier.Verified() {
+		err := fmt.Errorf("image config verification failed for digest %s", m.Config.Digest)
+		logrus.Error(err)
+		return nil, nil, nil, err
+	}
+
+	var config imageConfig
+	if err := json.Unmarshal(data, &config); err != nil {
+		return nil, nil, nil, err
+	}
+	if p.config.Platform != "" {
+		if err := checkPlatform(p.repoInfo.LocalName+":"+tag, config.OS, config.Architecture, p.config.Platform); err != nil {
+			return nil, nil, nil, err
+		}
+	}
+
+	var layers []digest.Digest
+	for _, l := range m.Layers {
+		layers = append(layers, l.Digest)
+	}
+	return v1ImagesFromConfig(&config, layers)
+}
+
+// checkManifestPlatform returns an error if the image of a manifest was
+// built for another platform than the requested one.
+func checkManifestPlatform(m *manifest.SignedManifest, platform string) error {
+	img, err := image.NewImgJSON([]byte(m.History[0].V1Compatibility))
+	if err != nil {
+		return err
+	}
+	return checkPlatform(m.Name+":"+m.Tag, img.OS, img.Architecture, platform)
+}
+
+// checkPlatform returns an error if the image ref was built for another
+// platform than the requested one. Images which don't record their platform
+// are accepted.
+func checkPlatform(ref, imageOS, arch, platform string) error {
+	platformOS, platformArch, _, err := parsers.ParsePlatform(platform)
+	if err != nil {
+		return err
+	}
+	if (imageOS != "" && imageOS != platformOS) || (arch != "" && arch != platformArch) {
+		return fmt.Errorf("image %s is for platform %s/%s, not %s", ref, imageOS, arch, platform)
+	}
+	return nil
+}
+
 // verifyTrustedKeys checks the keys provided against the trust store,
 // ensuring that the provided keys are trusted for the namespace. The keys
 // provided from this method must come from the signatures provided as part of
@@ -423,3 +546,9 @@ func (p *v2Puller) validateManifest(m *manifest.SignedManifest, tag string) (ver
 	}
 	return verified, nil
 }
+
+// addBlobSource records that the blob of the layer exists in the pulled
+// repository, for pushes to other repositories of the registry to mount it.
+func (p *v2Puller) addBlobSource(id string, dgst digest.Digest) error {
+	return p.graph.AddBlobSource(id, BlobSource{Digest: dgst, Registry: p.endpoint.URL, Repository: p.repoName})
+}
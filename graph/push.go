@@ -51,7 +51,7 @@ func (s *TagStore) NewPusher(endpoint registry.APIEndpoint, localRepo Repository
 			repoInfo:     repoInfo,
 			config:       imagePushConfig,
 			sf:           sf,
-			layersPushed: make(map[digest.Digest]bool),
+			layersPushed: make(map[digest.Digest]int64),
 		}, nil
 	case registry.APIVersion1:
 		return &v1Pusher{
@@ -1,14 +1,21 @@
 package graph
 
 import (
+	"bytes"
+	"encoding/json"
 	"fmt"
 	"io"
 	"io/ioutil"
+	"net/http"
+	"net/url"
 
 	"github.com/Sirupsen/logrus"
 	"github.com/docker/distribution"
 	"github.com/docker/distribution/digest"
 	"github.com/docker/distribution/manifest"
+	"github.com/docker/distribution/registry/api/errcode"
+	"github.com/docker/distribution/registry/api/v2"
+	"github.com/docker/distribution/registry/client"
 	"github.com/docker/docker/image"
 	"github.com/docker/docker/pkg/progressreader"
 	"github.com/docker/docker/pkg/streamformatter"
@@ -27,15 +34,26 @@ type v2Pusher struct {
 	config    *ImagePushConfig
 	sf        *streamformatter.StreamFormatter
 	repo      distribution.Repository
+	repoName  string            // name of the repository on the endpoint
+	transport http.RoundTripper // authenticated transport to the endpoint
 
-	// layersPushed is the set of layers known to exist on the remote side.
-	// This avoids redundant queries when pushing multiple tags that
+	// layersPushed is the size of the layers known to exist on the remote
+	// side. This avoids redundant queries when pushing multiple tags that
 	// involve the same layers.
-	layersPushed map[digest.Digest]bool
+	layersPushed map[digest.Digest]int64
+	// mountTransports are the transports authenticated for mounting
+	// blobs from other repositories, by repository.
+	mountTransports map[string]http.RoundTripper
 }
 
 func (p *v2Pusher) Push() (fallback bool, err error) {
-	p.repo, err = NewV2Repository(p.repoInfo, p.endpoint, p.config.MetaHeaders, p.config.AuthConfig, "push", "pull")
+	p.repoName = v2RepoName(p.repoInfo, p.endpoint)
+	p.transport, err = newV2Transport(p.endpoint, p.config.MetaHeaders, p.config.AuthConfig, registry.RepositoryScope(p.repoName, "push", "pull"))
+	if err != nil {
+		logrus.Debugf("Error getting v2 registry: %v", err)
+		return true, err
+	}
+	p.repo, err = client.NewRepository(context.Background(), p.repoName, p.endpoint.URL, p.transport)
 	if err != nil {
 		logrus.Debugf("Error getting v2 registry: %v", err)
 		return true, err
@@ -84,6 +102,16 @@ func (p *v2Pusher) pushV2Repository(tag string) error {
 	return nil
 }
 
+// uploadInfo is a layer of a pushed image, and its upload if it doesn't
+// exist on the remote side.
+type uploadInfo struct {
+	img      *image.Image
+	jsonData []byte
+	digest   digest.Digest
+	size     int64
+	transfer *transfer
+}
+
 func (p *v2Pusher) pushV2Tag(tag string) error {
 	logrus.Debugf("Pushing repository: %s:%s", p.repo.Name(), tag)
 
@@ -117,6 +145,15 @@ func (p *v2Pusher) pushV2Tag(tag string) error {
 
 	out := p.config.OutStream
 
+	var uploads []*uploadInfo
+	defer func() {
+		for _, u := range uploads {
+			if u.transfer != nil {
+				u.transfer.release()
+			}
+		}
+	}()
+
 	for ; layer != nil; layer, err = p.graph.GetParent(layer) {
 		if err != nil {
 			return err
@@ -143,24 +180,30 @@ func (p *v2Pusher) pushV2Tag(tag string) error {
 			return fmt.Errorf("cannot retrieve the path for %s: %s", layer.ID, err)
 		}
 
-		var exists bool
+		var (
+			exists bool
+			size   int64
+		)
 		dgst, err := p.graph.GetDigest(layer.ID)
 		switch err {
 		case nil:
-			if p.layersPushed[dgst] {
-				exists = true
+			if size, exists = p.layersPushed[dgst]; exists {
 				// break out of switch, it is already known that
 				// the push is not needed and therefore doing a
 				// stat is unnecessary
 				break
 			}
-			_, err := p.repo.Blobs(context.Background()).Stat(context.Background(), dgst)
+			desc, err := p.repo.Blobs(context.Background()).Stat(context.Background(), dgst)
 			switch err {
 			case nil:
 				exists = true
+				size = desc.Size
 				out.Write(p.sf.FormatProgress(stringid.TruncateID(layer.ID), "Image already exists", nil))
 			case distribution.ErrBlobUnknown:
-				// nop
+				var from string
+				if from, size, exists = p.mountBlob(layer.ID, dgst); exists {
+					out.Write(p.sf.FormatProgress(stringid.TruncateID(layer.ID), "Mounted from "+from, nil))
+				}
 			default:
 				out.Write(p.sf.FormatProgress(stringid.TruncateID(layer.ID), "Image push failed", nil))
 				return err
@@ -171,25 +214,45 @@ func (p *v2Pusher) pushV2Tag(tag string) error {
 			return fmt.Errorf("error getting image checksum: %v", err)
 		}
 
+		u := &uploadInfo{img: layer, jsonData: jsonData, digest: dgst, size: size}
+		uploads = append(uploads, u)
+
 		// if digest was empty or not saved, or if blob does not exist on the remote repository,
-		// then fetch it.
+		// then upload it.
 		if !exists {
-			if pushDigest, err := p.pushV2Image(p.repo.Blobs(context.Background()), layer); err != nil {
+			u.transfer = p.upload(layer, dgst)
+		}
+
+		layersSeen[layer.ID] = true
+	}
+
+	for _, u := range uploads {
+		if u.transfer != nil {
+			if err := u.transfer.Wait(); err != nil {
 				return err
-			} else if pushDigest != dgst {
+			}
+			if u.transfer.digest != u.digest {
 				// Cache new checksum
-				if err := p.graph.SetDigest(layer.ID, pushDigest); err != nil {
+				if err := p.graph.SetDigest(u.img.ID, u.transfer.digest); err != nil {
 					return err
 				}
-				dgst = pushDigest
+				u.digest = u.transfer.digest
 			}
+			u.size = u.transfer.size
 		}
 
-		m.FSLayers = append(m.FSLayers, manifest.FSLayer{BlobSum: dgst})
-		m.History = append(m.History, manifest.History{V1Compatibility: string(jsonData)})
+		m.FSLayers = append(m.FSLayers, manifest.FSLayer{BlobSum: u.digest})
+		m.History = append(m.History, manifest.History{V1Compatibility: string(u.jsonData)})
 
-		layersSeen[layer.ID] = true
-		p.layersPushed[dgst] = true
+		p.layersPushed[u.digest] = u.size
+		if err := p.graph.AddBlobSource(u.img.ID, BlobSource{Digest: u.digest, Registry: p.endpoint.URL, Repository: p.repoName}); err != nil {
+			return err
+		}
+	}
+
+	pushed, err := p.pushSchema2Manifest(tag, uploads)
+	if err != nil || pushed {
+		return err
 	}
 
 	logrus.Infof("Signed manifest for %s:%s using daemon's key: %s", p.repo.Name(), tag, p.trustKey.KeyID())
@@ -213,25 +276,133 @@ func (p *v2Pusher) pushV2Tag(tag string) error {
 	return manSvc.Put(signed)
 }
 
-func (p *v2Pusher) pushV2Image(bs distribution.BlobService, img *image.Image) (digest.Digest, error) {
-	out := p.config.OutStream
+// mountBlob mounts the blob of the layer from another repository of the
+// registry known to hold it, instead of uploading it again. It returns the
+// repository the blob was mounted from and its size, if it was mounted.
+func (p *v2Pusher) mountBlob(id string, dgst digest.Digest) (string, int64, bool) {
+	sources, err := p.graph.GetBlobSources(id)
+	if err != nil {
+		logrus.Debugf("Error getting the sources of %s: %v", id, err)
+		return "", 0, false
+	}
+	for _, source := range sources {
+		if source.Digest != dgst || source.Registry != p.endpoint.URL || source.Repository == p.repoName {
+			continue
+		}
+		mounted, err := p.tryMount(dgst, source.Repository)
+		if err != nil {
+			logrus.Debugf("Error mounting %s from %s: %v", dgst, source.Repository, err)
+			continue
+		}
+		if !mounted {
+			continue
+		}
+		desc, err := p.repo.Blobs(context.Background()).Stat(context.Background(), dgst)
+		if err != nil {
+			logrus.Debugf("Error getting the size of %s mounted from %s: %v", dgst, source.Repository, err)
+			continue
+		}
+		return source.Repository, desc.Size, true
+	}
+	return "", 0, false
+}
+
+// tryMount asks the registry to mount the blob from the repository from.
+// Registries which don't support mounting blobs, or don't let the user pull
+// from the repository, start an upload instead, which is cancelled.
+func (p *v2Pusher) tryMount(dgst digest.Digest, from string) (bool, error) {
+	tr, ok := p.mountTransports[from]
+	if !ok {
+		var err error
+		// Mounting requires pulling from the other repository.
+		tr, err = newV2Transport(p.endpoint, p.config.MetaHeaders, p.config.AuthConfig, registry.RepositoryScope(p.repoName, "push", "pull"), registry.RepositoryScope(from, "pull"))
+		if err != nil {
+			return false, err
+		}
+		if p.mountTransports == nil {
+			p.mountTransports = make(map[string]http.RoundTripper)
+		}
+		p.mountTransports[from] = tr
+	}
+	httpClient := &http.Client{Transport: tr}
+
+	ub, err := v2.NewURLBuilderFromString(p.endpoint.URL)
+	if err != nil {
+		return false, err
+	}
+	u, err := ub.BuildBlobUploadURL(p.repoName, url.Values{"mount": {dgst.String()}, "from": {from}})
+	if err != nil {
+		return false, err
+	}
+	req, err := http.NewRequest("POST", u, nil)
+	if err != nil {
+		return false, err
+	}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return false, err
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusCreated:
+		logrus.Debugf("Mounted %s from %s to %s", dgst, from, p.repoName)
+		return true, nil
+	case http.StatusAccepted:
+		location, err := url.Parse(resp.Header.Get("Location"))
+		if err != nil {
+			return false, err
+		}
+		cancelReq, err := http.NewRequest("DELETE", req.URL.ResolveReference(location).String(), nil)
+		if err != nil {
+			return false, err
+		}
+		cancelResp, err := httpClient.Do(cancelReq)
+		if err != nil {
+			return false, err
+		}
+		cancelResp.Body.Close()
+		return false, nil
+	default:
+		return false, &client.UnexpectedHTTPStatusError{Status: resp.Status}
+	}
+}
+
+// upload returns the transfer uploading the layer, attaching to the upload
+// of the layer, or of its blob if its digest is known, to the same
+// repository if it is in flight.
+func (p *v2Pusher) upload(img *image.Image, dgst digest.Digest) *transfer {
+	key := img.ID
+	if dgst != "" {
+		key = dgst.String()
+	}
+	key = p.endpoint.URL + "/" + p.repo.Name() + "@" + key
+
+	return p.uploadManager.transfer(key, p.config.OutStream, func(t *transfer) error {
+		pushDigest, size, err := p.pushV2Image(p.repo.Blobs(context.Background()), img, t)
+		t.digest = pushDigest
+		t.size = size
+		return err
+	})
+}
 
+func (p *v2Pusher) pushV2Image(bs distribution.BlobService, img *image.Image, out io.Writer) (digest.Digest, int64, error) {
 	out.Write(p.sf.FormatProgress(stringid.TruncateID(img.ID), "Preparing", nil))
 
 	image, err := p.graph.Get(img.ID)
 	if err != nil {
-		return "", err
+		return "", 0, err
 	}
 	arch, err := p.graph.TarLayer(image)
 	if err != nil {
-		return "", err
+		return "", 0, err
 	}
 	defer arch.Close()
 
 	// Send the layer
 	layerUpload, err := bs.Create(context.Background())
 	if err != nil {
-		return "", err
+		return "", 0, err
 	}
 	defer layerUpload.Close()
 
@@ -256,16 +427,165 @@ func (p *v2Pusher) pushV2Image(bs distribution.BlobService, img *image.Image) (d
 	out.Write(p.sf.FormatProgress(stringid.TruncateID(img.ID), "Pushing", nil))
 	nn, err := io.Copy(layerUpload, reader)
 	if err != nil {
-		return "", err
+		return "", 0, err
 	}
 
 	dgst := digester.Digest()
 	if _, err := layerUpload.Commit(context.Background(), distribution.Descriptor{Digest: dgst}); err != nil {
-		return "", err
+		return "", 0, err
 	}
 
 	logrus.Debugf("uploaded layer %s (%s), %d bytes", img.ID, dgst, nn)
 	out.Write(p.sf.FormatProgress(stringid.TruncateID(img.ID), "Pushed", nil))
 
+	// The blob is the uncompressed layer.
+	if err := p.graph.SetDiffID(img.ID, dgst); err != nil {
+		return "", 0, err
+	}
+
+	return dgst, nn, nil
+}
+
+// pushSchema2Manifest pushes the config of the image of the uploaded layers
+// and its schema2 manifest. It returns false if the registry doesn't accept
+// schema2 manifests, in which case a schema1 manifest should be pushed.
+func (p *v2Pusher) pushSchema2Manifest(tag string, uploads []*uploadInfo) (bool, error) {
+	m := schema2Manifest{
+		SchemaVersion: 2,
+		MediaType:     schema2MediaType,
+	}
+	var (
+		imgs    []*image.Image
+		diffIDs []digest.Digest
+	)
+	// The uploads go from the top layer to the base one.
+	for i := len(uploads) - 1; i >= 0; i-- {
+		u := uploads[i]
+		diffID, err := p.diffID(u.img)
+		if err != nil {
+			return false, err
+		}
+		mediaType := layerMediaType
+		if diffID == u.digest {
+			mediaType = uncompressedLayerMediaType
+		}
+		m.Layers = append(m.Layers, schema2Descriptor{
+			MediaType: mediaType,
+			Size:      u.size,
+			Digest:    u.digest,
+		})
+		imgs = append(imgs, u.img)
+		diffIDs = append(diffIDs, diffID)
+	}
+
+	config, err := configFromV1Images(imgs, diffIDs)
+	if err != nil {
+		return false, err
+	}
+	configJSON, err := json.Marshal(config)
+	if err != nil {
+		return false, err
+	}
+	configDigest, err := digest.FromBytes(configJSON)
+	if err != nil {
+		return false, err
+	}
+	m.Config = schema2Descriptor{
+		MediaType: imageConfigMediaType,
+		Size:      int64(len(configJSON)),
+		Digest:    configDigest,
+	}
+
+	payload, err := json.MarshalIndent(m, "", "   ")
+	if err != nil {
+		return false, err
+	}
+	manifestDigest, err := digest.FromBytes(payload)
+	if err != nil {
+		return false, err
+	}
+
+	// The config is only uploaded once the registry is known to accept
+	// schema2 manifests, so that falling back to a schema1 manifest
+	// doesn't leave it behind. Registries which check the blobs of a
+	// manifest reject it until the config is uploaded.
+	accepted, blobUnknown, err := p.putSchema2Manifest(tag, payload)
+	if err != nil || (!accepted && !blobUnknown) {
+		return false, err
+	}
+	if _, err := p.repo.Blobs(context.Background()).Put(context.Background(), imageConfigMediaType, configJSON); err != nil {
+		return false, err
+	}
+	if !accepted {
+		if accepted, _, err = p.putSchema2Manifest(tag, payload); err != nil || !accepted {
+			return false, err
+		}
+	}
+
+	p.config.OutStream.Write(p.sf.FormatStatus("", "%s: digest: %s size: %d", tag, manifestDigest, len(payload)))
+	return true, nil
+}
+
+// putSchema2Manifest puts the schema2 manifest of the tag. It returns whether
+// the registry accepted it and, if it didn't, whether it rejected it because
+// of a missing blob rather than because it doesn't support schema2.
+func (p *v2Pusher) putSchema2Manifest(tag string, payload []byte) (accepted, blobUnknown bool, err error) {
+	ub, err := v2.NewURLBuilderFromString(p.endpoint.URL)
+	if err != nil {
+		return false, false, err
+	}
+	u, err := ub.BuildManifestURL(p.repoName, tag)
+	if err != nil {
+		return false, false, err
+	}
+	req, err := http.NewRequest("PUT", u, bytes.NewReader(payload))
+	if err != nil {
+		return false, false, err
+	}
+	req.Header.Set("Content-Type", schema2MediaType)
+	resp, err := (&http.Client{Transport: p.transport}).Do(req)
+	if err != nil {
+		return false, false, err
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case client.SuccessStatus(resp.StatusCode):
+		return true, false, nil
+	case resp.StatusCode == http.StatusBadRequest || resp.StatusCode == http.StatusUnsupportedMediaType:
+		var errs errcode.Errors
+		if err := json.NewDecoder(resp.Body).Decode(&errs); err == nil {
+			for _, e := range errs {
+				if e, ok := e.(errcode.Error); ok && e.Code == v2.ErrorCodeManifestBlobUnknown {
+					return false, true, nil
+				}
+			}
+		}
+		logrus.Debugf("Registry rejected the schema2 manifest of %s:%s, pushing a schema1 manifest: %s", p.repoName, tag, resp.Status)
+		return false, false, nil
+	default:
+		return false, false, &client.UnexpectedHTTPStatusError{Status: resp.Status}
+	}
+}
+
+// diffID returns the digest of the uncompressed content of the layer of the
+// image, hashing the layer if it isn't known yet.
+func (p *v2Pusher) diffID(img *image.Image) (digest.Digest, error) {
+	dgst, err := p.graph.GetDiffID(img.ID)
+	if err != ErrDigestNotSet {
+		return dgst, err
+	}
+
+	arch, err := p.graph.TarLayer(img)
+	if err != nil {
+		return "", err
+	}
+	defer arch.Close()
+	if dgst, err = digest.FromReader(arch); err != nil {
+		return "", err
+	}
+	if err := p.graph.SetDiffID(img.ID, dgst); err != nil {
+		return "", err
+	}
 	return dgst, nil
 }
@@ -0,0 +1,109 @@
+package graph
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/docker/docker/cliconfig"
+	"github.com/docker/docker/registry"
+)
+
+func TestTryMount(t *testing.T) {
+	var cancelled bool
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == "GET" && r.URL.Path == "/v2/":
+			w.WriteHeader(http.StatusOK)
+		case r.Method == "POST" && r.URL.Path == "/v2/foo/blobs/uploads/":
+			if r.URL.Query().Get("mount") != "sha256:abc" {
+				t.Errorf("Unexpected mounted blob %q", r.URL.Query().Get("mount"))
+			}
+			if r.URL.Query().Get("from") == "bar" {
+				w.WriteHeader(http.StatusCreated)
+				return
+			}
+			// The blob isn't in the other repository, an upload starts.
+			w.Header().Set("Location", "/v2/foo/blobs/uploads/upload-uuid")
+			w.WriteHeader(http.StatusAccepted)
+		case r.Method == "DELETE" && r.URL.Path == "/v2/foo/blobs/uploads/upload-uuid":
+			cancelled = true
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer srv.Close()
+
+	p := &v2Pusher{
+		endpoint: registry.APIEndpoint{URL: srv.URL},
+		config:   &ImagePushConfig{AuthConfig: &cliconfig.AuthConfig{}},
+		repoName: "foo",
+	}
+	mounted, err := p.tryMount("sha256:abc", "bar")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !mounted {
+		t.Fatal("Expected the blob to be mounted from bar")
+	}
+
+	mounted, err = p.tryMount("sha256:abc", "baz")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if mounted || !cancelled {
+		t.Fatal("Expected the upload started instead of the mount to be cancelled")
+	}
+}
+
+func TestPutSchema2Manifest(t *testing.T) {
+	var status int
+	var body string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "PUT" || r.URL.Path != "/v2/foo/manifests/latest" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		if r.Header.Get("Content-Type") != schema2MediaType {
+			t.Errorf("Unexpected content type %q", r.Header.Get("Content-Type"))
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		w.Write([]byte(body))
+	}))
+	defer srv.Close()
+
+	p := &v2Pusher{
+		endpoint: registry.APIEndpoint{URL: srv.URL},
+		repoName: "foo",
+	}
+	cases := []struct {
+		status      int
+		body        string
+		accepted    bool
+		blobUnknown bool
+	}{
+		{http.StatusCreated, "", true, false},
+		// the registry supports schema2 but misses the config
+		{http.StatusBadRequest, `{"errors":[{"code":"MANIFEST_BLOB_UNKNOWN","message":"blob unknown to registry","detail":"sha256:abc"}]}`, false, true},
+		// the registry only supports schema1
+		{http.StatusBadRequest, `{"errors":[{"code":"MANIFEST_INVALID","message":"manifest invalid"}]}`, false, false},
+		{http.StatusUnsupportedMediaType, "", false, false},
+	}
+	for _, c := range cases {
+		status, body = c.status, c.body
+		accepted, blobUnknown, err := p.putSchema2Manifest("latest", []byte("{}"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if accepted != c.accepted || blobUnknown != c.blobUnknown {
+			t.Fatalf("Expected accepted=%v blobUnknown=%v for %d %s, got %v %v", c.accepted, c.blobUnknown, c.status, c.body, accepted, blobUnknown)
+		}
+	}
+
+	status, body = http.StatusInternalServerError, ""
+	if _, _, err := p.putSchema2Manifest("latest", []byte("{}")); err == nil {
+		t.Fatal("Expected an error for an unexpected status")
+	}
+}
@@ -31,14 +31,26 @@ func (dcs dumbCredentialStore) Basic(*url.URL) (string, string) {
 // providing timeout settings and authentication support, and also verifies the
 // remote API version.
 func NewV2Repository(repoInfo *registry.RepositoryInfo, endpoint registry.APIEndpoint, metaHeaders http.Header, authConfig *cliconfig.AuthConfig, actions ...string) (distribution.Repository, error) {
-	ctx := context.Background()
+	repoName := v2RepoName(repoInfo, endpoint)
+	tr, err := newV2Transport(endpoint, metaHeaders, authConfig, registry.RepositoryScope(repoName, actions...))
+	if err != nil {
+		return nil, err
+	}
+	return client.NewRepository(context.Background(), repoName, endpoint.URL, tr)
+}
 
-	repoName := repoInfo.CanonicalName
+// v2RepoName returns the name of the repository on the endpoint.
+func v2RepoName(repoInfo *registry.RepositoryInfo, endpoint registry.APIEndpoint) string {
 	// If endpoint does not support CanonicalName, use the RemoteName instead
 	if endpoint.TrimHostname {
-		repoName = repoInfo.RemoteName
+		return repoInfo.RemoteName
 	}
+	return repoInfo.CanonicalName
+}
 
+// newV2Transport returns a HTTP transport to the endpoint authenticated for
+// the given token scopes, after verifying the remote API version.
+func newV2Transport(endpoint registry.APIEndpoint, metaHeaders http.Header, authConfig *cliconfig.AuthConfig, scopes ...string) (http.RoundTripper, error) {
 	// TODO(dmcgowan): Call close idle connections when complete, use keep alive
 	base := &http.Transport{
 		Proxy: http.ProxyFromEnvironment,
@@ -90,13 +102,14 @@ func NewV2Repository(repoInfo *registry.RepositoryInfo, endpoint registry.APIEnd
 		return nil, err
 	}
 
+	if endpoint.NoAuth {
+		authConfig = &cliconfig.AuthConfig{}
+	}
 	creds := dumbCredentialStore{auth: authConfig}
-	tokenHandler := auth.NewTokenHandler(authTransport, creds, repoName, actions...)
+	tokenHandler := registry.NewTokenHandler(authTransport, authConfig, scopes...)
 	basicHandler := auth.NewBasicHandler(creds)
 	modifiers = append(modifiers, auth.NewAuthorizer(challengeManager, tokenHandler, basicHandler))
-	tr := transport.NewTransport(base, modifiers...)
-
-	return client.NewRepository(ctx, repoName, endpoint.URL, tr)
+	return transport.NewTransport(base, modifiers...), nil
 }
 
 func digestFromManifest(m *manifest.SignedManifest, localName string) (digest.Digest, int, error) {
@@ -35,14 +35,21 @@ func (s *TagStore) Lookup(name string) (*types.ImageInspect, error) {
 		return nil, fmt.Errorf("No such image: %s", name)
 	}
 
-	var tags = make([]string, 0)
+	var (
+		tags    = make([]string, 0)
+		digests = make([]string, 0)
+	)
 
 	s.Lock()
 	for repoName, repository := range s.Repositories {
 		for ref, id := range repository {
 			if id == image.ID {
 				imgRef := utils.ImageReference(repoName, ref)
-				tags = append(tags, imgRef)
+				if utils.DigestReference(ref) {
+					digests = append(digests, imgRef)
+				} else {
+					tags = append(tags, imgRef)
+				}
 			}
 		}
 	}
@@ -51,6 +58,7 @@ func (s *TagStore) Lookup(name string) (*types.ImageInspect, error) {
 	imageInspect := &types.ImageInspect{
 		ID:              image.ID,
 		Tags:            tags,
+		RepoDigests:     digests,
 		Parent:          image.Parent,
 		Comment:         image.Comment,
 		Created:         image.Created.Format(time.RFC3339Nano),
@@ -33,12 +33,18 @@ type TagStore struct {
 	graph *Graph
 	// Repositories is a map of repositories, indexed by name.
 	Repositories map[string]Repository
+	// PlatformDigests maps the name:tag references pulled for a platform
+	// without changing their tag, see ImagePullConfig.KeepTags, to the
+	// digest reference of the image pulled for each platform.
+	PlatformDigests map[string]map[string]string `json:",omitempty"`
 	trustKey     libtrust.PrivateKey
 	sync.Mutex
 	// FIXME: move push/pull-related fields
 	// to a helper type
 	pullingPool     map[string]*progressreader.Broadcaster
 	pushingPool     map[string]*progressreader.Broadcaster
+	downloadManager *transferManager
+	uploadManager   *transferManager
 	registryService *registry.Service
 	eventsService   *events.Events
 	trustService    *trust.Store
@@ -79,6 +85,12 @@ type TagStoreConfig struct {
 	Events *events.Events
 	// Trust is the trust service to use for push and pull operations.
 	Trust *trust.Store
+	// MaxConcurrentDownloads is the maximum number of layers downloaded
+	// concurrently by pulls. DefaultMaxConcurrentDownloads if not set.
+	MaxConcurrentDownloads int
+	// MaxConcurrentUploads is the maximum number of layers uploaded
+	// concurrently by pushes. DefaultMaxConcurrentUploads if not set.
+	MaxConcurrentUploads int
 }
 
 // NewTagStore creates a new TagStore at specified path, using the parameters
@@ -89,6 +101,14 @@ func NewTagStore(path string, cfg *TagStoreConfig) (*TagStore, error) {
 		return nil, err
 	}
 
+	maxDownloads, maxUploads := cfg.MaxConcurrentDownloads, cfg.MaxConcurrentUploads
+	if maxDownloads <= 0 {
+		maxDownloads = DefaultMaxConcurrentDownloads
+	}
+	if maxUploads <= 0 {
+		maxUploads = DefaultMaxConcurrentUploads
+	}
+
 	store := &TagStore{
 		path:            abspath,
 		graph:           cfg.Graph,
@@ -96,6 +116,8 @@ func NewTagStore(path string, cfg *TagStoreConfig) (*TagStore, error) {
 		Repositories:    make(map[string]Repository),
 		pullingPool:     make(map[string]*progressreader.Broadcaster),
 		pushingPool:     make(map[string]*progressreader.Broadcaster),
+		downloadManager: newTransferManager(maxDownloads),
+		uploadManager:   newTransferManager(maxUploads),
 		registryService: cfg.Registry,
 		eventsService:   cfg.Events,
 		trustService:    cfg.Trust,
@@ -169,6 +191,40 @@ func (store *TagStore) LookupImage(name string) (*image.Image, error) {
 	return img, nil
 }
 
+// CheckDigestPolicy returns an error if name references the image of a
+// registry requiring its images to be referenced by digest by a tag. Image
+// IDs, which reference exact images, are accepted.
+func (store *TagStore) CheckDigestPolicy(name string) error {
+	repoName, ref := parsers.ParseRepositoryTag(name)
+	if utils.DigestReference(ref) {
+		return nil
+	}
+	if ref == "" {
+		// As in LookupImage, name is an image ID if it isn't a repository.
+		if img, err := store.GetImage(repoName, tags.DefaultTag); err == nil && img == nil {
+			if _, err := store.graph.Get(name); err == nil {
+				return nil
+			}
+		}
+	}
+
+	repoInfo, err := store.registryService.ResolveRepository(repoName)
+	if err != nil {
+		// Let the lookup of the image report invalid names.
+		return nil
+	}
+	if repoInfo.Index.RequireDigest {
+		return errDigestRequired(repoInfo)
+	}
+	return nil
+}
+
+// errDigestRequired returns the error for a reference by tag to an image of
+// a registry requiring its images to be referenced by digest.
+func errDigestRequired(repoInfo *registry.RepositoryInfo) error {
+	return fmt.Errorf("images of %s must be referenced by digest, e.g. %s@sha256:<digest>", repoInfo.Index.Name, repoInfo.LocalName)
+}
+
 // ByID returns a reverse-lookup table of all the names which refer to each
 // image - e.g. {"43b5f19b10584": {"base:latest", "base:v1"}}
 func (store *TagStore) ByID() map[string][]string {
@@ -352,6 +408,51 @@ func (store *TagStore) SetDigest(repoName, digest, imageName string) error {
 	return store.save()
 }
 
+// platformReference returns the name:tag reference keying PlatformDigests
+// for the given image name.
+func platformReference(name string) string {
+	repoName, tag := parsers.ParseRepositoryTag(name)
+	if tag == "" {
+		tag = tags.DefaultTag
+	}
+	return utils.ImageReference(registry.NormalizeLocalName(repoName), tag)
+}
+
+// SetPlatformDigest records the digest of the image pulled for the given
+// platform by the image name, which references a tag.
+func (store *TagStore) SetPlatformDigest(name, platform, digest string) error {
+	if err := validateDigest(digest); err != nil {
+		return err
+	}
+
+	store.Lock()
+	defer store.Unlock()
+	if err := store.reload(); err != nil {
+		return err
+	}
+
+	ref := platformReference(name)
+	if store.PlatformDigests == nil {
+		store.PlatformDigests = make(map[string]map[string]string)
+	}
+	if store.PlatformDigests[ref] == nil {
+		store.PlatformDigests[ref] = make(map[string]string)
+	}
+	store.PlatformDigests[ref][platform] = digest
+	return store.save()
+}
+
+// PlatformDigest returns the digest of the image last pulled for the given
+// platform by the image name, or an empty string if there is none.
+func (store *TagStore) PlatformDigest(name, platform string) string {
+	store.Lock()
+	defer store.Unlock()
+	if err := store.reload(); err != nil {
+		return ""
+	}
+	return store.PlatformDigests[platformReference(name)][platform]
+}
+
 // Get returns the Repository tag/image map for a given repository.
 func (store *TagStore) Get(repoName string) (Repository, error) {
 	store.Lock()
@@ -210,3 +210,40 @@ func TestValidateDigest(t *testing.T) {
 		}
 	}
 }
+
+func TestPlatformDigest(t *testing.T) {
+	tmp, err := utils.TestDirectory("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmp)
+	store := mkTestTagStore(tmp, t)
+	defer store.graph.driver.Cleanup()
+
+	if dgst := store.PlatformDigest(testPrivateImageName, "linux/arm"); dgst != "" {
+		t.Fatalf("Expected no digest, got %s", dgst)
+	}
+	if err := store.SetPlatformDigest(testPrivateImageName, "linux/arm", "fail"); err == nil {
+		t.Fatal("Expected an error for an invalid digest")
+	}
+	if err := store.SetPlatformDigest(testPrivateImageName+":"+tags.DefaultTag, "linux/arm", testPrivateImageDigest); err != nil {
+		t.Fatal(err)
+	}
+
+	// the default tag is implied, and the record survives a reload
+	if err := store.reload(); err != nil {
+		t.Fatal(err)
+	}
+	if dgst := store.PlatformDigest(testPrivateImageName, "linux/arm"); dgst != testPrivateImageDigest {
+		t.Fatalf("Expected digest %s, got %s", testPrivateImageDigest, dgst)
+	}
+	if dgst := store.PlatformDigest(testPrivateImageName, "linux/ppc64le"); dgst != "" {
+		t.Fatalf("Expected no digest for another platform, got %s", dgst)
+	}
+	if dgst := store.PlatformDigest(testPrivateImageName+":other", "linux/arm"); dgst != "" {
+		t.Fatalf("Expected no digest for another tag, got %s", dgst)
+	}
+	if img, _ := store.LookupImage(testPrivateImageName); img == nil || img.ID != testPrivateImageID {
+		t.Fatal("Expected the tag to be unchanged")
+	}
+}
@@ -0,0 +1,123 @@
+package graph
+
+import (
+	"io"
+	"os"
+	"sync"
+
+	"github.com/docker/distribution/digest"
+	"github.com/docker/docker/pkg/progressreader"
+)
+
+const (
+	// DefaultMaxConcurrentDownloads is the default number of layers
+	// downloaded concurrently by pulls.
+	DefaultMaxConcurrentDownloads = 3
+	// DefaultMaxConcurrentUploads is the default number of layers uploaded
+	// concurrently by pushes.
+	DefaultMaxConcurrentUploads = 5
+)
+
+// transferManager runs the layer transfers between the daemon and the
+// registries. It bounds the number of transfers running concurrently, and
+// lets the concurrent requests for the same transfer attach to it, sharing
+// its progress and result.
+type transferManager struct {
+	sync.Mutex
+	transfers map[string]*transfer
+	// slots holds a token for each running transfer.
+	slots chan struct{}
+}
+
+// transfer is a transfer in flight, or done but still watched. Its
+// broadcaster carries its progress and is closed with its result.
+type transfer struct {
+	*progressreader.Broadcaster
+	manager  *transferManager
+	key      string
+	watchers int
+
+	// file is the blob downloaded by a download.
+	file *os.File
+	// size is the size of the blob downloaded or uploaded.
+	size int64
+	// digest is the blob uploaded by an upload.
+	digest digest.Digest
+	// cleanup, if set, is called with the result of the transfer once it
+	// has no watchers left.
+	cleanup func(err error)
+}
+
+// transferFunc runs a transfer, writing its progress to t and setting its
+// result in t.
+type transferFunc func(t *transfer) error
+
+func newTransferManager(maxConcurrent int) *transferManager {
+	return &transferManager{
+		transfers: make(map[string]*transfer),
+		slots:     make(chan struct{}, maxConcurrent),
+	}
+}
+
+// transfer returns the transfer with the given key, running xfer once a
+// slot is free if it isn't in flight. The progress of the transfer is
+// written to out. The caller must call release when done with the result
+// of the transfer.
+func (tm *transferManager) transfer(key string, out io.Writer, xfer transferFunc) *transfer {
+	tm.Lock()
+	defer tm.Unlock()
+
+	t, ok := tm.transfers[key]
+	if !ok {
+		t = &transfer{
+			Broadcaster: progressreader.NewBroadcaster(),
+			manager:     tm,
+			key:         key,
+		}
+		tm.transfers[key] = t
+		go tm.run(t, xfer)
+	}
+	t.watchers++
+	// Adding an observer fails if the transfer is done, in which case
+	// there's no progress left to report.
+	t.Add(out)
+	return t
+}
+
+func (tm *transferManager) run(t *transfer, xfer transferFunc) {
+	tm.slots <- struct{}{}
+	err := xfer(t)
+	<-tm.slots
+
+	if err != nil {
+		// Let the next requests retry a failed transfer.
+		tm.Lock()
+		if tm.transfers[t.key] == t {
+			delete(tm.transfers, t.key)
+		}
+		tm.Unlock()
+	}
+	t.CloseWithError(err)
+}
+
+// release stops watching the transfer. The transfer is forgotten and
+// cleaned up once it has no watchers left.
+func (t *transfer) release() {
+	tm := t.manager
+	tm.Lock()
+	t.watchers--
+	last := t.watchers == 0
+	if last && tm.transfers[t.key] == t {
+		delete(tm.transfers, t.key)
+	}
+	tm.Unlock()
+
+	if last {
+		go func() {
+			err := t.Wait()
+			if t.cleanup != nil {
+				t.cleanup(err)
+			}
+		}()
+	}
+}
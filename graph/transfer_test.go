@@ -0,0 +1,105 @@
+package graph
+
+import (
+	"errors"
+	"io/ioutil"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestTransferManagerConcurrency(t *testing.T) {
+	tm := newTransferManager(2)
+
+	var (
+		mu               sync.Mutex
+		running, maxSeen int
+	)
+	xfer := func(*transfer) error {
+		mu.Lock()
+		running++
+		if running > maxSeen {
+			maxSeen = running
+		}
+		mu.Unlock()
+		time.Sleep(10 * time.Millisecond)
+		mu.Lock()
+		running--
+		mu.Unlock()
+		return nil
+	}
+
+	var transfers []*transfer
+	for _, key := range []string{"a", "b", "c", "d", "e"} {
+		transfers = append(transfers, tm.transfer(key, ioutil.Discard, xfer))
+	}
+	for _, xf := range transfers {
+		if err := xf.Wait(); err != nil {
+			t.Fatal(err)
+		}
+		xf.release()
+	}
+	if maxSeen != 2 {
+		t.Fatalf("Expected 2 concurrent transfers at most, got %d", maxSeen)
+	}
+}
+
+func TestTransferManagerSharedTransfer(t *testing.T) {
+	tm := newTransferManager(1)
+
+	start := make(chan struct{})
+	var runs int
+	cleanups := make(chan error, 1)
+	xfer := func(xf *transfer) error {
+		<-start
+		runs++
+		xf.Write([]byte("progress"))
+		xf.size = 42
+		xf.cleanup = func(err error) { cleanups <- err }
+		return nil
+	}
+
+	t1 := tm.transfer("a", ioutil.Discard, xfer)
+	t2 := tm.transfer("a", ioutil.Discard, xfer)
+	if t1 != t2 {
+		t.Fatal("Expected requests for the same key to share the transfer")
+	}
+	close(start)
+	if err := t2.Wait(); err != nil {
+		t.Fatal(err)
+	}
+	if runs != 1 || t2.size != 42 {
+		t.Fatalf("Expected a single run of the transfer, got %d", runs)
+	}
+
+	t1.release()
+	select {
+	case <-cleanups:
+		t.Fatal("Transfer cleaned up while still watched")
+	case <-time.After(10 * time.Millisecond):
+	}
+	t2.release()
+	if err := <-cleanups; err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestTransferManagerRetryFailedTransfer(t *testing.T) {
+	tm := newTransferManager(1)
+
+	failure := errors.New("failure")
+	t1 := tm.transfer("a", ioutil.Discard, func(*transfer) error { return failure })
+	if err := t1.Wait(); err != failure {
+		t.Fatalf("Expected the transfer to fail, got %v", err)
+	}
+
+	t2 := tm.transfer("a", ioutil.Discard, func(*transfer) error { return nil })
+	if t1 == t2 {
+		t.Fatal("Expected a new transfer after a failed one")
+	}
+	if err := t2.Wait(); err != nil {
+		t.Fatal(err)
+	}
+	t1.release()
+	t2.release()
+}
@@ -107,6 +107,9 @@ if [ -z "$DOCKER_CLIENTONLY" ]; then
 	if pkg-config libsystemd-journal 2> /dev/null ; then
 		DOCKER_BUILDTAGS+=" journald"
 	fi
+	if pkg-config --atleast-version=2.1.0 libseccomp 2> /dev/null ; then
+		DOCKER_BUILDTAGS+=" seccomp"
+	fi
 fi
 
 if [ "$DOCKER_EXECDRIVER" = 'lxc' ]; then
@@ -18,6 +18,7 @@ import (
 	"text/template"
 	"time"
 
+	"github.com/docker/docker/api/types"
 	"github.com/docker/docker/builder/command"
 	"github.com/docker/docker/pkg/archive"
 	"github.com/docker/docker/pkg/stringutils"
@@ -5674,6 +5675,31 @@ func (s *DockerSuite) TestBuildStopSignal(c *check.C) {
 	}
 }
 
+func (s *DockerSuite) TestBuildStopTimeout(c *check.C) {
+	testRequires(c, DaemonIsLinux)
+	name := "test_build_stop_timeout"
+	_, err := buildImage(name,
+		`FROM busybox
+		 STOPTIMEOUT 30`,
+		true)
+	c.Assert(err, check.IsNil)
+	res, err := inspectFieldJSON(name, "Config.StopTimeout")
+	c.Assert(err, check.IsNil)
+	c.Assert(res, check.Equals, "30")
+
+	// Containers inherit the stop timeout of their image.
+	dockerCmd(c, "create", "--name", "test_stop_timeout", name)
+	res, err = inspectFieldJSON("test_stop_timeout", "Config.StopTimeout")
+	c.Assert(err, check.IsNil)
+	c.Assert(res, check.Equals, "30")
+
+	_, err = buildImage(name,
+		`FROM busybox
+		 STOPTIMEOUT forever`,
+		true)
+	c.Assert(err, check.NotNil)
+}
+
 func (s *DockerSuite) TestBuildBuildTimeArg(c *check.C) {
 	testRequires(c, DaemonIsLinux)
 	imgName := "bldargtest"
@@ -6194,3 +6220,198 @@ func (s *DockerSuite) TestBuildBuildTimeArgDefintionWithNoEnvInjection(c *check.
 		c.Fatalf("unexpected number of occurrences of the arg in output: %q expected: 1", out)
 	}
 }
+
+func (s *DockerSuite) TestBuildProgressJSON(c *check.C) {
+	testRequires(c, DaemonIsLinux)
+	name := "testbuildprogressjson"
+	dockerfile := `FROM busybox
+		RUN echo foo`
+
+	if _, err := buildImage(name, dockerfile, true); err != nil {
+		c.Fatal(err)
+	}
+	id, stdout, _, err := buildImageWithStdoutStderr(name, dockerfile, true, "--progress=json")
+	if err != nil {
+		c.Fatalf("build failed to complete: %q %q", stdout, err)
+	}
+
+	var steps []types.BuildStepProgress
+	var context *types.BuildContextProgress
+	for _, line := range strings.Split(strings.TrimSpace(stdout), "\n") {
+		var msg struct {
+			Aux *types.BuildProgress `json:"aux"`
+		}
+		if err := json.Unmarshal([]byte(line), &msg); err != nil {
+			c.Fatalf("invalid json line %q: %v", line, err)
+		}
+		if msg.Aux == nil {
+			continue
+		}
+		if msg.Aux.Context != nil {
+			context = msg.Aux.Context
+		}
+		if msg.Aux.Step != nil {
+			steps = append(steps, *msg.Aux.Step)
+		}
+	}
+
+	if context == nil || context.Size == 0 {
+		c.Fatalf("expected context progress, got %v", context)
+	}
+	if len(steps) != 2 {
+		c.Fatalf("expected 2 steps, got %d: %v", len(steps), steps)
+	}
+	run := steps[1]
+	if run.Step != 2 || run.Instruction != "RUN echo foo" || !run.CacheHit {
+		c.Fatalf("unexpected progress for step 2: %+v", run)
+	}
+	if run.ImageID != id {
+		c.Fatalf("expected image %s for step 2, got %s", id, run.ImageID)
+	}
+	if run.End.Before(run.Start) {
+		c.Fatalf("step ended before it started: %+v", run)
+	}
+}
+
+func (s *DockerSuite) TestBuildCheck(c *check.C) {
+	ctx, err := fakeContext(`FROM busybox
+MAINTAINER someone
+ARG unused
+RUN ['echo', 'hello']`, nil)
+	if err != nil {
+		c.Fatal(err)
+	}
+	defer ctx.Close()
+
+	out, exitCode, err := dockerCmdInDir(c, ctx.Dir, "build", "--check", ".")
+	if err == nil || exitCode != 1 {
+		c.Fatalf("expected the check to fail with status 1, got %d: %s", exitCode, out)
+	}
+	for _, expected := range []string{
+		"Dockerfile:1: FROM busybox does not specify a tag, the latest tag will be used (from-without-tag)",
+		"Dockerfile:2: MAINTAINER is a candidate for deprecation, use a LABEL instead (maintainer-deprecated)",
+		"Dockerfile:3: ARG unused is never used (unused-arg)",
+		"Dockerfile:4: RUN arguments look like a JSON array but are not valid JSON",
+	} {
+		if !strings.Contains(out, expected) {
+			c.Fatalf("expected %q in the output: %s", expected, out)
+		}
+	}
+
+	if err := ctx.Add("Dockerfile", "FROM busybox:latest\nRUN echo hello"); err != nil {
+		c.Fatal(err)
+	}
+	out, _, err = dockerCmdInDir(c, ctx.Dir, "build", "--check", ".")
+	if err != nil || strings.TrimSpace(out) != "" {
+		c.Fatalf("expected no warnings, got: %s", out)
+	}
+}
+
+func (s *DockerSuite) TestBuildErrorLineNumbers(c *check.C) {
+	testRequires(c, DaemonIsLinux)
+	name := "testbuilderrorlinenumbers"
+
+	_, out, err := buildImageWithOut(name, `FROM busybox
+RUN true
+RUN echo first && \
+    false`, false)
+	if err == nil {
+		c.Fatal("expected the build to fail")
+	}
+	if !strings.Contains(out, "Dockerfile:3-4: The command '/bin/sh -c echo first") {
+		c.Fatalf("expected the error to include the lines of the failed instruction: %s", out)
+	}
+
+	if _, err := buildImage(name, `FROM busybox
+ONBUILD RUN false`, false); err != nil {
+		c.Fatal(err)
+	}
+	_, out, err = buildImageWithOut(name+"-child", "FROM "+name, false)
+	if err == nil {
+		c.Fatal("expected the build to fail")
+	}
+	if !strings.Contains(out, "Trigger 1 from "+name+" : RUN false") {
+		c.Fatalf("expected the trigger step to name its image: %s", out)
+	}
+	if !strings.Contains(out, `Dockerfile:1: ONBUILD trigger "RUN false" from `+name+": The command '/bin/sh -c false' returned a non-zero code: 1") {
+		c.Fatalf("expected the error to name the failed trigger: %s", out)
+	}
+}
+
+func (s *DockerSuite) TestBuildPlatform(c *check.C) {
+	testRequires(c, DaemonIsLinux)
+	name := "testbuildplatform"
+
+	_, err := buildImage(name, `FROM scratch
+LABEL foo=bar`, true, "--platform=linux/aarch64")
+	if err != nil {
+		c.Fatal(err)
+	}
+
+	platform, err := inspectField(name, "Os")
+	c.Assert(err, check.IsNil)
+	arch, err := inspectField(name, "Architecture")
+	c.Assert(err, check.IsNil)
+	if platform+"/"+arch != "linux/arm64" {
+		c.Fatalf("expected the image to be for linux/arm64, got %s/%s", platform, arch)
+	}
+
+	if _, err := buildImage(name, "FROM scratch", true, "--platform=linux"); err == nil {
+		c.Fatal("expected the build to fail with an invalid platform")
+	}
+}
+
+func (s *DockerSuite) TestBuildIncrementalContext(c *check.C) {
+	testRequires(c, DaemonIsLinux)
+	name := "testbuildincrementalcontext"
+
+	ctx, err := fakeContext(`FROM busybox
+COPY . /ctx`, map[string]string{
+		"unchanged":   "unchanged",
+		"changed":     "old",
+		"removed":     "removed",
+		"dir/removed": "removed",
+	})
+	c.Assert(err, check.IsNil)
+	defer ctx.Close()
+
+	if _, err := buildImageFromContext(name, ctx, false, "--incremental"); err != nil {
+		c.Fatal(err)
+	}
+
+	// The second build only sends the changes, which the daemon applies to
+	// the context it kept from the first one.
+	c.Assert(ctx.Add("changed", "new"), check.IsNil)
+	c.Assert(ctx.Add("added", "added"), check.IsNil)
+	c.Assert(ctx.Delete("removed"), check.IsNil)
+	c.Assert(ctx.Delete("dir"), check.IsNil)
+	c.Assert(ctx.Add("Dockerfile", `FROM busybox
+COPY . /ctx
+RUN [ "$(cat /ctx/unchanged)" = unchanged ] && [ "$(cat /ctx/changed)" = new ] && [ "$(cat /ctx/added)" = added ]
+RUN [ ! -e /ctx/removed ] && [ ! -e /ctx/dir ]`), check.IsNil)
+
+	if _, err := buildImageFromContext(name, ctx, false, "--incremental"); err != nil {
+		c.Fatal(err)
+	}
+}
+
+func (s *DockerSuite) TestBuildExitCodeOfFailedRun(c *check.C) {
+	testRequires(c, DaemonIsLinux)
+	name := "testbuildexitcodeoffailedrun"
+
+	out, exitCode, err := runCommandWithOutput(buildImageCmd(name, `FROM busybox
+RUN exit 3`, true))
+	if err == nil || exitCode != 3 {
+		c.Fatalf("expected the build to fail with the status of RUN, got %d: %s", exitCode, out)
+	}
+
+	// the same goes for a RUN run by an ONBUILD trigger
+	if _, err := buildImage(name, `FROM busybox
+ONBUILD RUN exit 4`, true); err != nil {
+		c.Fatal(err)
+	}
+	out, exitCode, err = runCommandWithOutput(buildImageCmd(name+"child", "FROM "+name, true))
+	if err == nil || exitCode != 4 {
+		c.Fatalf("expected the build to fail with the status of the trigger, got %d: %s", exitCode, out)
+	}
+}
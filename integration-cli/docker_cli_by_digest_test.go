@@ -337,15 +337,11 @@ func (s *DockerRegistrySuite) TestListImagesWithDigests(c *check.C) {
 	// list images
 	out, _ = dockerCmd(c, "images", "--digests")
 
-	// make sure image 1 has repo, tag, <none> AND repo, <none>, digest
-	reWithTag1 := regexp.MustCompile(`\s*` + repoName + `\s*tag1\s*<none>\s`)
-	reWithDigest1 := regexp.MustCompile(`\s*` + repoName + `\s*<none>\s*` + digest1.String() + `\s`)
+	// make sure image 1 has repo, tag, digest on the same line
+	reWithTag1 := regexp.MustCompile(`\s*` + repoName + `\s*tag1\s*` + digest1.String() + `\s`)
 	if !reWithTag1.MatchString(out) {
 		c.Fatalf("expected %q: %s", reWithTag1.String(), out)
 	}
-	if !reWithDigest1.MatchString(out) {
-		c.Fatalf("expected %q: %s", reWithDigest1.String(), out)
-	}
 	// make sure image 2 has repo, <none>, digest
 	if !re2.MatchString(out) {
 		c.Fatalf("expected %q: %s", re2.String(), out)
@@ -363,14 +359,10 @@ func (s *DockerRegistrySuite) TestListImagesWithDigests(c *check.C) {
 	}
 
 	// make sure image 2 has repo, tag, digest
-	reWithTag2 := regexp.MustCompile(`\s*` + repoName + `\s*tag2\s*<none>\s`)
-	reWithDigest2 := regexp.MustCompile(`\s*` + repoName + `\s*<none>\s*` + digest2.String() + `\s`)
+	reWithTag2 := regexp.MustCompile(`\s*` + repoName + `\s*tag2\s*` + digest2.String() + `\s`)
 	if !reWithTag2.MatchString(out) {
 		c.Fatalf("expected %q: %s", reWithTag2.String(), out)
 	}
-	if !reWithDigest2.MatchString(out) {
-		c.Fatalf("expected %q: %s", reWithDigest2.String(), out)
-	}
 
 	// list images
 	out, _ = dockerCmd(c, "images", "--digests")
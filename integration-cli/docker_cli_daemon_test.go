@@ -1713,3 +1713,81 @@ func (s *DockerDaemonSuite) TestDaemonCorruptedFluentdAddress(c *check.C) {
 		c.Fatalf("Expected %q message; but doesn't exist in log: %q, err: %v", expected, out, err)
 	}
 }
+
+func (s *DockerDaemonSuite) TestDaemonRequireDigest(c *check.C) {
+	c.Assert(s.d.StartWithBusybox("--require-digest", "docker.io"), check.IsNil)
+
+	out, err := s.d.Cmd("run", "busybox", "true")
+	c.Assert(err, check.NotNil, check.Commentf("run by tag should be rejected: %s", out))
+	if !strings.Contains(out, "must be referenced by digest") {
+		c.Fatalf("Expected a digest policy error, got %s", out)
+	}
+
+	out, err = s.d.Cmd("pull", "busybox:latest")
+	c.Assert(err, check.NotNil, check.Commentf("pull by tag should be rejected: %s", out))
+	if !strings.Contains(out, "must be referenced by digest") {
+		c.Fatalf("Expected a digest policy error, got %s", out)
+	}
+
+	id, err := s.d.Cmd("inspect", "-f", "{{.Id}}", "busybox")
+	c.Assert(err, check.IsNil, check.Commentf(id))
+	out, err = s.d.Cmd("run", "--rm", strings.TrimSpace(id), "true")
+	c.Assert(err, check.IsNil, check.Commentf("run by image ID should be allowed: %s", out))
+}
+
+func (s *DockerDaemonSuite) TestDaemonLiveRestore(c *check.C) {
+	c.Assert(s.d.StartWithBusybox("--live-restore"), check.IsNil)
+
+	out, err := s.d.Cmd("run", "-d", "--net=none", "--name", "top", "busybox", "top")
+	c.Assert(err, check.IsNil, check.Commentf(out))
+	pid, err := s.d.Cmd("inspect", "-f", "{{.State.Pid}}", "top")
+	c.Assert(err, check.IsNil, check.Commentf(pid))
+	out, err = s.d.Cmd("run", "-d", "--net=bridge", "--name", "bridged", "busybox", "top")
+	c.Assert(err, check.IsNil, check.Commentf(out))
+
+	c.Assert(s.d.Restart("--live-restore"), check.IsNil)
+
+	out, err = s.d.Cmd("inspect", "-f", "{{.State.Running}} {{.State.Pid}}", "top")
+	c.Assert(err, check.IsNil, check.Commentf(out))
+	c.Assert(strings.TrimSpace(out), check.Equals, "true "+strings.TrimSpace(pid), check.Commentf("container should have kept running across the daemon restart"))
+	out, err = s.d.Cmd("inspect", "-f", "{{.State.Running}}", "bridged")
+	c.Assert(err, check.IsNil, check.Commentf(out))
+	c.Assert(strings.TrimSpace(out), check.Equals, "false", check.Commentf("containers on the bridge network should not be kept running"))
+
+	out, err = s.d.Cmd("stop", "top")
+	c.Assert(err, check.IsNil, check.Commentf(out))
+	out, err = s.d.Cmd("inspect", "-f", "{{.State.Running}} {{.State.ExitCode}}", "top")
+	c.Assert(err, check.IsNil, check.Commentf(out))
+	c.Assert(strings.TrimSpace(out), check.Equals, "false 137")
+}
+
+func (s *DockerDaemonSuite) TestDaemonLiveRestoreStdinAndExitCode(c *check.C) {
+	c.Assert(s.d.StartWithBusybox("--live-restore"), check.IsNil)
+
+	out, err := s.d.Cmd("run", "-di", "--net=host", "--name", "read", "busybox", "sh", "-c", "read code; exit $code")
+	c.Assert(err, check.IsNil, check.Commentf(out))
+
+	c.Assert(s.d.Restart("--live-restore"), check.IsNil)
+
+	attachCmd := exec.Command(dockerBinary, "--host", s.d.sock(), "attach", "read")
+	attachCmd.Stdin = strings.NewReader("3\n")
+	out, exitCode, _ := runCommandWithOutput(attachCmd)
+	c.Assert(exitCode, check.Equals, 3, check.Commentf(out))
+
+	out, err = s.d.Cmd("wait", "read")
+	c.Assert(err, check.IsNil, check.Commentf(out))
+	c.Assert(strings.TrimSpace(out), check.Equals, "3", check.Commentf("the exit code of the restored container should be kept"))
+}
+
+func (s *DockerDaemonSuite) TestDaemonLiveRestoreTty(c *check.C) {
+	c.Assert(s.d.StartWithBusybox("--live-restore"), check.IsNil)
+
+	out, err := s.d.Cmd("run", "-d", "-t", "--net=none", "--name", "top", "busybox", "top")
+	c.Assert(err, check.IsNil, check.Commentf(out))
+
+	c.Assert(s.d.Restart("--live-restore"), check.IsNil)
+
+	out, err = s.d.Cmd("inspect", "-f", "{{.State.Running}}", "top")
+	c.Assert(err, check.IsNil, check.Commentf(out))
+	c.Assert(strings.TrimSpace(out), check.Equals, "false", check.Commentf("containers with a tty should not be kept running"))
+}
@@ -72,3 +72,12 @@ func (s *DockerSuite) TestExecTTY(c *check.C) {
 	c.Assert(err, check.IsNil)
 	c.Assert(bytes.Contains(buf, []byte("hello")), check.Equals, true, check.Commentf(string(buf[:read])))
 }
+
+func (s *DockerSuite) TestExecWithPidsLimit(c *check.C) {
+	testRequires(c, DaemonIsLinux, pidsLimit)
+	dockerCmd(c, "run", "-d", "--pids-limit", "4", "--name", "pids", "busybox", "top")
+
+	// The exec'd processes count against the limit of the container.
+	out, _, err := dockerCmdWithError("exec", "pids", "sh", "-c", "for i in 1 2 3 4 5 6; do sleep 10 & done; wait")
+	c.Assert(err, check.NotNil, check.Commentf("Expected forking beyond the pids limit to fail: %s", out))
+}
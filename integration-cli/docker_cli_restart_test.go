@@ -1,6 +1,7 @@
 package main
 
 import (
+	"fmt"
 	"strings"
 
 	"github.com/go-check/check"
@@ -147,3 +148,33 @@ func (s *DockerSuite) TestContainerRestartwithGoodContainer(c *check.C) {
 	}
 
 }
+
+// a failing container with --restart=on-failure:2 records why it was restarted
+func (s *DockerSuite) TestContainerRestartReason(c *check.C) {
+	testRequires(c, DaemonIsLinux)
+	out, _ := dockerCmd(c, "run", "-d", "--restart=on-failure:2", "--restart-max-delay=500ms", "busybox", "sh", "-c", "exit 3")
+
+	id := strings.TrimSpace(string(out))
+	if err := waitInspect(id, "{{ .State.Restarting }} {{ .State.Running }}", "false false", 10); err != nil {
+		c.Fatal(err)
+	}
+	reason, err := inspectField(id, "RestartReason")
+	c.Assert(err, check.IsNil)
+	if reason != "exit status 3" {
+		c.Fatalf("Container restart reason is %q, expected %q", reason, "exit status 3")
+	}
+
+	out, _ = dockerCmd(c, "events", "--since=0", fmt.Sprintf("--until=%d", daemonTime(c).Unix()), "--filter", "container="+id, "--filter", "event=restart")
+	if restarts := strings.Count(out, " restart (reason=exit status 3)"); restarts != 2 {
+		c.Fatalf("Expected 2 restart events with their reason, got %d: %s", restarts, out)
+	}
+}
+
+func (s *DockerSuite) TestContainerRestartNegativeMaxDelay(c *check.C) {
+	testRequires(c, DaemonIsLinux)
+	out, _, err := dockerCmdWithError("run", "--restart=always", "--restart-max-delay=-1s", "busybox", "true")
+	c.Assert(err, check.NotNil)
+	if !strings.Contains(out, "maximum delay -1s can not be negative") {
+		c.Fatalf("Expected an error about the negative maximum delay, got %s", out)
+	}
+}
@@ -2039,6 +2039,61 @@ func (s *DockerSuite) TestRunModeIpcContainerNotRunning(c *check.C) {
 	}
 }
 
+func (s *DockerSuite) TestRunModeIpcContainerPrivate(c *check.C) {
+	testRequires(c, SameHostDaemon, DaemonIsLinux)
+
+	out, _ := dockerCmd(c, "run", "-d", "--ipc=private", "busybox", "top")
+	id := strings.TrimSpace(out)
+	out, _, err := dockerCmdWithError("run", fmt.Sprintf("--ipc=container:%s", id), "busybox", "true")
+	if err == nil || !strings.Contains(out, "private") {
+		c.Fatalf("Run container with ipc mode container should fail with a private IPC container: %s\n%v", out, err)
+	}
+}
+
+func (s *DockerSuite) TestRunModeIpcContainerHostOrJoined(c *check.C) {
+	testRequires(c, SameHostDaemon, DaemonIsLinux)
+
+	out, _ := dockerCmd(c, "run", "-d", "--ipc=host", "busybox", "top")
+	hostID := strings.TrimSpace(out)
+	c.Assert(waitRun(hostID), check.IsNil)
+	dockerCmd(c, "run", fmt.Sprintf("--ipc=container:%s", hostID), "busybox", "true")
+
+	out, _ = dockerCmd(c, "run", "-d", "busybox", "top")
+	id := strings.TrimSpace(out)
+	c.Assert(waitRun(id), check.IsNil)
+	out, _ = dockerCmd(c, "run", "-d", fmt.Sprintf("--ipc=container:%s", id), "busybox", "top")
+	joinedID := strings.TrimSpace(out)
+	c.Assert(waitRun(joinedID), check.IsNil)
+	dockerCmd(c, "run", fmt.Sprintf("--ipc=container:%s", joinedID), "busybox", "true")
+}
+
+func (s *DockerSuite) TestRunModeIpcContainerShm(c *check.C) {
+	testRequires(c, SameHostDaemon, DaemonIsLinux, NativeExecDriver)
+
+	out, _ := dockerCmd(c, "run", "-d", "--ipc=shareable", "busybox", "sh", "-c", "echo test > /dev/shm/test && top")
+	id := strings.TrimSpace(out)
+	c.Assert(waitRun(id), check.IsNil)
+
+	out, _ = dockerCmd(c, "run", fmt.Sprintf("--ipc=container:%s", id), "busybox", "cat", "/dev/shm/test")
+	if strings.TrimSpace(out) != "test" {
+		c.Fatalf("Expected the /dev/shm of the container to be shared, got %q", out)
+	}
+}
+
+func (s *DockerSuite) TestRunWithShmSize(c *check.C) {
+	testRequires(c, DaemonIsLinux, NativeExecDriver)
+
+	out, _ := dockerCmd(c, "run", "--shm-size=1G", "busybox", "grep", "/dev/shm", "/proc/self/mounts")
+	if !strings.Contains(out, "size=1048576k") {
+		c.Fatalf("Expected a 1G /dev/shm, got %s", out)
+	}
+
+	out, _ = dockerCmd(c, "run", "busybox", "grep", "/dev/shm", "/proc/self/mounts")
+	if !strings.Contains(out, "size=65536k") {
+		c.Fatalf("Expected a 64MB /dev/shm by default, got %s", out)
+	}
+}
+
 func (s *DockerSuite) TestContainerNetworkMode(c *check.C) {
 	testRequires(c, SameHostDaemon, DaemonIsLinux)
 
@@ -3126,3 +3181,29 @@ func (s *DockerSuite) TestTwoContainersInNetHost(c *check.C) {
 	dockerCmd(c, "stop", "first")
 	dockerCmd(c, "stop", "second")
 }
+
+func (s *DockerSuite) TestRunTmpfsMounts(c *check.C) {
+	testRequires(c, NativeExecDriver, DaemonIsLinux)
+
+	out, _ := dockerCmd(c, "run", "--read-only", "--tmpfs", "/run:size=1m,mode=1777", "busybox", "sh", "-c", "touch /run/somefile && stat -c %a /run && grep /run /proc/mounts")
+	if !strings.Contains(out, "1777") || !strings.Contains(out, "tmpfs /run tmpfs") || !strings.Contains(out, "size=1024k") {
+		c.Fatalf("Expected /run to be a 1m tmpfs with mode 1777, got %s", out)
+	}
+
+	if _, _, err := dockerCmdWithError("run", "--tmpfs", "/run:foo=bar", "busybox", "true"); err == nil {
+		c.Fatal("Expected an error running a container with an invalid tmpfs option")
+	}
+}
+
+func (s *DockerSuite) TestRunTmpfsMountsNotCommitted(c *check.C) {
+	testRequires(c, NativeExecDriver, DaemonIsLinux)
+	name := "tmpfscommit"
+	dockerCmd(c, "run", "--name", name, "--tmpfs", "/data", "busybox", "sh", "-c", "echo test > /data/file")
+
+	out, _ := dockerCmd(c, "commit", name)
+	imageID := strings.TrimSpace(out)
+	out, _, err := dockerCmdWithError("run", "--rm", imageID, "cat", "/data/file")
+	if err == nil {
+		c.Fatalf("Expected the tmpfs contents not to be committed, got %s", out)
+	}
+}
@@ -12,6 +12,7 @@ import (
 	"strings"
 	"time"
 
+	"github.com/docker/docker/pkg/integration/checker"
 	"github.com/docker/docker/pkg/mount"
 	"github.com/go-check/check"
 	"github.com/kr/pty"
@@ -214,6 +215,107 @@ func (s *DockerSuite) TestRunWithKernelMemory(c *check.C) {
 	}
 }
 
+func (s *DockerSuite) TestRunWithPidsLimit(c *check.C) {
+	testRequires(c, pidsLimit)
+
+	out, _ := dockerCmd(c, "run", "--pids-limit", "4", "--name", "pids", "busybox", "cat", "/sys/fs/cgroup/pids/pids.max")
+	c.Assert(strings.TrimSpace(out), check.Equals, "4")
+
+	out, err := inspectField("pids", "HostConfig.PidsLimit")
+	c.Assert(err, check.IsNil)
+	c.Assert(out, check.Equals, "4")
+
+	// A fork bomb can't start more processes than the limit.
+	out, _, err = dockerCmdWithError("run", "--pids-limit", "4", "busybox", "sh", "-c", "for i in 1 2 3 4 5 6; do sleep 10 & done; wait")
+	c.Assert(err, check.NotNil, check.Commentf("Expected forking beyond the pids limit to fail: %s", out))
+}
+
+func (s *DockerSuite) TestRunWithMemoryReservation(c *check.C) {
+	testRequires(c, memoryReservationSupport)
+
+	file := "/sys/fs/cgroup/memory/memory.soft_limit_in_bytes"
+	out, _ := dockerCmd(c, "run", "--memory-reservation", "200M", "--name", "test", "busybox", "cat", file)
+	c.Assert(strings.TrimSpace(out), check.Equals, "209715200")
+
+	out, err := inspectField("test", "HostConfig.MemoryReservation")
+	c.Assert(err, check.IsNil)
+	c.Assert(out, check.Equals, "209715200")
+}
+
+func (s *DockerSuite) TestRunWithMemoryReservationInvalid(c *check.C) {
+	testRequires(c, memoryLimitSupport, memoryReservationSupport)
+
+	out, _, err := dockerCmdWithError("run", "-m", "500M", "--memory-reservation", "800M", "busybox", "true")
+	c.Assert(err, check.NotNil)
+	c.Assert(out, checker.Contains, "Minimum memory limit should be larger than memory reservation limit")
+}
+
+func (s *DockerSuite) TestRunWithOomScoreAdj(c *check.C) {
+	testRequires(c, NativeExecDriver)
+
+	out, _ := dockerCmd(c, "run", "--oom-score-adj", "642", "--name", "test", "busybox", "cat", "/proc/self/oom_score_adj")
+	c.Assert(strings.TrimSpace(out), check.Equals, "642")
+
+	out, err := inspectField("test", "HostConfig.OomScoreAdj")
+	c.Assert(err, check.IsNil)
+	c.Assert(out, check.Equals, "642")
+}
+
+func (s *DockerSuite) TestRunWithOomScoreAdjInvalidRange(c *check.C) {
+	out, _, err := dockerCmdWithError("run", "--oom-score-adj", "1001", "busybox", "true")
+	c.Assert(err, check.NotNil)
+	c.Assert(out, checker.Contains, "Invalid value 1001, range for oom score adj is [-1000, 1000].")
+
+	out, _, err = dockerCmdWithError("run", "--oom-score-adj", "-1001", "busybox", "true")
+	c.Assert(err, check.NotNil)
+	c.Assert(out, checker.Contains, "Invalid value -1001, range for oom score adj is [-1000, 1000].")
+}
+
+func (s *DockerSuite) TestRunWithStopTimeout(c *check.C) {
+	// sleep ignores SIGTERM as PID 1, so it is only killed after the timeout.
+	dockerCmd(c, "run", "-d", "--stop-timeout", "1", "--name", "test", "busybox", "sleep", "100")
+
+	out, err := inspectField("test", "Config.StopTimeout")
+	c.Assert(err, check.IsNil)
+	c.Assert(out, check.Equals, "1")
+
+	start := time.Now()
+	dockerCmd(c, "restart", "test")
+	c.Assert(time.Since(start) < 8*time.Second, check.Equals, true, check.Commentf("Expected the container to restart within its stop timeout"))
+
+	start = time.Now()
+	dockerCmd(c, "stop", "test")
+	c.Assert(time.Since(start) < 8*time.Second, check.Equals, true, check.Commentf("Expected the container to stop within its stop timeout"))
+}
+
+func (s *DockerSuite) TestRunWithInit(c *check.C) {
+	testRequires(c, NativeExecDriver)
+
+	out, _ := dockerCmd(c, "run", "--init", "busybox", "sh", "-c", "cat /proc/1/cmdline | tr '\\0' ' '")
+	c.Assert(strings.HasPrefix(out, "/dev/init sh -c"), check.Equals, true, check.Commentf("Expected the init as PID 1, got %s", out))
+
+	// The init exits with the exit code of the command.
+	out, exitCode, err := dockerCmdWithError("run", "--init", "busybox", "sh", "-c", "exit 3")
+	c.Assert(err, check.NotNil, check.Commentf(out))
+	c.Assert(exitCode, check.Equals, 3)
+}
+
+func (s *DockerSuite) TestRunWithInitForwardsSignals(c *check.C) {
+	testRequires(c, NativeExecDriver)
+
+	// sleep ignores SIGTERM as PID 1, but not as the child of the init.
+	dockerCmd(c, "run", "-d", "--init", "--name", "test", "busybox", "sleep", "100")
+	dockerCmd(c, "stop", "-t", "30", "test")
+
+	out, err := inspectField("test", "State.ExitCode")
+	c.Assert(err, check.IsNil)
+	c.Assert(out, check.Equals, "143")
+
+	out, err = inspectField("test", "HostConfig.Init")
+	c.Assert(err, check.IsNil)
+	c.Assert(out, check.Equals, "true")
+}
+
 // "test" should be printed
 func (s *DockerSuite) TestRunEchoStdoutWitCPUShares(c *check.C) {
 	testRequires(c, cpuShare)
@@ -261,6 +363,28 @@ func (s *DockerSuite) TestRunWithBlkioWeight(c *check.C) {
 	}
 }
 
+func (s *DockerSuite) TestRunWithBlkioThrottleDevices(c *check.C) {
+	testRequires(c, blkioThrottle, SameHostDaemon)
+	if _, err := os.Stat("/dev/sda"); err != nil {
+		c.Skip("Test requires the /dev/sda block device")
+	}
+
+	out, _ := dockerCmd(c, "run", "--name", "throttled", "--device-read-bps", "/dev/sda:1mb", "--device-write-iops", "/dev/sda:100", "busybox",
+		"cat", "/sys/fs/cgroup/blkio/blkio.throttle.read_bps_device", "/sys/fs/cgroup/blkio/blkio.throttle.write_iops_device")
+	c.Assert(out, checker.Contains, "8:0 1048576")
+	c.Assert(out, checker.Contains, "8:0 100")
+
+	out, err := inspectField("throttled", "HostConfig.BlkioDeviceReadBps")
+	c.Assert(err, check.IsNil)
+	c.Assert(out, checker.Contains, "/dev/sda")
+}
+
+func (s *DockerSuite) TestRunWithBlkioThrottleInvalidDevice(c *check.C) {
+	testRequires(c, blkioThrottle)
+	out, _, err := dockerCmdWithError("run", "--device-read-bps", "/dev/doesnotexist:1mb", "busybox", "true")
+	c.Assert(err, check.NotNil, check.Commentf("run with a missing device should fail: %s", out))
+}
+
 func (s *DockerSuite) TestRunWithBlkioInvalidWeight(c *check.C) {
 	testRequires(c, blkioWeight)
 	if _, _, err := dockerCmdWithError("run", "--blkio-weight", "5", "busybox", "true"); err == nil {
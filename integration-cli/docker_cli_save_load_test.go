@@ -10,6 +10,7 @@ import (
 	"sort"
 	"strings"
 
+	"github.com/docker/docker/pkg/integration/checker"
 	"github.com/go-check/check"
 )
 
@@ -298,3 +299,40 @@ func (s *DockerSuite) TestSaveDirectoryPermissions(c *check.C) {
 	}
 
 }
+
+func (s *DockerSuite) TestSaveAndLoadOCILayout(c *check.C) {
+	testRequires(c, DaemonIsLinux)
+	repoName := "foobar-save-load-oci"
+	dockerCmd(c, "tag", "busybox:latest", repoName+":latest")
+
+	tmpDir, err := ioutil.TempDir("", "save-load-oci")
+	c.Assert(err, check.IsNil)
+	defer os.RemoveAll(tmpDir)
+	layoutDir := filepath.Join(tmpDir, "layout")
+	c.Assert(os.Mkdir(layoutDir, 0755), check.IsNil)
+
+	if out, _, err := runCommandPipelineWithOutput(
+		exec.Command(dockerBinary, "save", "--format", "oci", repoName),
+		exec.Command("tar", "-xf", "-", "-C", layoutDir),
+	); err != nil {
+		c.Fatalf("failed to save and extract the OCI layout: %s, %v", out, err)
+	}
+	for _, name := range []string{"oci-layout", "index.json", "blobs/sha256"} {
+		_, err := os.Stat(filepath.Join(layoutDir, name))
+		c.Assert(err, check.IsNil, check.Commentf("expected %s in the OCI layout", name))
+	}
+	index, err := ioutil.ReadFile(filepath.Join(layoutDir, "index.json"))
+	c.Assert(err, check.IsNil)
+	c.Assert(string(index), checker.Contains, `"org.opencontainers.image.ref.name":"`+repoName+`:latest"`)
+
+	deleteImages(repoName)
+	out, _ := dockerCmd(c, "load", "--input", layoutDir)
+	c.Assert(out, checker.Contains, "Loaded image: "+repoName+":latest")
+
+	out, _ = dockerCmd(c, "run", "--rm", repoName, "echo", "loaded")
+	c.Assert(strings.TrimSpace(out), check.Equals, "loaded")
+
+	out, _, err = dockerCmdWithError("save", "--format", "foo", repoName)
+	c.Assert(err, check.NotNil)
+	c.Assert(out, checker.Contains, "Invalid format")
+}
@@ -89,3 +89,29 @@ func (s *DockerSuite) TestSearchCmdOptions(c *check.C) {
 		c.Fatalf("failed to search with stars&automated&no-trunc options on the central registry: %s", out)
 	}
 }
+
+func (s *DockerSuite) TestSearchWithLimitAndFilters(c *check.C) {
+	testRequires(c, Network)
+
+	out, _, err := dockerCmdWithError("search", "--limit=0", "busybox")
+	if err == nil || !strings.Contains(out, "outside the range") {
+		c.Fatalf("Expected an error for a limit out of range: %s", out)
+	}
+
+	out, _, err = dockerCmdWithError("search", "--filter", "name=foo", "busybox")
+	if err == nil || !strings.Contains(out, "Invalid filter") {
+		c.Fatalf("Expected an error for an invalid filter: %s", out)
+	}
+
+	out, _ = dockerCmd(c, "search", "--limit=2", "busybox")
+	if lines := strings.Split(strings.TrimSpace(out), "\n"); len(lines) > 3 {
+		c.Fatalf("Expected at most 2 results: %s", out)
+	}
+
+	out, _ = dockerCmd(c, "search", "--filter", "is-official=true", "busybox")
+	for _, line := range strings.Split(strings.TrimSpace(out), "\n")[1:] {
+		if !strings.Contains(line, "[OK]") {
+			c.Fatalf("Expected only official images: %s", out)
+		}
+	}
+}
@@ -39,12 +39,24 @@ var (
 		},
 		"Test requires an environment that supports cgroup kernel memory.",
 	}
+	pidsLimit = testRequirement{
+		func() bool {
+			return SysInfo.PidsLimit
+		},
+		"Test requires pids limit support.",
+	}
 	memoryLimitSupport = testRequirement{
 		func() bool {
 			return SysInfo.MemoryLimit
 		},
 		"Test requires an environment that supports cgroup memory limit.",
 	}
+	memoryReservationSupport = testRequirement{
+		func() bool {
+			return SysInfo.MemoryReservation
+		},
+		"Test requires an environment that supports cgroup memory reservation.",
+	}
 	swapMemorySupport = testRequirement{
 		func() bool {
 			return SysInfo.SwapLimit
@@ -63,6 +75,12 @@ var (
 		},
 		"Test requires an environment that supports blkio weight.",
 	}
+	blkioThrottle = testRequirement{
+		func() bool {
+			return SysInfo.BlkioReadBpsDevice && SysInfo.BlkioWriteIOpsDevice
+		},
+		"Test requires an environment that supports blkio throttling.",
+	}
 	cgroupCpuset = testRequirement{
 		func() bool {
 			return SysInfo.Cpuset
@@ -0,0 +1,124 @@
+package opts
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/docker/docker/pkg/blkiodev"
+	"github.com/docker/docker/pkg/units"
+)
+
+// WeightDeviceOpt defines a list of block IO weights per device.
+type WeightDeviceOpt struct {
+	values []*blkiodev.WeightDevice
+}
+
+// NewWeightDeviceOpt creates a new WeightDeviceOpt
+func NewWeightDeviceOpt() *WeightDeviceOpt {
+	return &WeightDeviceOpt{}
+}
+
+// Set validates a device:weight pair and adds it to the list.
+func (o *WeightDeviceOpt) Set(val string) error {
+	path, value, err := splitBlkioDevice(val)
+	if err != nil {
+		return err
+	}
+	weight, err := strconv.ParseUint(value, 10, 0)
+	if err != nil {
+		return fmt.Errorf("invalid weight for device: %s", val)
+	}
+	if weight > 0 && (weight < 10 || weight > 1000) {
+		return fmt.Errorf("invalid weight for device: %s, weight must be between 10 and 1000", val)
+	}
+
+	o.values = append(o.values, &blkiodev.WeightDevice{
+		Path:   path,
+		Weight: uint16(weight),
+	})
+	return nil
+}
+
+// String returns the device:weight pairs as a string.
+func (o *WeightDeviceOpt) String() string {
+	var out []string
+	for _, v := range o.values {
+		out = append(out, v.String())
+	}
+
+	return fmt.Sprintf("%v", out)
+}
+
+// GetList returns a slice of pointers to WeightDevices.
+func (o *WeightDeviceOpt) GetList() []*blkiodev.WeightDevice {
+	return o.values
+}
+
+// ThrottleDeviceOpt defines a list of block IO rate limits per device.
+type ThrottleDeviceOpt struct {
+	values []*blkiodev.ThrottleDevice
+	bytes  bool
+}
+
+// NewThrottleDeviceOpt creates a new ThrottleDeviceOpt. The rates of bytes
+// per second accept a unit, e.g. 10mb, the rates of IO per second don't.
+func NewThrottleDeviceOpt(bytes bool) *ThrottleDeviceOpt {
+	return &ThrottleDeviceOpt{bytes: bytes}
+}
+
+// Set validates a device:rate pair and adds it to the list.
+func (o *ThrottleDeviceOpt) Set(val string) error {
+	path, value, err := splitBlkioDevice(val)
+	if err != nil {
+		return err
+	}
+	var rate uint64
+	if o.bytes {
+		bytes, err := units.RAMInBytes(value)
+		if err != nil || bytes < 0 {
+			return fmt.Errorf("invalid rate for device: %s. The correct format is <device-path>:<number>[<unit>]. Number must be a positive integer. Unit is optional and can be kb, mb, or gb", val)
+		}
+		rate = uint64(bytes)
+	} else {
+		rate, err = strconv.ParseUint(value, 10, 64)
+		if err != nil {
+			return fmt.Errorf("invalid rate for device: %s. The correct format is <device-path>:<number>. Number must be a positive integer", val)
+		}
+	}
+
+	o.values = append(o.values, &blkiodev.ThrottleDevice{
+		Path: path,
+		Rate: rate,
+	})
+	return nil
+}
+
+// String returns the device:rate pairs as a string.
+func (o *ThrottleDeviceOpt) String() string {
+	var out []string
+	for _, v := range o.values {
+		out = append(out, v.String())
+	}
+
+	return fmt.Sprintf("%v", out)
+}
+
+// GetList returns a slice of pointers to ThrottleDevices.
+func (o *ThrottleDeviceOpt) GetList() []*blkiodev.ThrottleDevice {
+	return o.values
+}
+
+// splitBlkioDevice splits a device:value pair, the device being a path
+// under /dev.
+func splitBlkioDevice(val string) (string, string, error) {
+	i := strings.LastIndex(val, ":")
+	if i < 0 {
+		return "", "", fmt.Errorf("bad format: %s", val)
+	}
+	path, value := val[:i], val[i+1:]
+	if !strings.HasPrefix(path, "/dev/") {
+		return "", "", fmt.Errorf("bad format for device path: %s", val)
+	}
+	return path, value, nil
+}
@@ -0,0 +1,46 @@
+package opts
+
+import (
+	"testing"
+)
+
+func TestWeightDeviceOpt(t *testing.T) {
+	o := NewWeightDeviceOpt()
+	if err := o.Set("/dev/sda:100"); err != nil {
+		t.Fatal(err)
+	}
+	for _, invalid := range []string{"/dev/sda", "sda:100", "/dev/sda:5", "/dev/sda:1001", "/dev/sda:foo"} {
+		if err := o.Set(invalid); err == nil {
+			t.Fatalf("Expected an error setting %q", invalid)
+		}
+	}
+	if expected := "[/dev/sda:100]"; o.String() != expected {
+		t.Fatalf("Expected %v, got %v", expected, o)
+	}
+}
+
+func TestThrottleDeviceOpt(t *testing.T) {
+	bps := NewThrottleDeviceOpt(true)
+	if err := bps.Set("/dev/sda:10mb"); err != nil {
+		t.Fatal(err)
+	}
+	if err := bps.Set("/dev/sdb:1024"); err != nil {
+		t.Fatal(err)
+	}
+	if expected := "[/dev/sda:10485760 /dev/sdb:1024]"; bps.String() != expected {
+		t.Fatalf("Expected %v, got %v", expected, bps)
+	}
+
+	iops := NewThrottleDeviceOpt(false)
+	if err := iops.Set("/dev/sda:1000"); err != nil {
+		t.Fatal(err)
+	}
+	for _, invalid := range []string{"/dev/sda:10mb", "/dev/sda:-1", "sda:1000"} {
+		if err := iops.Set(invalid); err == nil {
+			t.Fatalf("Expected an error setting %q", invalid)
+		}
+	}
+	if len(iops.GetList()) != 1 || iops.GetList()[0].Rate != 1000 {
+		t.Fatalf("Unexpected rates %v", iops)
+	}
+}
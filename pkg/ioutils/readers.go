@@ -260,3 +260,23 @@ func (r *OnEOFReader) runFunc() {
 		r.Fn = nil
 	}
 }
+
+// ReadCounter wraps a concrete io.Reader and holds a count of the number
+// of bytes read from the reader during a "session".
+type ReadCounter struct {
+	Count  int64
+	Reader io.Reader
+}
+
+// NewReadCounter returns a new ReadCounter.
+func NewReadCounter(r io.Reader) *ReadCounter {
+	return &ReadCounter{
+		Reader: r,
+	}
+}
+
+func (rc *ReadCounter) Read(p []byte) (count int, err error) {
+	count, err = rc.Reader.Read(p)
+	rc.Count += int64(count)
+	return
+}
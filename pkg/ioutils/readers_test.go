@@ -214,3 +214,19 @@ func Benchmark10k32KBytesReads(b *testing.B) {
 		testWithData(data, reads)
 	}
 }
+
+func TestReadCounter(t *testing.T) {
+	dummy := "This is a dummy string."
+	rc := NewReadCounter(strings.NewReader(dummy))
+
+	b, err := ioutil.ReadAll(rc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(b) != dummy {
+		t.Errorf("Wrong data read: %q", b)
+	}
+	if rc.Count != int64(len(dummy)) {
+		t.Errorf("Wrong count: %d vs. %d", rc.Count, len(dummy))
+	}
+}
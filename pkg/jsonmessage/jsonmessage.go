@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
+	"sort"
 	"strings"
 	"time"
 
@@ -102,6 +103,11 @@ type JSONMessage struct {
 	TimeNano        int64         `json:"timeNano,omitempty"`
 	Error           *JSONError    `json:"errorDetail,omitempty"`
 	ErrorMessage    string        `json:"error,omitempty"` //deprecated
+	// Attributes details an event, such as the reason of a restart.
+	Attributes map[string]string `json:"attributes,omitempty"`
+	// Aux contains out-of-band data, such as structured build progress,
+	// which is not meant to be displayed: Display skips it.
+	Aux *json.RawMessage `json:"aux,omitempty"`
 }
 
 // Display displays the JSONMessage to `out`. `isTerminal` describes if `out`
@@ -114,6 +120,9 @@ func (jm *JSONMessage) Display(out io.Writer, isTerminal bool) error {
 		}
 		return jm.Error
 	}
+	if jm.Aux != nil {
+		return nil
+	}
 	var endl string
 	if isTerminal && jm.Stream == "" && jm.Progress != nil {
 		// <ESC>[2K = erase entire current line
@@ -139,12 +148,28 @@ func (jm *JSONMessage) Display(out io.Writer, isTerminal bool) error {
 		fmt.Fprintf(out, "%s %s%s", jm.Status, jm.ProgressMessage, endl)
 	} else if jm.Stream != "" {
 		fmt.Fprintf(out, "%s%s", jm.Stream, endl)
+	} else if len(jm.Attributes) > 0 {
+		fmt.Fprintf(out, "%s %s%s\n", jm.Status, formatAttributes(jm.Attributes), endl)
 	} else {
 		fmt.Fprintf(out, "%s%s\n", jm.Status, endl)
 	}
 	return nil
 }
 
+// formatAttributes formats the attributes of an event as
+// "(key1=value1, key2=value2)", sorted by key.
+func formatAttributes(attributes map[string]string) string {
+	keys := make([]string, 0, len(attributes))
+	for k := range attributes {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for i, k := range keys {
+		keys[i] = k + "=" + attributes[k]
+	}
+	return "(" + strings.Join(keys, ", ") + ")"
+}
+
 // DisplayJSONMessagesStream displays a json message stream from `in` to `out`, `isTerminal`
 // describes if `out` is a terminal. If this is the case, it will print `\n` at the end of
 // each line and move the cursor while displaying.
@@ -2,6 +2,7 @@ package jsonmessage
 
 import (
 	"bytes"
+	"encoding/json"
 	"fmt"
 	"strings"
 	"testing"
@@ -54,18 +55,19 @@ func TestProgress(t *testing.T) {
 
 func TestJSONMessageDisplay(t *testing.T) {
 	now := time.Now()
-	messages := map[JSONMessage][]string{
+	aux := json.RawMessage(`{"step":1}`)
+	messages := map[*JSONMessage][]string{
 		// Empty
-		JSONMessage{}: {"\n", "\n"},
+		&JSONMessage{}: {"\n", "\n"},
 		// Status
-		JSONMessage{
+		&JSONMessage{
 			Status: "status",
 		}: {
 			"status\n",
 			"status\n",
 		},
 		// General
-		JSONMessage{
+		&JSONMessage{
 			Time:   now.Unix(),
 			ID:     "ID",
 			From:   "From",
@@ -75,7 +77,7 @@ func TestJSONMessageDisplay(t *testing.T) {
 			fmt.Sprintf("%v ID: (from From) status\n", time.Unix(now.Unix(), 0).Format(timeutils.RFC3339NanoFixed)),
 		},
 		// General, with nano precision time
-		JSONMessage{
+		&JSONMessage{
 			TimeNano: now.UnixNano(),
 			ID:       "ID",
 			From:     "From",
@@ -85,7 +87,7 @@ func TestJSONMessageDisplay(t *testing.T) {
 			fmt.Sprintf("%v ID: (from From) status\n", time.Unix(0, now.UnixNano()).Format(timeutils.RFC3339NanoFixed)),
 		},
 		// General, with both times Nano is preferred
-		JSONMessage{
+		&JSONMessage{
 			Time:     now.Unix(),
 			TimeNano: now.UnixNano(),
 			ID:       "ID",
@@ -96,7 +98,7 @@ func TestJSONMessageDisplay(t *testing.T) {
 			fmt.Sprintf("%v ID: (from From) status\n", time.Unix(0, now.UnixNano()).Format(timeutils.RFC3339NanoFixed)),
 		},
 		// Stream over status
-		JSONMessage{
+		&JSONMessage{
 			Status: "status",
 			Stream: "stream",
 		}: {
@@ -104,7 +106,7 @@ func TestJSONMessageDisplay(t *testing.T) {
 			"stream",
 		},
 		// With progress message
-		JSONMessage{
+		&JSONMessage{
 			Status:          "status",
 			ProgressMessage: "progressMessage",
 		}: {
@@ -112,7 +114,7 @@ func TestJSONMessageDisplay(t *testing.T) {
 			"status progressMessage",
 		},
 		// With progress, stream empty
-		JSONMessage{
+		&JSONMessage{
 			Status:   "status",
 			Stream:   "",
 			Progress: &JSONProgress{Current: 1},
@@ -120,6 +122,22 @@ func TestJSONMessageDisplay(t *testing.T) {
 			"",
 			fmt.Sprintf("%c[2K\rstatus      1 B\r", 27),
 		},
+		// Attributes of an event
+		&JSONMessage{
+			ID:         "ID",
+			Status:     "restart",
+			Attributes: map[string]string{"reason": "exit status 1", "exitCode": "1"},
+		}: {
+			"ID: restart (exitCode=1, reason=exit status 1)\n",
+			"ID: restart (exitCode=1, reason=exit status 1)\n",
+		},
+		// Auxiliary data is not displayed
+		&JSONMessage{
+			Aux: &aux,
+		}: {
+			"",
+			"",
+		},
 	}
 
 	// The tests :)
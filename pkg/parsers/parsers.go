@@ -117,6 +117,34 @@ func ParseRepositoryTag(repos string) (string, string) {
 	return repos, ""
 }
 
+// ParsePlatform parses and validates the specified string as a platform
+// (os/arch[/variant], e.g. linux/arm/v7). Architectures are normalized to
+// the names used by Go, e.g. x86_64 -> amd64.
+func ParsePlatform(platform string) (os, arch, variant string, err error) {
+	parts := strings.Split(strings.ToLower(platform), "/")
+	if len(parts) < 2 || len(parts) > 3 {
+		return "", "", "", fmt.Errorf("Invalid platform format: %s, expected os/arch[/variant]", platform)
+	}
+	for _, part := range parts {
+		if part == "" {
+			return "", "", "", fmt.Errorf("Invalid platform format: %s, expected os/arch[/variant]", platform)
+		}
+	}
+	os, arch = parts[0], parts[1]
+	if len(parts) == 3 {
+		variant = parts[2]
+	}
+	switch arch {
+	case "x86_64", "x86-64":
+		arch = "amd64"
+	case "aarch64":
+		arch = "arm64"
+	case "i386", "i686":
+		arch = "386"
+	}
+	return os, arch, variant, nil
+}
+
 // PartParser parses and validates the specified string (data) using the specified template
 // e.g. ip:public:private -> 192.168.0.1:80:8000
 func PartParser(template, data string) (map[string]string, error) {
@@ -238,3 +238,29 @@ func TestParseLink(t *testing.T) {
 		t.Fatalf("Expected error 'bad format for links: link:alias:wrong' but got: %v", err)
 	}
 }
+
+func TestParsePlatform(t *testing.T) {
+	valid := map[string][3]string{
+		"linux/amd64":    {"linux", "amd64", ""},
+		"Linux/x86_64":   {"linux", "amd64", ""},
+		"linux/arm/v7":   {"linux", "arm", "v7"},
+		"linux/aarch64":  {"linux", "arm64", ""},
+		"windows/amd64":  {"windows", "amd64", ""},
+		"linux/i386/foo": {"linux", "386", "foo"},
+	}
+	for platform, expected := range valid {
+		os, arch, variant, err := ParsePlatform(platform)
+		if err != nil {
+			t.Fatalf("Expected %s to be valid, got %v", platform, err)
+		}
+		if os != expected[0] || arch != expected[1] || variant != expected[2] {
+			t.Fatalf("Expected %v for %s, got %s %s %s", expected, platform, os, arch, variant)
+		}
+	}
+
+	for _, platform := range []string{"", "linux", "linux/", "/amd64", "linux/arm/v7/extra", "linux//v7"} {
+		if _, _, _, err := ParsePlatform(platform); err == nil {
+			t.Fatalf("Expected %q to be invalid", platform)
+		}
+	}
+}
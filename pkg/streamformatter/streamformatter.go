@@ -92,6 +92,23 @@ func (sf *StreamFormatter) FormatProgress(id, action string, progress *jsonmessa
 	return []byte(action + " " + progress.String() + endl)
 }
 
+// FormatAux formats the specified out-of-band data. Only JSON streams can
+// carry auxiliary data, so nothing is returned for a plain text stream.
+func (sf *StreamFormatter) FormatAux(aux interface{}) []byte {
+	if !sf.json {
+		return nil
+	}
+	auxJSON, err := json.Marshal(aux)
+	if err != nil {
+		return sf.FormatError(err)
+	}
+	b, err := json.Marshal(&jsonmessage.JSONMessage{Aux: (*json.RawMessage)(&auxJSON)})
+	if err != nil {
+		return sf.FormatError(err)
+	}
+	return append(b, streamNewlineBytes...)
+}
+
 // StdoutFormatter is a streamFormatter that writes to the standard output.
 type StdoutFormatter struct {
 	io.Writer
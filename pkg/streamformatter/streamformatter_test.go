@@ -91,3 +91,18 @@ func TestJSONFormatProgress(t *testing.T) {
 		t.Fatal("Original progress not equals progress from FormatProgress")
 	}
 }
+
+func TestFormatAux(t *testing.T) {
+	sf := NewStreamFormatter()
+	if res := sf.FormatAux(map[string]int{"step": 1}); res != nil {
+		t.Fatalf("Expected no output for a plain text stream, got %q", res)
+	}
+}
+
+func TestJSONFormatAux(t *testing.T) {
+	sf := NewJSONStreamFormatter()
+	res := sf.FormatAux(map[string]int{"step": 1})
+	if string(res) != `{"aux":{"step":1}}`+"\r\n" {
+		t.Fatalf("%q", res)
+	}
+}
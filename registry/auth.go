@@ -11,13 +11,16 @@ import (
 	"github.com/docker/docker/cliconfig"
 )
 
-// Login tries to register/login to the registry server.
-func Login(authConfig *cliconfig.AuthConfig, registryEndpoint *Endpoint) (string, error) {
+// Login tries to register/login to the registry server. Along with the
+// status, it returns the identity token to use instead of the password of
+// the user for the registries issuing OAuth2 refresh tokens.
+func Login(authConfig *cliconfig.AuthConfig, registryEndpoint *Endpoint) (string, string, error) {
 	// Separates the v2 registry login logic from the v1 logic.
 	if registryEndpoint.Version == APIVersion2 {
 		return loginV2(authConfig, registryEndpoint, "" /* scope */)
 	}
-	return loginV1(authConfig, registryEndpoint)
+	status, err := loginV1(authConfig, registryEndpoint)
+	return status, "", err
 }
 
 // loginV1 tries to register/login to the v1 registry server.
@@ -136,11 +139,12 @@ func loginV1(authConfig *cliconfig.AuthConfig, registryEndpoint *Endpoint) (stri
 // now, users should create their account through other means like directly from a web page
 // served by the v2 registry service provider. Whether this will be supported in the future
 // is to be determined.
-func loginV2(authConfig *cliconfig.AuthConfig, registryEndpoint *Endpoint, scope string) (string, error) {
+func loginV2(authConfig *cliconfig.AuthConfig, registryEndpoint *Endpoint, scope string) (string, string, error) {
 	logrus.Debugf("attempting v2 login to registry endpoint %s", registryEndpoint)
 	var (
-		err       error
-		allErrors []error
+		err           error
+		identityToken string
+		allErrors     []error
 	)
 
 	for _, challenge := range registryEndpoint.AuthChallenges {
@@ -155,14 +159,14 @@ func loginV2(authConfig *cliconfig.AuthConfig, registryEndpoint *Endpoint, scope
 		case "basic":
 			err = tryV2BasicAuthLogin(authConfig, params, registryEndpoint)
 		case "bearer":
-			err = tryV2TokenAuthLogin(authConfig, params, registryEndpoint)
+			identityToken, err = tryV2TokenAuthLogin(authConfig, params, registryEndpoint)
 		default:
 			// Unsupported challenge types are explicitly skipped.
 			err = fmt.Errorf("unsupported auth scheme: %q", challenge.Scheme)
 		}
 
 		if err == nil {
-			return "Login Succeeded", nil
+			return "Login Succeeded", identityToken, nil
 		}
 
 		logrus.Debugf("error trying auth challenge %q: %s", challenge.Scheme, err)
@@ -170,7 +174,7 @@ func loginV2(authConfig *cliconfig.AuthConfig, registryEndpoint *Endpoint, scope
 		allErrors = append(allErrors, err)
 	}
 
-	return "", fmt.Errorf("no successful auth challenge for %s - errors: %s", registryEndpoint, allErrors)
+	return "", "", fmt.Errorf("no successful auth challenge for %s - errors: %s", registryEndpoint, allErrors)
 }
 
 func tryV2BasicAuthLogin(authConfig *cliconfig.AuthConfig, params map[string]string, registryEndpoint *Endpoint) error {
@@ -194,30 +198,34 @@ func tryV2BasicAuthLogin(authConfig *cliconfig.AuthConfig, params map[string]str
 	return nil
 }
 
-func tryV2TokenAuthLogin(authConfig *cliconfig.AuthConfig, params map[string]string, registryEndpoint *Endpoint) error {
-	token, err := getToken(authConfig.Username, authConfig.Password, params, registryEndpoint)
+// tryV2TokenAuthLogin returns the refresh token issued by the token server,
+// if any, so that the password of the user isn't needed afterwards.
+func tryV2TokenAuthLogin(authConfig *cliconfig.AuthConfig, params map[string]string, registryEndpoint *Endpoint) (string, error) {
+	th := newTokenHandler(registryEndpoint.client, authConfig, registryEndpoint.IsSecure)
+	th.offline = true
+	token, err := th.token(params, params["scope"])
 	if err != nil {
-		return err
+		return "", err
 	}
 
 	req, err := http.NewRequest("GET", registryEndpoint.Path(""), nil)
 	if err != nil {
-		return err
+		return "", err
 	}
 
 	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", token))
 
 	resp, err := registryEndpoint.client.Do(req)
 	if err != nil {
-		return err
+		return "", err
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		return fmt.Errorf("token auth attempt to %s realm %q failed with status: %d %s", registryEndpoint, params["realm"], resp.StatusCode, http.StatusText(resp.StatusCode))
+		return "", fmt.Errorf("token auth attempt to %s realm %q failed with status: %d %s", registryEndpoint, params["realm"], resp.StatusCode, http.StatusText(resp.StatusCode))
 	}
 
-	return nil
+	return th.identityToken(), nil
 }
 
 // ResolveAuthConfig matches an auth configuration to a server address or a URL
@@ -17,7 +17,9 @@ import (
 // Options holds command line options.
 type Options struct {
 	Mirrors            opts.ListOpts
+	MirrorsWithAuth    opts.ListOpts
 	InsecureRegistries opts.ListOpts
+	RequireDigest      opts.ListOpts
 }
 
 const (
@@ -51,8 +53,12 @@ var (
 func (options *Options) InstallFlags(cmd *flag.FlagSet, usageFn func(string) string) {
 	options.Mirrors = opts.NewListOpts(ValidateMirror)
 	cmd.Var(&options.Mirrors, []string{"-registry-mirror"}, usageFn("Preferred Docker registry mirror"))
+	options.MirrorsWithAuth = opts.NewListOpts(ValidateMirrorWithAuth)
+	cmd.Var(&options.MirrorsWithAuth, []string{"-registry-mirror-auth"}, usageFn("Send the credentials of a registry to its mirror"))
 	options.InsecureRegistries = opts.NewListOpts(ValidateIndexName)
 	cmd.Var(&options.InsecureRegistries, []string{"-insecure-registry"}, usageFn("Enable insecure registry communication"))
+	options.RequireDigest = opts.NewListOpts(ValidateIndexName)
+	cmd.Var(&options.RequireDigest, []string{"-require-digest"}, usageFn("Require pulls and runs by digest for a registry"))
 }
 
 type netIPNet net.IPNet
@@ -84,7 +90,9 @@ func NewServiceConfig(options *Options) *ServiceConfig {
 	if options == nil {
 		options = &Options{
 			Mirrors:            opts.NewListOpts(nil),
+			MirrorsWithAuth:    opts.NewListOpts(nil),
 			InsecureRegistries: opts.NewListOpts(nil),
+			RequireDigest:      opts.NewListOpts(nil),
 		}
 	}
 
@@ -98,9 +106,20 @@ func NewServiceConfig(options *Options) *ServiceConfig {
 	config := &ServiceConfig{
 		InsecureRegistryCIDRs: make([]*netIPNet, 0),
 		IndexConfigs:          make(map[string]*IndexInfo, 0),
-		// Hack: Bypass setting the mirrors to IndexConfigs since they are going away
-		// and Mirrors are only for the official registry anyways.
-		Mirrors: options.Mirrors.GetAll(),
+		// Hack: Bypass setting the mirrors of the official registry to
+		// IndexConfigs since they are going away.
+		Mirrors: make([]string, 0),
+	}
+	// Split --registry-mirror into official and registry-specific mirrors,
+	// keeping their order.
+	indexMirrors := make(map[string][]string)
+	for _, m := range options.Mirrors.GetAll() {
+		indexName, mirror := splitMirror(m)
+		if indexName == IndexName {
+			config.Mirrors = append(config.Mirrors, mirror)
+		} else {
+			indexMirrors[indexName] = append(indexMirrors[indexName], mirror)
+		}
 	}
 	// Split --insecure-registry into CIDR and registry-specific settings.
 	for _, r := range options.InsecureRegistries.GetAll() {
@@ -120,6 +139,29 @@ func NewServiceConfig(options *Options) *ServiceConfig {
 		}
 	}
 
+	// Configure the mirrors of private registries.
+	for indexName, mirrors := range indexMirrors {
+		if index, ok := config.IndexConfigs[indexName]; ok {
+			index.Mirrors = mirrors
+			continue
+		}
+		config.IndexConfigs[indexName] = &IndexInfo{
+			Name:     indexName,
+			Mirrors:  mirrors,
+			Secure:   config.isSecureIndex(indexName),
+			Official: false,
+		}
+	}
+
+	// Configure the mirrors which are sent the credentials of the private
+	// registry they mirror.
+	for _, m := range options.MirrorsWithAuth.GetAll() {
+		indexName, mirror := splitMirror(m)
+		if index, ok := config.IndexConfigs[indexName]; ok {
+			index.AuthMirrors = append(index.AuthMirrors, mirror)
+		}
+	}
+
 	// Configure public registry.
 	config.IndexConfigs[IndexName] = &IndexInfo{
 		Name:     IndexName,
@@ -128,6 +170,21 @@ func NewServiceConfig(options *Options) *ServiceConfig {
 		Official: true,
 	}
 
+	// Configure the registries whose images must be referenced by digest.
+	for _, indexName := range options.RequireDigest.GetAll() {
+		index, ok := config.IndexConfigs[indexName]
+		if !ok {
+			index = &IndexInfo{
+				Name:     indexName,
+				Mirrors:  make([]string, 0),
+				Secure:   config.isSecureIndex(indexName),
+				Official: false,
+			}
+			config.IndexConfigs[indexName] = index
+		}
+		index.RequireDigest = true
+	}
+
 	return config
 }
 
@@ -180,8 +237,22 @@ func (config *ServiceConfig) isSecureIndex(indexName string) bool {
 	return true
 }
 
-// ValidateMirror validates an HTTP(S) registry mirror
+// ValidateMirror validates an HTTP(S) registry mirror. The mirror applies to
+// the official registry, unless it is prefixed with the name of the registry
+// it mirrors, as in "registry.example.com=https://mirror.example.com".
 func ValidateMirror(val string) (string, error) {
+	var indexName string
+	if i := strings.Index(val, "="); i >= 0 {
+		var err error
+		if indexName, err = ValidateIndexName(val[:i]); err != nil {
+			return "", err
+		}
+		if indexName == "" {
+			return "", fmt.Errorf("Missing registry name in mirror %s", val)
+		}
+		val = val[i+1:]
+	}
+
 	uri, err := url.Parse(val)
 	if err != nil {
 		return "", fmt.Errorf("%s is not a valid URI", val)
@@ -195,7 +266,34 @@ func ValidateMirror(val string) (string, error) {
 		return "", fmt.Errorf("Unsupported path/query/fragment at end of the URI")
 	}
 
-	return fmt.Sprintf("%s://%s/", uri.Scheme, uri.Host), nil
+	mirror := fmt.Sprintf("%s://%s/", uri.Scheme, uri.Host)
+	if indexName != "" && indexName != IndexName {
+		return indexName + "=" + mirror, nil
+	}
+	return mirror, nil
+}
+
+// ValidateMirrorWithAuth validates a mirror of a private registry which may be
+// sent the credentials of the registry, as in
+// "registry.example.com=https://mirror.example.com".
+func ValidateMirrorWithAuth(val string) (string, error) {
+	mirror, err := ValidateMirror(val)
+	if err != nil {
+		return "", err
+	}
+	if indexName, _ := splitMirror(mirror); indexName == IndexName {
+		return "", fmt.Errorf("Missing private registry name in mirror %s", val)
+	}
+	return mirror, nil
+}
+
+// splitMirror splits a mirror validated by ValidateMirror into the name of
+// the registry it mirrors and its URI.
+func splitMirror(val string) (indexName, mirror string) {
+	if i := strings.Index(val, "="); i >= 0 {
+		return val[:i], val[i+1:]
+	}
+	return IndexName, val
 }
 
 // ValidateIndexName validates an index name.
@@ -16,6 +16,8 @@ func TestValidateMirror(t *testing.T) {
 		"https://127.0.0.1",
 		"http://127.0.0.1:5000",
 		"https://127.0.0.1:5000",
+		"registry.example.com=https://mirror-1.com",
+		"registry.example.com:5000=http://localhost:5000",
 	}
 
 	invalid := []string{
@@ -33,6 +35,10 @@ func TestValidateMirror(t *testing.T) {
 		"https://mirror-1.com/v1/",
 		"https://mirror-1.com/v1/#",
 		"https://mirror-1.com?q",
+		"=https://mirror-1.com",
+		"-registry.example.com=https://mirror-1.com",
+		"registry.example.com=ftp://mirror-1.com",
+		"registry.example.com=https://mirror-1.com/v1/",
 	}
 
 	for _, address := range valid {
@@ -46,4 +52,16 @@ func TestValidateMirror(t *testing.T) {
 			t.Errorf("ValidateMirror(`"+address+"`) got %s %s", ret, err)
 		}
 	}
+
+	expected := map[string]string{
+		"https://mirror-1.com":                      "https://mirror-1.com/",
+		"docker.io=https://mirror-1.com":            "https://mirror-1.com/",
+		"index.docker.io=https://mirror-1.com":      "https://mirror-1.com/",
+		"registry.example.com=https://mirror-1.com": "registry.example.com=https://mirror-1.com/",
+	}
+	for address, mirror := range expected {
+		if ret, err := ValidateMirror(address); err != nil || ret != mirror {
+			t.Errorf("ValidateMirror(`"+address+"`) got %s %s, expected %s", ret, err, mirror)
+		}
+	}
 }
@@ -175,7 +175,9 @@ func makePublicIndex() *IndexInfo {
 func makeServiceConfig(mirrors []string, insecureRegistries []string) *ServiceConfig {
 	options := &Options{
 		Mirrors:            opts.NewListOpts(nil),
+		MirrorsWithAuth:    opts.NewListOpts(nil),
 		InsecureRegistries: opts.NewListOpts(nil),
+		RequireDigest:      opts.NewListOpts(nil),
 	}
 	if mirrors != nil {
 		for _, mirror := range mirrors {
@@ -10,6 +10,7 @@ import (
 
 	"github.com/docker/distribution/registry/client/transport"
 	"github.com/docker/docker/cliconfig"
+	"github.com/docker/docker/opts"
 )
 
 var (
@@ -706,6 +707,132 @@ func TestMirrorEndpointLookup(t *testing.T) {
 	}
 }
 
+func TestRegistryMirrorEndpointLookup(t *testing.T) {
+	s := Service{Config: makeServiceConfig([]string{
+		"http://official.mirror/",
+		"registry.corp=https://mirror1.corp/",
+		"registry.corp=http://mirror2.corp/",
+	}, nil)}
+
+	pullAPIEndpoints, err := s.LookupPullEndpoints("registry.corp/test/image")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []string{"https://mirror1.corp/", "http://mirror2.corp/", "https://registry.corp", "https://registry.corp"}
+	if len(pullAPIEndpoints) != len(expected) {
+		t.Fatalf("Expected %d pull endpoints, got %v", len(expected), pullAPIEndpoints)
+	}
+	for i, pe := range pullAPIEndpoints {
+		if pe.URL != expected[i] {
+			t.Fatalf("Expected pull endpoint %d to be %s, got %s", i, expected[i], pe.URL)
+		}
+		if mirror := i < 2; pe.Mirror != mirror {
+			t.Fatalf("Expected pull endpoint %s mirror to be %v", pe.URL, mirror)
+		}
+	}
+
+	pushAPIEndpoints, err := s.LookupPushEndpoints("registry.corp/test/image")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, pe := range pushAPIEndpoints {
+		if pe.Mirror {
+			t.Fatalf("Push endpoint should not contain mirror %s", pe.URL)
+		}
+	}
+
+	pullAPIEndpoints, err = s.LookupPullEndpoints("other.corp/test/image")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, pe := range pullAPIEndpoints {
+		if pe.Mirror {
+			t.Fatalf("Pull endpoint of other.corp should not contain mirror %s", pe.URL)
+		}
+	}
+
+	index, err := s.Config.NewIndexInfo("registry.corp")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !index.Secure || len(index.Mirrors) != 2 {
+		t.Fatalf("Unexpected index info for registry.corp: %v", index)
+	}
+	if len(s.Config.Mirrors) != 1 || s.Config.Mirrors[0] != "http://official.mirror/" {
+		t.Fatalf("Unexpected official mirrors: %v", s.Config.Mirrors)
+	}
+}
+
+func TestRegistryMirrorAuth(t *testing.T) {
+	options := &Options{
+		Mirrors:            opts.NewListOpts(ValidateMirror),
+		MirrorsWithAuth:    opts.NewListOpts(ValidateMirrorWithAuth),
+		InsecureRegistries: opts.NewListOpts(nil),
+		RequireDigest:      opts.NewListOpts(nil),
+	}
+	options.Mirrors.Set("https://official.mirror")
+	options.Mirrors.Set("registry.corp=https://mirror1.corp")
+	options.Mirrors.Set("registry.corp=https://mirror2.corp")
+	if err := options.MirrorsWithAuth.Set("registry.corp=https://mirror2.corp"); err != nil {
+		t.Fatal(err)
+	}
+	if err := options.MirrorsWithAuth.Set("https://official.mirror"); err == nil {
+		t.Fatal("Expected an error for a mirror of Docker Hub")
+	}
+	s := Service{Config: NewServiceConfig(options)}
+
+	pullAPIEndpoints, err := s.LookupPullEndpoints("registry.corp/test/image")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, noAuth := range []bool{true, false, false, false} {
+		if pullAPIEndpoints[i].NoAuth != noAuth {
+			t.Fatalf("Expected pull endpoint %s NoAuth to be %v", pullAPIEndpoints[i].URL, noAuth)
+		}
+	}
+
+	pullAPIEndpoints, err = s.LookupPullEndpoints("docker.io/test/image")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !pullAPIEndpoints[0].Mirror || pullAPIEndpoints[0].NoAuth {
+		t.Fatalf("Expected the Docker Hub mirror to be sent the credentials, got %v", pullAPIEndpoints[0])
+	}
+}
+
+func TestRequireDigest(t *testing.T) {
+	options := &Options{
+		Mirrors:            opts.NewListOpts(nil),
+		MirrorsWithAuth:    opts.NewListOpts(nil),
+		InsecureRegistries: opts.NewListOpts(nil),
+		RequireDigest:      opts.NewListOpts(ValidateIndexName),
+	}
+	options.InsecureRegistries.Set("insecure.corp")
+	options.RequireDigest.Set("index.docker.io")
+	options.RequireDigest.Set("insecure.corp")
+	options.RequireDigest.Set("registry.corp:5000")
+	config := NewServiceConfig(options)
+
+	for indexName, expected := range map[string]bool{
+		IndexName:            true,
+		"insecure.corp":      true,
+		"registry.corp:5000": true,
+		"registry.corp":      false,
+		"other.corp":         false,
+	} {
+		index, err := config.NewIndexInfo(indexName)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if index.RequireDigest != expected {
+			t.Fatalf("Expected RequireDigest of %s to be %v", indexName, expected)
+		}
+	}
+	if index := config.IndexConfigs["insecure.corp"]; index.Secure {
+		t.Fatal("Expected insecure.corp to remain insecure")
+	}
+}
+
 func TestPushRegistryTag(t *testing.T) {
 	r := spawnTestRegistrySession(t)
 	err := r.PushRegistryTag("foo42/bar", imageID, "stable", makeURL("/v1/"))
@@ -744,7 +871,7 @@ func TestPushImageJSONIndex(t *testing.T) {
 
 func TestSearchRepositories(t *testing.T) {
 	r := spawnTestRegistrySession(t)
-	results, err := r.SearchRepositories("fakequery")
+	results, err := r.SearchRepositories("fakequery", DefaultSearchLimit)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -0,0 +1,240 @@
+package registry
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/Sirupsen/logrus"
+	"github.com/docker/docker/cliconfig"
+	"github.com/docker/docker/pkg/httputils"
+	"github.com/docker/docker/pkg/parsers/filters"
+)
+
+const (
+	// DefaultSearchLimit is the number of results a search returns when
+	// no limit is given.
+	DefaultSearchLimit = 25
+	// MaxSearchLimit is the maximum number of results a search returns.
+	MaxSearchLimit = 100
+
+	// catalogPageSize is the number of repositories requested for each
+	// page of the catalog of a v2 registry.
+	catalogPageSize = 100
+)
+
+var acceptedSearchFilters = map[string]bool{
+	"is-automated": true,
+	"is-official":  true,
+	"stars":        true,
+}
+
+// ValidateSearchLimit checks that limit is within the range of the number
+// of results a search can return.
+func ValidateSearchLimit(limit int) error {
+	if limit < 1 || limit > MaxSearchLimit {
+		return fmt.Errorf("Limit %d is outside the range of [1, %d]", limit, MaxSearchLimit)
+	}
+	return nil
+}
+
+// filterAndLimitSearchResults keeps at most limit of the results matching the
+// filters, as registries may return more results than asked for, and updates
+// the count of the results accordingly.
+func filterAndLimitSearchResults(results *SearchResults, searchFilters filters.Args, limit int) error {
+	filtered, err := filterSearchResults(results.Results, searchFilters)
+	if err != nil {
+		return err
+	}
+	if len(filtered) > limit {
+		filtered = filtered[:limit]
+	}
+	results.Results = filtered
+	results.NumResults = len(filtered)
+	return nil
+}
+
+// filterSearchResults returns the results matching all of the is-automated,
+// is-official and stars filters.
+func filterSearchResults(results []SearchResult, searchFilters filters.Args) ([]SearchResult, error) {
+	for name := range searchFilters {
+		if !acceptedSearchFilters[name] {
+			return nil, fmt.Errorf("Invalid filter '%s'", name)
+		}
+	}
+
+	var (
+		isAutomated, isOfficial *bool
+		stars                   int
+	)
+	for _, value := range searchFilters["is-automated"] {
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return nil, fmt.Errorf("Invalid filter 'is-automated=%s'", value)
+		}
+		isAutomated = &b
+	}
+	for _, value := range searchFilters["is-official"] {
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return nil, fmt.Errorf("Invalid filter 'is-official=%s'", value)
+		}
+		isOfficial = &b
+	}
+	for _, value := range searchFilters["stars"] {
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 0 {
+			return nil, fmt.Errorf("Invalid filter 'stars=%s'", value)
+		}
+		if n > stars {
+			stars = n
+		}
+	}
+
+	filtered := []SearchResult{}
+	for _, result := range results {
+		if isAutomated != nil && *isAutomated != (result.IsAutomated || result.IsTrusted) {
+			continue
+		}
+		if isOfficial != nil && *isOfficial != result.IsOfficial {
+			continue
+		}
+		if result.StarCount < stars {
+			continue
+		}
+		filtered = append(filtered, result)
+	}
+	return filtered, nil
+}
+
+// v2Search searches a v2 registry, which has no search endpoint. The
+// repositories are listed from the catalog of the registry and filtered
+// on the client side.
+type v2Search struct {
+	endpoint   *Endpoint
+	authConfig *cliconfig.AuthConfig
+	// tokens fetches and caches the bearer tokens of the registry.
+	tokens *tokenHandler
+}
+
+// searchV2 returns the repositories of the catalog of a v2 registry whose
+// name contains term, along with their tags, up to limit results. The
+// names of the results are prefixed with indexName, so that they can be
+// pulled as is.
+func searchV2(endpoint *Endpoint, authConfig *cliconfig.AuthConfig, indexName, term string, limit int) (*SearchResults, error) {
+	s := &v2Search{
+		endpoint:   endpoint,
+		authConfig: authConfig,
+		tokens:     newTokenHandler(endpoint.client, authConfig, endpoint.IsSecure),
+	}
+	results := &SearchResults{Query: term, Results: []SearchResult{}}
+	term = strings.ToLower(term)
+
+	last := ""
+	for {
+		repos, more, err := s.catalog(last)
+		if err != nil {
+			return nil, err
+		}
+		for _, repo := range repos {
+			if !strings.Contains(strings.ToLower(repo), term) {
+				continue
+			}
+			result := SearchResult{Name: indexName + "/" + repo}
+			// The catalog may list repositories the user can't pull
+			// from, only omit their tags.
+			if tags, err := s.tags(repo); err != nil {
+				logrus.Debugf("Error listing the tags of %s: %v", repo, err)
+			} else if len(tags) > 0 {
+				result.Description = "Tags: " + strings.Join(tags, ", ")
+			}
+			results.Results = append(results.Results, result)
+			if len(results.Results) == limit {
+				more = false
+				break
+			}
+		}
+		if !more || len(repos) == 0 {
+			break
+		}
+		last = repos[len(repos)-1]
+	}
+	results.NumResults = len(results.Results)
+	return results, nil
+}
+
+// catalog returns the page of the catalog following the repository last,
+// and whether more pages follow it.
+func (s *v2Search) catalog(last string) ([]string, bool, error) {
+	v := url.Values{}
+	v.Set("n", strconv.Itoa(catalogPageSize))
+	if last != "" {
+		v.Set("last", last)
+	}
+	var catalog struct {
+		Repositories []string `json:"repositories"`
+	}
+	res, err := s.get(s.endpoint.Path("_catalog?"+v.Encode()), "registry:catalog:*", &catalog)
+	if err != nil {
+		return nil, false, err
+	}
+	// The registry links to the next page while there is one.
+	return catalog.Repositories, res.Header.Get("Link") != "", nil
+}
+
+// tags returns the tags of the repository name.
+func (s *v2Search) tags(name string) ([]string, error) {
+	var tags struct {
+		Tags []string `json:"tags"`
+	}
+	if _, err := s.get(s.endpoint.Path(name+"/tags/list"), RepositoryScope(name, "pull"), &tags); err != nil {
+		return nil, err
+	}
+	return tags.Tags, nil
+}
+
+// get decodes the JSON response to a GET of u into v, authorizing the
+// request for scope.
+func (s *v2Search) get(u, scope string, v interface{}) (*http.Response, error) {
+	req, err := http.NewRequest("GET", u, nil)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.authorize(req, scope); err != nil {
+		return nil, err
+	}
+	res, err := s.endpoint.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return nil, httputils.NewHTTPRequestError(fmt.Sprintf("Unexpected status code %d from %s", res.StatusCode, u), res)
+	}
+	return res, json.NewDecoder(res.Body).Decode(v)
+}
+
+// authorize authorizes req for scope using the authentication schemes the
+// registry challenged with when it was pinged.
+func (s *v2Search) authorize(req *http.Request, scope string) error {
+	for _, challenge := range s.endpoint.AuthChallenges {
+		switch strings.ToLower(challenge.Scheme) {
+		case "basic":
+			if s.authConfig.Username != "" {
+				req.SetBasicAuth(s.authConfig.Username, s.authConfig.Password)
+			}
+			return nil
+		case "bearer":
+			token, err := s.tokens.token(challenge.Parameters, scope)
+			if err != nil {
+				return err
+			}
+			req.Header.Set("Authorization", "Bearer "+token)
+			return nil
+		}
+	}
+	return nil
+}
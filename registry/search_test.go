@@ -0,0 +1,150 @@
+package registry
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/docker/docker/cliconfig"
+	"github.com/docker/docker/pkg/parsers/filters"
+)
+
+func TestSearchV2(t *testing.T) {
+	catalog := []string{"bar/baz", "foo/bar", "foo/baz", "qux", "team/foo"}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/token" {
+			json.NewEncoder(w).Encode(map[string]string{"token": r.URL.Query().Get("scope")})
+			return
+		}
+		scope := "registry:catalog:*"
+		if r.URL.Path != "/v2/_catalog" {
+			scope = "repository:foo/bar:pull"
+		}
+		if r.Header.Get("Authorization") != "Bearer "+scope {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		switch r.URL.Path {
+		case "/v2/_catalog":
+			// Serve pages of two repositories.
+			var page []string
+			for _, repo := range catalog {
+				if repo > r.URL.Query().Get("last") && len(page) < 2 {
+					page = append(page, repo)
+				}
+			}
+			if len(page) == 2 && page[1] != catalog[len(catalog)-1] {
+				w.Header().Set("Link", `</v2/_catalog?last=`+page[1]+`&n=2>; rel="next"`)
+			}
+			json.NewEncoder(w).Encode(map[string][]string{"repositories": page})
+		case "/v2/foo/bar/tags/list":
+			json.NewEncoder(w).Encode(map[string]interface{}{"name": "foo/bar", "tags": []string{"1.0", "latest"}})
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer srv.Close()
+
+	u, _ := url.Parse(srv.URL)
+	endpoint := &Endpoint{
+		client:  http.DefaultClient,
+		URL:     u,
+		Version: APIVersion2,
+		AuthChallenges: []*AuthorizationChallenge{
+			{Scheme: "Bearer", Parameters: map[string]string{"realm": srv.URL + "/token"}},
+		},
+	}
+
+	results, err := searchV2(endpoint, &cliconfig.AuthConfig{}, "registry.corp", "FOO", DefaultSearchLimit)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertEqual(t, results.NumResults, 3, "Expected 3 search results")
+	assertEqual(t, results.Query, "FOO", "Expected 'FOO' as query")
+	assertEqual(t, results.Results[0].Name, "registry.corp/foo/bar", "Expected the results to be prefixed with the registry")
+	assertEqual(t, results.Results[0].Description, "Tags: 1.0, latest", "Expected the tags of foo/bar")
+	assertEqual(t, results.Results[1].Description, "", "Expected no tags for foo/baz")
+	assertEqual(t, results.Results[2].Name, "registry.corp/team/foo", "Expected the last page of the catalog to be searched")
+
+	results, err = searchV2(endpoint, &cliconfig.AuthConfig{}, "registry.corp", "foo", 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertEqual(t, results.NumResults, 1, "Expected the results to be limited")
+}
+
+func TestFilterSearchResults(t *testing.T) {
+	results := []SearchResult{
+		{Name: "official", IsOfficial: true, StarCount: 100},
+		{Name: "automated", IsAutomated: true, StarCount: 10},
+		{Name: "trusted", IsTrusted: true},
+		{Name: "other", StarCount: 50},
+	}
+
+	tests := []struct {
+		filters  filters.Args
+		expected []string
+	}{
+		{filters.Args{}, []string{"official", "automated", "trusted", "other"}},
+		{filters.Args{"is-official": {"true"}}, []string{"official"}},
+		{filters.Args{"is-official": {"false"}}, []string{"automated", "trusted", "other"}},
+		{filters.Args{"is-automated": {"true"}}, []string{"automated", "trusted"}},
+		{filters.Args{"stars": {"10"}}, []string{"official", "automated", "other"}},
+		{filters.Args{"stars": {"10"}, "is-official": {"false"}}, []string{"automated", "other"}},
+	}
+	for _, test := range tests {
+		filtered, err := filterSearchResults(results, test.filters)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(filtered) != len(test.expected) {
+			t.Fatalf("Expected %v for %v, got %v", test.expected, test.filters, filtered)
+		}
+		for i, name := range test.expected {
+			assertEqual(t, filtered[i].Name, name, "Unexpected result")
+		}
+	}
+
+	for _, invalid := range []filters.Args{
+		{"name": {"foo"}},
+		{"is-official": {"yes please"}},
+		{"stars": {"-1"}},
+	} {
+		if _, err := filterSearchResults(results, invalid); err == nil {
+			t.Fatalf("Expected an error for the filters %v", invalid)
+		}
+	}
+}
+
+func TestValidateSearchLimit(t *testing.T) {
+	for _, limit := range []int{1, DefaultSearchLimit, MaxSearchLimit} {
+		if err := ValidateSearchLimit(limit); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, limit := range []int{-1, 0, MaxSearchLimit + 1} {
+		if err := ValidateSearchLimit(limit); err == nil {
+			t.Fatalf("Expected an error for the limit %d", limit)
+		}
+	}
+}
+
+func TestFilterAndLimitSearchResults(t *testing.T) {
+	results := &SearchResults{
+		NumResults: 120,
+		Results: []SearchResult{
+			{Name: "official", IsOfficial: true},
+			{Name: "other1"},
+			{Name: "other2"},
+			{Name: "other3"},
+		},
+	}
+	if err := filterAndLimitSearchResults(results, filters.Args{"is-official": {"false"}}, 2); err != nil {
+		t.Fatal(err)
+	}
+	assertEqual(t, len(results.Results), 2, "Expected the results to be limited")
+	assertEqual(t, results.Results[0].Name, "other1", "Unexpected result")
+	assertEqual(t, results.NumResults, 2, "Expected the count to match the results")
+}
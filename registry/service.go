@@ -10,6 +10,7 @@ import (
 
 	"github.com/docker/distribution/registry/client/auth"
 	"github.com/docker/docker/cliconfig"
+	"github.com/docker/docker/pkg/parsers/filters"
 	"github.com/docker/docker/pkg/tlsconfig"
 )
 
@@ -28,9 +29,10 @@ func NewService(options *Options) *Service {
 }
 
 // Auth contacts the public registry with the provided credentials,
-// and returns OK if authentication was successful.
+// and returns OK if authentication was successful, along with the identity
+// token the registry issued, if any.
 // It can be used to verify the validity of a client's credentials.
-func (s *Service) Auth(authConfig *cliconfig.AuthConfig) (string, error) {
+func (s *Service) Auth(authConfig *cliconfig.AuthConfig) (string, string, error) {
 	addr := authConfig.ServerAddress
 	if addr == "" {
 		// Use the official registry address if not specified.
@@ -38,19 +40,31 @@ func (s *Service) Auth(authConfig *cliconfig.AuthConfig) (string, error) {
 	}
 	index, err := s.ResolveIndex(addr)
 	if err != nil {
-		return "", err
+		return "", "", err
 	}
 	endpoint, err := NewEndpoint(index, nil)
 	if err != nil {
-		return "", err
+		return "", "", err
 	}
 	authConfig.ServerAddress = endpoint.String()
 	return Login(authConfig, endpoint)
 }
 
-// Search queries the public registry for images matching the specified
-// search terms, and returns the results.
-func (s *Service) Search(term string, authConfig *cliconfig.AuthConfig, headers map[string][]string) (*SearchResults, error) {
+// Search queries the registry of term for images matching the specified
+// search terms, and returns at most limit results matching searchFilters.
+// The Docker Hub and v1 registries are searched through their search
+// endpoint, the catalog of v2 registries is searched on the client side.
+func (s *Service) Search(term string, limit int, searchFilters filters.Args, authConfig *cliconfig.AuthConfig, headers map[string][]string) (*SearchResults, error) {
+	if err := ValidateSearchLimit(limit); err != nil {
+		return nil, err
+	}
+	// Check the filters before querying the registry.
+	if _, err := filterSearchResults(nil, searchFilters); err != nil {
+		return nil, err
+	}
+	if authConfig == nil {
+		authConfig = &cliconfig.AuthConfig{}
+	}
 	repoInfo, err := s.ResolveRepository(term)
 	if err != nil {
 		return nil, err
@@ -61,11 +75,23 @@ func (s *Service) Search(term string, authConfig *cliconfig.AuthConfig, headers
 	if err != nil {
 		return nil, err
 	}
-	r, err := NewSession(endpoint.client, authConfig, endpoint)
+	var results *SearchResults
+	if endpoint.Version == APIVersion2 {
+		results, err = searchV2(endpoint, authConfig, repoInfo.Index.Name, repoInfo.GetSearchTerm(), limit)
+	} else {
+		var r *Session
+		if r, err = NewSession(endpoint.client, authConfig, endpoint); err != nil {
+			return nil, err
+		}
+		results, err = r.SearchRepositories(repoInfo.GetSearchTerm(), limit)
+	}
 	if err != nil {
 		return nil, err
 	}
-	return r.SearchRepositories(repoInfo.GetSearchTerm())
+	if err := filterAndLimitSearchResults(results, searchFilters, limit); err != nil {
+		return nil, err
+	}
+	return results, nil
 }
 
 // ResolveRepository splits a repository name into its components
@@ -82,6 +108,7 @@ func (s *Service) ResolveIndex(name string) (*IndexInfo, error) {
 // APIEndpoint represents a remote API endpoint
 type APIEndpoint struct {
 	Mirror        bool
+	NoAuth        bool
 	URL           string
 	Version       APIVersion
 	Official      bool
@@ -131,24 +158,42 @@ func (s *Service) LookupPushEndpoints(repoName string) (endpoints []APIEndpoint,
 	return endpoints, err
 }
 
+// mirrorEndpoints returns the endpoints of the given mirrors, in order. The
+// mirrors missing from authMirrors get NoAuth, so that they aren't sent the
+// credentials of the registry they mirror.
+func (s *Service) mirrorEndpoints(mirrors, authMirrors []string) (endpoints []APIEndpoint, err error) {
+	for _, mirror := range mirrors {
+		noAuth := true
+		for _, authMirror := range authMirrors {
+			if mirror == authMirror {
+				noAuth = false
+			}
+		}
+		mirrorTLSConfig, err := s.tlsConfigForMirror(mirror)
+		if err != nil {
+			return nil, err
+		}
+		endpoints = append(endpoints, APIEndpoint{
+			URL: mirror,
+			// guess mirrors are v2
+			Version:      APIVersion2,
+			Mirror:       true,
+			NoAuth:       noAuth,
+			TrimHostname: true,
+			TLSConfig:    mirrorTLSConfig,
+		})
+	}
+	return endpoints, nil
+}
+
 func (s *Service) lookupEndpoints(repoName string) (endpoints []APIEndpoint, err error) {
 	var cfg = tlsconfig.ServerDefault
 	tlsConfig := &cfg
 	if strings.HasPrefix(repoName, DefaultNamespace+"/") {
-		// v2 mirrors
-		for _, mirror := range s.Config.Mirrors {
-			mirrorTLSConfig, err := s.tlsConfigForMirror(mirror)
-			if err != nil {
-				return nil, err
-			}
-			endpoints = append(endpoints, APIEndpoint{
-				URL: mirror,
-				// guess mirrors are v2
-				Version:      APIVersion2,
-				Mirror:       true,
-				TrimHostname: true,
-				TLSConfig:    mirrorTLSConfig,
-			})
+		// v2 mirrors, which have always been sent the Docker Hub credentials
+		endpoints, err = s.mirrorEndpoints(s.Config.Mirrors, s.Config.Mirrors)
+		if err != nil {
+			return nil, err
 		}
 		// v2 registry
 		endpoints = append(endpoints, APIEndpoint{
@@ -183,13 +228,21 @@ func (s *Service) lookupEndpoints(repoName string) (endpoints []APIEndpoint, err
 	}
 	isSecure := !tlsConfig.InsecureSkipVerify
 
+	// v2 mirrors of the registry
+	if index, ok := s.Config.IndexConfigs[hostname]; ok {
+		endpoints, err = s.mirrorEndpoints(index.Mirrors, index.AuthMirrors)
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	v2Versions := []auth.APIVersion{
 		{
 			Type:    "registry",
 			Version: "2.0",
 		},
 	}
-	endpoints = []APIEndpoint{
+	endpoints = append(endpoints, []APIEndpoint{
 		{
 			URL:           "https://" + hostname,
 			Version:       APIVersion2,
@@ -204,7 +257,7 @@ func (s *Service) lookupEndpoints(repoName string) (endpoints []APIEndpoint, err
 			TrimHostname: true,
 			TLSConfig:    tlsConfig,
 		},
-	}
+	}...)
 
 	if !isSecure {
 		endpoints = append(endpoints, APIEndpoint{
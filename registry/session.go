@@ -723,10 +723,11 @@ func shouldRedirect(response *http.Response) bool {
 	return response.StatusCode >= 300 && response.StatusCode < 400
 }
 
-// SearchRepositories performs a search against the remote repository
-func (r *Session) SearchRepositories(term string) (*SearchResults, error) {
+// SearchRepositories performs a search against the remote repository,
+// asking for at most limit results.
+func (r *Session) SearchRepositories(term string, limit int) (*SearchResults, error) {
 	logrus.Debugf("Index server: %s", r.indexEndpoint)
-	u := r.indexEndpoint.VersionString(1) + "search?q=" + url.QueryEscape(term)
+	u := r.indexEndpoint.VersionString(1) + "search?q=" + url.QueryEscape(term) + "&n=" + url.QueryEscape(strconv.Itoa(limit))
 
 	req, err := http.NewRequest("GET", u, nil)
 	if err != nil {
@@ -7,39 +7,293 @@ import (
 	"net/http"
 	"net/url"
 	"strings"
+	"sync"
+	"time"
+
+	"github.com/Sirupsen/logrus"
+	"github.com/docker/distribution/registry/client/auth"
+	"github.com/docker/docker/cliconfig"
+)
+
+const (
+	// clientID identifies the docker client to the token servers.
+	clientID = "docker"
+	// minTokenLifetime is the lifetime of the tokens whose token server
+	// doesn't tell when they expire, or tells an earlier expiration.
+	minTokenLifetime = 60 * time.Second
 )
 
+// errOAuthUnsupported is returned for the token servers answering the OAuth2
+// password flow with an error status, which may only issue tokens for basic
+// credentials.
+var errOAuthUnsupported = errors.New("the token server doesn't support OAuth2")
+
+// rotatedRefreshTokens maps the refresh tokens the clients hold to the last
+// refresh token issued in their place, for the token servers which rotate
+// refresh tokens on use: the token a client stored at login is no longer
+// valid once the daemon used it. The entries are forgotten once unused for
+// rotatedRefreshTokenLifetime, after which the client has to log in again.
+var rotatedRefreshTokens = struct {
+	sync.Mutex
+	m map[refreshTokenKey]*rotatedRefreshToken
+}{m: make(map[refreshTokenKey]*rotatedRefreshToken)}
+
+// rotatedRefreshTokenLifetime is how long a rotated refresh token is
+// remembered after its last use.
+const rotatedRefreshTokenLifetime = 7 * 24 * time.Hour
+
+// refreshTokenKey identifies a refresh token by the token server which
+// issued it and the user it was issued to.
+type refreshTokenKey struct {
+	realm    string
+	username string
+	token    string
+}
+
+type rotatedRefreshToken struct {
+	token   string
+	expires time.Time
+}
+
+// currentRefreshToken returns the refresh token to use in place of held.
+func currentRefreshToken(held refreshTokenKey) string {
+	rotatedRefreshTokens.Lock()
+	defer rotatedRefreshTokens.Unlock()
+	now := time.Now()
+	if current, ok := rotatedRefreshTokens.m[held]; ok && now.Before(current.expires) {
+		current.expires = now.Add(rotatedRefreshTokenLifetime)
+		return current.token
+	}
+	return held.token
+}
+
+// rotateRefreshToken records that the token server issued the refresh token
+// issued when used was used.
+func rotateRefreshToken(used refreshTokenKey, issued string) {
+	if used.token == "" || used.token == issued {
+		return
+	}
+	rotatedRefreshTokens.Lock()
+	defer rotatedRefreshTokens.Unlock()
+	now := time.Now()
+	rotated := false
+	for held, current := range rotatedRefreshTokens.m {
+		switch {
+		case now.After(current.expires):
+			delete(rotatedRefreshTokens.m, held)
+		case held.realm == used.realm && held.username == used.username && current.token == used.token:
+			current.token = issued
+			current.expires = now.Add(rotatedRefreshTokenLifetime)
+			rotated = true
+		}
+	}
+	if !rotated {
+		rotatedRefreshTokens.m[used] = &rotatedRefreshToken{
+			token:   issued,
+			expires: now.Add(rotatedRefreshTokenLifetime),
+		}
+	}
+}
+
 type tokenResponse struct {
-	Token string `json:"token"`
+	Token        string `json:"token"`
+	AccessToken  string `json:"access_token"`
+	RefreshToken string `json:"refresh_token"`
+	ExpiresIn    int    `json:"expires_in"`
+}
+
+type cachedToken struct {
+	token   string
+	expires time.Time
+}
+
+// tokenHandler fetches bearer tokens from the token server of a registry,
+// and caches them by scope until they expire. Given the identity token of
+// a user, it gets its tokens with the OAuth2 refresh token flow instead of
+// sending the username and password of the user to the token server.
+type tokenHandler struct {
+	client     *http.Client
+	authConfig *cliconfig.AuthConfig
+	// secure is whether to reach the token server with https when the
+	// realm of the registry has no scheme.
+	secure bool
+	// offline asks the token server for a refresh token along with the
+	// tokens fetched with the username and password of the user.
+	offline bool
+	// scope is the scope of the tokens requests are authorized with.
+	scope string
+
+	mu     sync.Mutex
+	tokens map[string]cachedToken
+	// refreshToken is the last refresh token issued by the token server.
+	refreshToken string
+}
+
+// NewTokenHandler returns an authentication handler authorizing requests
+// with bearer tokens for all of the given scopes.
+func NewTokenHandler(transport http.RoundTripper, authConfig *cliconfig.AuthConfig, scopes ...string) auth.AuthenticationHandler {
+	client := &http.Client{
+		Transport: transport,
+		Timeout:   15 * time.Second,
+	}
+	th := newTokenHandler(client, authConfig, true)
+	th.scope = strings.Join(scopes, " ")
+	return th
+}
+
+// RepositoryScope returns the scope of the tokens allowing the given actions
+// on the repository name.
+func RepositoryScope(name string, actions ...string) string {
+	return fmt.Sprintf("repository:%s:%s", name, strings.Join(actions, ","))
+}
+
+func newTokenHandler(client *http.Client, authConfig *cliconfig.AuthConfig, secure bool) *tokenHandler {
+	if authConfig == nil {
+		authConfig = &cliconfig.AuthConfig{}
+	}
+	return &tokenHandler{
+		client:     client,
+		authConfig: authConfig,
+		secure:     secure,
+		tokens:     make(map[string]cachedToken),
+	}
+}
+
+func (th *tokenHandler) Scheme() string {
+	return "bearer"
+}
+
+func (th *tokenHandler) AuthorizeRequest(req *http.Request, params map[string]string) error {
+	token, err := th.token(params, th.scope)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	return nil
 }
 
-func getToken(username, password string, params map[string]string, registryEndpoint *Endpoint) (string, error) {
+// token returns a token for scope, fetched from the token server of the
+// challenge params unless a cached one hasn't expired yet.
+func (th *tokenHandler) token(params map[string]string, scope string) (string, error) {
+	th.mu.Lock()
+	defer th.mu.Unlock()
+
+	now := time.Now()
+	if t, ok := th.tokens[scope]; ok && now.Before(t.expires) {
+		return t.token, nil
+	}
+
+	used := refreshTokenKey{
+		realm:    params["realm"],
+		username: th.authConfig.Username,
+		token:    th.refreshToken,
+	}
+	if used.token == "" {
+		used.token = currentRefreshToken(refreshTokenKey{
+			realm:    used.realm,
+			username: used.username,
+			token:    th.authConfig.IdentityToken,
+		})
+	}
+	refreshToken := used.token
+	tr, err := th.fetchToken(params, scope, refreshToken)
+	if err != nil {
+		return "", err
+	}
+	lifetime := time.Duration(tr.ExpiresIn) * time.Second
+	if lifetime < minTokenLifetime {
+		lifetime = minTokenLifetime
+	}
+	th.tokens[scope] = cachedToken{token: tr.Token, expires: now.Add(lifetime)}
+	if tr.RefreshToken != "" {
+		rotateRefreshToken(used, tr.RefreshToken)
+		th.refreshToken = tr.RefreshToken
+	}
+	return tr.Token, nil
+}
+
+// identityToken returns the refresh token to store for the user, if any.
+func (th *tokenHandler) identityToken() string {
+	th.mu.Lock()
+	defer th.mu.Unlock()
+	if th.refreshToken != "" {
+		return th.refreshToken
+	}
+	return th.authConfig.IdentityToken
+}
+
+// fetchToken fetches a token for scope with the given refresh token, or with
+// the credentials of the user if it is empty.
+func (th *tokenHandler) fetchToken(params map[string]string, scope, refreshToken string) (*tokenResponse, error) {
 	realm, ok := params["realm"]
 	if !ok {
-		return "", errors.New("no realm specified for token auth challenge")
+		return nil, errors.New("no realm specified for token auth challenge")
 	}
 
 	realmURL, err := url.Parse(realm)
 	if err != nil {
-		return "", fmt.Errorf("invalid token auth challenge realm: %s", err)
+		return nil, fmt.Errorf("invalid token auth challenge realm: %s", err)
 	}
 
 	if realmURL.Scheme == "" {
-		if registryEndpoint.IsSecure {
+		if th.secure {
 			realmURL.Scheme = "https"
 		} else {
 			realmURL.Scheme = "http"
 		}
 	}
 
+	if refreshToken != "" || (th.offline && th.authConfig.Password != "") {
+		tr, err := th.fetchOAuthToken(realmURL, params["service"], scope, refreshToken)
+		if err != errOAuthUnsupported {
+			return tr, err
+		}
+	}
+	return th.fetchBasicToken(realmURL, params["service"], scope)
+}
+
+// fetchOAuthToken gets a token with the OAuth2 refresh token flow, or with
+// the password flow when refreshToken is empty.
+func (th *tokenHandler) fetchOAuthToken(realmURL *url.URL, service, scope, refreshToken string) (*tokenResponse, error) {
+	form := url.Values{}
+	form.Set("scope", scope)
+	form.Set("service", service)
+	form.Set("client_id", clientID)
+	if refreshToken != "" {
+		form.Set("grant_type", "refresh_token")
+		form.Set("refresh_token", refreshToken)
+	} else {
+		form.Set("grant_type", "password")
+		form.Set("username", th.authConfig.Username)
+		form.Set("password", th.authConfig.Password)
+		// Ask for a refresh token.
+		form.Set("access_type", "offline")
+	}
+
+	resp, err := th.client.PostForm(realmURL.String(), form)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if (resp.StatusCode < 200 || resp.StatusCode >= 300) && refreshToken == "" {
+		logrus.Debugf("Token server %s rejected the OAuth2 password flow with status %d, falling back to basic credentials", realmURL, resp.StatusCode)
+		return nil, errOAuthUnsupported
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return nil, fmt.Errorf("token auth attempt for registry %s request failed with status: %d %s", realmURL, resp.StatusCode, http.StatusText(resp.StatusCode))
+	}
+	return decodeTokenResponse(resp)
+}
+
+// fetchBasicToken gets a token with the basic credentials of the user.
+func (th *tokenHandler) fetchBasicToken(realmURL *url.URL, service, scope string) (*tokenResponse, error) {
 	req, err := http.NewRequest("GET", realmURL.String(), nil)
 	if err != nil {
-		return "", err
+		return nil, err
 	}
 
 	reqParams := req.URL.Query()
-	service := params["service"]
-	scope := params["scope"]
 
 	if service != "" {
 		reqParams.Add("service", service)
@@ -49,33 +303,42 @@ func getToken(username, password string, params map[string]string, registryEndpo
 		reqParams.Add("scope", scopeField)
 	}
 
-	if username != "" {
-		reqParams.Add("account", username)
-		req.SetBasicAuth(username, password)
+	if th.authConfig.Username != "" {
+		reqParams.Add("account", th.authConfig.Username)
+		req.SetBasicAuth(th.authConfig.Username, th.authConfig.Password)
+	}
+
+	if th.offline {
+		reqParams.Add("offline_token", "true")
+		reqParams.Add("client_id", clientID)
 	}
 
 	req.URL.RawQuery = reqParams.Encode()
 
-	resp, err := registryEndpoint.client.Do(req)
+	resp, err := th.client.Do(req)
 	if err != nil {
-		return "", err
+		return nil, err
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		return "", fmt.Errorf("token auth attempt for registry %s: %s request failed with status: %d %s", registryEndpoint, req.URL, resp.StatusCode, http.StatusText(resp.StatusCode))
+		return nil, fmt.Errorf("token auth attempt for registry %s request failed with status: %d %s", req.URL, resp.StatusCode, http.StatusText(resp.StatusCode))
 	}
+	return decodeTokenResponse(resp)
+}
 
-	decoder := json.NewDecoder(resp.Body)
-
+func decodeTokenResponse(resp *http.Response) (*tokenResponse, error) {
 	tr := new(tokenResponse)
-	if err = decoder.Decode(tr); err != nil {
-		return "", fmt.Errorf("unable to decode token response: %s", err)
+	if err := json.NewDecoder(resp.Body).Decode(tr); err != nil {
+		return nil, fmt.Errorf("unable to decode token response: %s", err)
 	}
 
+	// OAuth2 token servers return an access token.
 	if tr.Token == "" {
-		return "", errors.New("authorization server did not include a token in the response")
+		tr.Token = tr.AccessToken
 	}
-
-	return tr.Token, nil
+	if tr.Token == "" {
+		return nil, errors.New("authorization server did not include a token in the response")
+	}
+	return tr, nil
 }
@@ -0,0 +1,174 @@
+package registry
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/docker/docker/cliconfig"
+)
+
+// newTestTokenServer returns a token server issuing the refresh token
+// "refresh" for the password "pass", and counting the tokens it issues. If
+// oauthStatus isn't 0, it answers OAuth2 requests with this status instead.
+func newTestTokenServer(t *testing.T, oauthStatus int, issued *int) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var tr tokenResponse
+		switch r.Method {
+		case "POST":
+			if oauthStatus != 0 {
+				w.WriteHeader(oauthStatus)
+				return
+			}
+			r.ParseForm()
+			if r.Form.Get("client_id") != clientID {
+				t.Errorf("Unexpected client id %q", r.Form.Get("client_id"))
+			}
+			switch r.Form.Get("grant_type") {
+			case "password":
+				if r.Form.Get("password") != "pass" || r.Form.Get("access_type") != "offline" {
+					w.WriteHeader(http.StatusUnauthorized)
+					return
+				}
+				tr.RefreshToken = "refresh"
+			case "refresh_token":
+				if r.Form.Get("refresh_token") != "refresh" {
+					w.WriteHeader(http.StatusUnauthorized)
+					return
+				}
+			}
+			tr.AccessToken = "oauth " + r.Form.Get("scope")
+			tr.ExpiresIn = 300
+		case "GET":
+			if _, password, _ := r.BasicAuth(); password != "pass" {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			tr.Token = "basic " + r.URL.Query().Get("scope")
+		}
+		*issued++
+		json.NewEncoder(w).Encode(tr)
+	}))
+}
+
+func TestTokenHandlerLogin(t *testing.T) {
+	var issued int
+	srv := newTestTokenServer(t, 0, &issued)
+	defer srv.Close()
+	params := map[string]string{"realm": srv.URL}
+
+	th := newTokenHandler(http.DefaultClient, &cliconfig.AuthConfig{Username: "user", Password: "pass"}, false)
+	th.offline = true
+	token, err := th.token(params, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertEqual(t, token, "oauth ", "Expected a token from the OAuth2 password flow")
+	assertEqual(t, th.identityToken(), "refresh", "Expected the refresh token to be the identity token")
+
+	// Without a password, the identity token gets the tokens.
+	th = newTokenHandler(http.DefaultClient, &cliconfig.AuthConfig{Username: "user", IdentityToken: "refresh"}, false)
+	if token, err = th.token(params, "repository:foo:pull"); err != nil {
+		t.Fatal(err)
+	}
+	assertEqual(t, token, "oauth repository:foo:pull", "Expected a token from the OAuth2 refresh token flow")
+
+	th = newTokenHandler(http.DefaultClient, &cliconfig.AuthConfig{Username: "user", IdentityToken: "expired"}, false)
+	if _, err = th.token(params, "repository:foo:pull"); err == nil {
+		t.Fatal("Expected an error for an invalid refresh token")
+	}
+}
+
+func TestTokenHandlerWithoutOAuth(t *testing.T) {
+	for _, status := range []int{http.StatusBadRequest, http.StatusUnauthorized, http.StatusNotFound, http.StatusMethodNotAllowed} {
+		var issued int
+		srv := newTestTokenServer(t, status, &issued)
+		params := map[string]string{"realm": srv.URL}
+
+		th := newTokenHandler(http.DefaultClient, &cliconfig.AuthConfig{Username: "user", Password: "pass"}, false)
+		th.offline = true
+		token, err := th.token(params, "")
+		srv.Close()
+		if err != nil {
+			t.Fatalf("Expected a fallback to basic credentials for the status %d: %v", status, err)
+		}
+		assertEqual(t, token, "basic ", "Expected a token for the basic credentials")
+		assertEqual(t, th.identityToken(), "", "Expected no identity token")
+	}
+}
+
+func TestTokenHandlerRotatedRefreshToken(t *testing.T) {
+	// The token server issues a new refresh token each time one is used,
+	// and only accepts the last one it issued.
+	current := "rotating0"
+	var n int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		if r.Form.Get("refresh_token") != current {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		n++
+		current = fmt.Sprintf("rotating%d", n)
+		json.NewEncoder(w).Encode(tokenResponse{AccessToken: "oauth", RefreshToken: current})
+	}))
+	defer srv.Close()
+	params := map[string]string{"realm": srv.URL}
+
+	// Each pull starts over with the identity token the client stored.
+	for i := 0; i < 3; i++ {
+		th := newTokenHandler(http.DefaultClient, &cliconfig.AuthConfig{IdentityToken: "rotating0"}, false)
+		if _, err := th.token(params, "repository:foo:pull"); err != nil {
+			t.Fatalf("Expected the rotated refresh token to be used: %v", err)
+		}
+		assertEqual(t, th.identityToken(), current, "Expected the last issued refresh token to be the identity token")
+	}
+
+	// The rotation doesn't apply to the same token held for another user.
+	th := newTokenHandler(http.DefaultClient, &cliconfig.AuthConfig{Username: "other", IdentityToken: "rotating0"}, false)
+	if _, err := th.token(params, "repository:foo:pull"); err == nil {
+		t.Fatal("Expected the refresh token of another user not to be rotated")
+	}
+
+	// The rotated refresh tokens are forgotten once they expire.
+	rotatedRefreshTokens.Lock()
+	for _, r := range rotatedRefreshTokens.m {
+		r.expires = time.Now()
+	}
+	rotatedRefreshTokens.Unlock()
+	th = newTokenHandler(http.DefaultClient, &cliconfig.AuthConfig{IdentityToken: "rotating0"}, false)
+	if _, err := th.token(params, "repository:foo:pull"); err == nil {
+		t.Fatal("Expected the expired rotated refresh token not to be used")
+	}
+	rotateRefreshToken(refreshTokenKey{realm: srv.URL, token: "unused"}, "issued")
+	rotatedRefreshTokens.Lock()
+	entries := len(rotatedRefreshTokens.m)
+	rotatedRefreshTokens.Unlock()
+	assertEqual(t, entries, 1, "Expected the expired rotated refresh tokens to be removed")
+}
+
+func TestTokenHandlerCache(t *testing.T) {
+	var issued int
+	srv := newTestTokenServer(t, 0, &issued)
+	defer srv.Close()
+	params := map[string]string{"realm": srv.URL}
+
+	th := newTokenHandler(http.DefaultClient, &cliconfig.AuthConfig{IdentityToken: "refresh"}, false)
+	for _, scope := range []string{"repository:foo:pull", "repository:bar:pull", "repository:foo:pull"} {
+		token, err := th.token(params, scope)
+		if err != nil {
+			t.Fatal(err)
+		}
+		assertEqual(t, token, "oauth "+scope, "Unexpected token")
+	}
+	assertEqual(t, issued, 2, "Expected a token to be fetched once for each scope")
+
+	req, _ := http.NewRequest("GET", "http://registry.example.com/v2/foo/manifests/latest", nil)
+	if err := NewTokenHandler(http.DefaultTransport, &cliconfig.AuthConfig{IdentityToken: "refresh"}, RepositoryScope("foo", "pull", "push"), RepositoryScope("bar", "pull")).AuthorizeRequest(req, params); err != nil {
+		t.Fatal(err)
+	}
+	assertEqual(t, req.Header.Get("Authorization"), "Bearer oauth repository:foo:pull,push repository:bar:pull", "Unexpected authorization")
+}
@@ -112,12 +112,18 @@ type IndexInfo struct {
 	Name string
 	// Mirrors is a list of mirrors, expressed as URIs
 	Mirrors []string
+	// AuthMirrors lists the mirrors, among Mirrors, which are sent the
+	// credentials of the registry.
+	AuthMirrors []string `json:",omitempty"`
 	// Secure is set to false if the registry is part of the list of
 	// insecure registries. Insecure registries accept HTTP and/or accept
 	// HTTPS with certificates from unknown CAs.
 	Secure bool
 	// Official indicates whether this is an official registry
 	Official bool
+	// RequireDigest is set if the images of the registry can only be
+	// pulled and run by digest.
+	RequireDigest bool
 }
 
 // RepositoryInfo describes a repository
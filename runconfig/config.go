@@ -8,6 +8,10 @@ import (
 	"github.com/docker/docker/pkg/stringutils"
 )
 
+// DefaultStopTimeout is the timeout (in seconds) to stop a container, before
+// it is killed, unless its config or the caller sets another one.
+const DefaultStopTimeout = 10
+
 // Config contains the configuration data about a container.
 // It should hold only portable information about the container.
 // Here, "portable" means "independent from the host we are running on".
@@ -35,6 +39,7 @@ type Config struct {
 	OnBuild         []string              // ONBUILD metadata that were defined on the image Dockerfile
 	Labels          map[string]string     // List of labels set to this container
 	StopSignal      string                // Signal to stop a container
+	StopTimeout     *int                  // Timeout (in seconds) to stop a container; DefaultStopTimeout if nil
 }
 
 // DecodeContainerConfig decodes a json encoded config into a ContainerConfigWrapper
@@ -56,6 +61,9 @@ func DecodeContainerConfig(src io.Reader) (*Config, *HostConfig, error) {
 	if err := ValidateNetMode(w.Config, hc); err != nil {
 		return nil, nil, err
 	}
+	if err := ValidateBlkioDevices(hc); err != nil {
+		return nil, nil, err
+	}
 
 	return w.Config, hc, nil
 }
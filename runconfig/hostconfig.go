@@ -4,7 +4,9 @@ import (
 	"encoding/json"
 	"io"
 	"strings"
+	"time"
 
+	"github.com/docker/docker/pkg/blkiodev"
 	"github.com/docker/docker/pkg/nat"
 	"github.com/docker/docker/pkg/stringutils"
 	"github.com/docker/docker/pkg/ulimit"
@@ -27,6 +29,14 @@ func (n IpcMode) IsPrivate() bool {
 	return !(n.IsHost() || n.IsContainer())
 }
 
+// IsShareable indicates whether other containers can join the container's
+// ipc stack. Only the private mode refuses it; containers without an explicit
+// ipc mode, or using the host's or another container's stack, are shareable
+// for backwards compatibility.
+func (n IpcMode) IsShareable() bool {
+	return n != "private"
+}
+
 // IsHost indicates whether the container uses the host's ipc stack.
 func (n IpcMode) IsHost() bool {
 	return n == "host"
@@ -42,7 +52,7 @@ func (n IpcMode) IsContainer() bool {
 func (n IpcMode) Valid() bool {
 	parts := strings.Split(string(n), ":")
 	switch mode := parts[0]; mode {
-	case "", "host":
+	case "", "host", "shareable", "private":
 	case "container":
 		if len(parts) != 2 || parts[1] == "" {
 			return false
@@ -121,8 +131,18 @@ type DeviceMapping struct {
 type RestartPolicy struct {
 	Name              string
 	MaximumRetryCount int
+	// MaximumDelay caps the delay between two restarts, which doubles each
+	// time the container exits too quickly. 0 means no limit.
+	MaximumDelay time.Duration
+	// ResetWindow is how long the container must run for the delay between
+	// restarts to be reset. 0 means DefaultRestartResetWindow.
+	ResetWindow time.Duration
 }
 
+// DefaultRestartResetWindow is how long a container must run for the delay
+// between its restarts to be reset, unless its restart policy says otherwise.
+const DefaultRestartResetWindow = 10 * time.Second
+
 // IsNone indicates whether the container has the "no" restart policy.
 // This means the container will not automatically restart when exiting.
 func (rp *RestartPolicy) IsNone() bool {
@@ -214,45 +234,56 @@ func NewLxcConfig(values []KeyValuePair) *LxcConfig {
 // Here, "non-portable" means "dependent of the host we are running on".
 // Portable information *should* appear in Config.
 type HostConfig struct {
-	Binds            []string              // List of volume bindings for this container
-	ContainerIDFile  string                // File (path) where the containerId is written
-	LxcConf          *LxcConfig            // Additional lxc configuration
-	Memory           int64                 // Memory limit (in bytes)
-	MemorySwap       int64                 // Total memory usage (memory + swap); set `-1` to disable swap
-	KernelMemory     int64                 // Kernel memory limit (in bytes)
-	CPUShares        int64                 `json:"CpuShares"` // CPU shares (relative weight vs. other containers)
-	CPUPeriod        int64                 `json:"CpuPeriod"` // CPU CFS (Completely Fair Scheduler) period
-	CpusetCpus       string                // CpusetCpus 0-2, 0,1
-	CpusetMems       string                // CpusetMems 0-2, 0,1
-	CPUQuota         int64                 `json:"CpuQuota"` // CPU CFS (Completely Fair Scheduler) quota
-	BlkioWeight      int64                 // Block IO weight (relative weight vs. other containers)
-	OomKillDisable   bool                  // Whether to disable OOM Killer or not
-	MemorySwappiness *int64                // Tuning container memory swappiness behaviour
-	Privileged       bool                  // Is the container in privileged mode
-	PortBindings     nat.PortMap           // Port mapping between the exposed port (container) and the host
-	Links            []string              // List of links (in the name:alias form)
-	PublishAllPorts  bool                  // Should docker publish all exposed port for the container
-	DNS              []string              `json:"Dns"`        // List of DNS server to lookup
-	DNSOptions       []string              `json:"DnsOptions"` // List of DNSOption to look for
-	DNSSearch        []string              `json:"DnsSearch"`  // List of DNSSearch to look for
-	ExtraHosts       []string              // List of extra hosts
-	VolumesFrom      []string              // List of volumes to take from other container
-	Devices          []DeviceMapping       // List of devices to map inside the container
-	NetworkMode      NetworkMode           // Network namespace to use for the container
-	IpcMode          IpcMode               // IPC namespace to use for the container
-	PidMode          PidMode               // PID namespace to use for the container
-	UTSMode          UTSMode               // UTS namespace to use for the container
-	CapAdd           *stringutils.StrSlice // List of kernel capabilities to add to the container
-	CapDrop          *stringutils.StrSlice // List of kernel capabilities to remove from the container
-	GroupAdd         []string              // List of additional groups that the container process will run as
-	RestartPolicy    RestartPolicy         // Restart policy to be used for the container
-	SecurityOpt      []string              // List of string values to customize labels for MLS systems, such as SELinux.
-	ReadonlyRootfs   bool                  // Is the container root filesystem in read-only
-	Ulimits          []*ulimit.Ulimit      // List of ulimits to be set in the container
-	LogConfig        LogConfig             // Configuration of the logs for this container
-	CgroupParent     string                // Parent cgroup.
-	ConsoleSize      [2]int                // Initial console size on Windows
-	VolumeDriver     string                // Name of the volume driver used to mount volumes
+	Binds                []string                   // List of volume bindings for this container
+	Tmpfs                map[string]string          // List of tmpfs mounts, by destination, with their mount options
+	ContainerIDFile      string                     // File (path) where the containerId is written
+	LxcConf              *LxcConfig                 // Additional lxc configuration
+	Memory               int64                      // Memory limit (in bytes)
+	MemoryReservation    int64                      // Memory soft limit (in bytes)
+	MemorySwap           int64                      // Total memory usage (memory + swap); set `-1` to disable swap
+	KernelMemory         int64                      // Kernel memory limit (in bytes)
+	CPUShares            int64                      `json:"CpuShares"` // CPU shares (relative weight vs. other containers)
+	CPUPeriod            int64                      `json:"CpuPeriod"` // CPU CFS (Completely Fair Scheduler) period
+	CpusetCpus           string                     // CpusetCpus 0-2, 0,1
+	CpusetMems           string                     // CpusetMems 0-2, 0,1
+	CPUQuota             int64                      `json:"CpuQuota"` // CPU CFS (Completely Fair Scheduler) quota
+	BlkioWeight          int64                      // Block IO weight (relative weight vs. other containers)
+	BlkioWeightDevice    []*blkiodev.WeightDevice   // Block IO weight (relative device weight)
+	BlkioDeviceReadBps   []*blkiodev.ThrottleDevice // Limit read rate (bytes per second) from a device
+	BlkioDeviceWriteBps  []*blkiodev.ThrottleDevice // Limit write rate (bytes per second) to a device
+	BlkioDeviceReadIOps  []*blkiodev.ThrottleDevice // Limit read rate (IO per second) from a device
+	BlkioDeviceWriteIOps []*blkiodev.ThrottleDevice // Limit write rate (IO per second) to a device
+	OomKillDisable       bool                       // Whether to disable OOM Killer or not
+	OomScoreAdj          int                        // Container preference for OOM-killing
+	MemorySwappiness     *int64                     // Tuning container memory swappiness behaviour
+	PidsLimit            int64                      // Setting pids limit for a container
+	Privileged           bool                       // Is the container in privileged mode
+	PortBindings         nat.PortMap                // Port mapping between the exposed port (container) and the host
+	Links                []string                   // List of links (in the name:alias form)
+	PublishAllPorts      bool                       // Should docker publish all exposed port for the container
+	DNS                  []string                   `json:"Dns"`        // List of DNS server to lookup
+	DNSOptions           []string                   `json:"DnsOptions"` // List of DNSOption to look for
+	DNSSearch            []string                   `json:"DnsSearch"`  // List of DNSSearch to look for
+	ExtraHosts           []string                   // List of extra hosts
+	VolumesFrom          []string                   // List of volumes to take from other container
+	Devices              []DeviceMapping            // List of devices to map inside the container
+	NetworkMode          NetworkMode                // Network namespace to use for the container
+	IpcMode              IpcMode                    // IPC namespace to use for the container
+	ShmSize              int64                      // Size of /dev/shm (in bytes); 64MB if 0
+	PidMode              PidMode                    // PID namespace to use for the container
+	UTSMode              UTSMode                    // UTS namespace to use for the container
+	CapAdd               *stringutils.StrSlice      // List of kernel capabilities to add to the container
+	CapDrop              *stringutils.StrSlice      // List of kernel capabilities to remove from the container
+	GroupAdd             []string                   // List of additional groups that the container process will run as
+	RestartPolicy        RestartPolicy              // Restart policy to be used for the container
+	SecurityOpt          []string                   // List of string values to customize labels for MLS systems, such as SELinux.
+	ReadonlyRootfs       bool                       // Is the container root filesystem in read-only
+	Ulimits              []*ulimit.Ulimit           // List of ulimits to be set in the container
+	LogConfig            LogConfig                  // Configuration of the logs for this container
+	CgroupParent         string                     // Parent cgroup.
+	ConsoleSize          [2]int                     // Initial console size on Windows
+	VolumeDriver         string                     // Name of the volume driver used to mount volumes
+	Init                 *bool                      // Run an init inside the container that forwards signals and reaps processes; the daemon default if nil
 }
 
 // DecodeHostConfig creates a HostConfig based on the specified Reader.
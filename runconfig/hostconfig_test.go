@@ -64,6 +64,8 @@ func TestIpcModeTest(t *testing.T) {
 		"something:weird":          {true, false, false, false},
 		":weird":                   {true, false, false, true},
 		"host":                     {false, true, false, true},
+		"shareable":                {true, false, false, true},
+		"private":                  {true, false, false, true},
 		"container:name":           {false, false, true, true},
 		"container:name:something": {false, false, true, false},
 		"container:":               {false, false, true, false},
@@ -82,6 +84,18 @@ func TestIpcModeTest(t *testing.T) {
 			t.Fatalf("IpcMode.Valid for %v should have been %v but was %v", ipcMode, state[3], ipcMode.Valid())
 		}
 	}
+	shareableIpcModes := map[IpcMode]bool{
+		"":               true,
+		"shareable":      true,
+		"private":        false,
+		"host":           true,
+		"container:name": true,
+	}
+	for ipcMode, shareable := range shareableIpcModes {
+		if ipcMode.IsShareable() != shareable {
+			t.Fatalf("IpcMode.IsShareable for %v should have been %v but was %v", ipcMode, shareable, ipcMode.IsShareable())
+		}
+	}
 	containerIpcModes := map[IpcMode]string{
 		"":                      "",
 		"something":             "",
@@ -143,11 +157,11 @@ func TestPidModeTest(t *testing.T) {
 func TestRestartPolicy(t *testing.T) {
 	restartPolicies := map[RestartPolicy][]bool{
 		// none, always, failure
-		RestartPolicy{}:                {false, false, false},
-		RestartPolicy{"something", 0}:  {false, false, false},
-		RestartPolicy{"no", 0}:         {true, false, false},
-		RestartPolicy{"always", 0}:     {false, true, false},
-		RestartPolicy{"on-failure", 0}: {false, false, true},
+		RestartPolicy{}:                   {false, false, false},
+		RestartPolicy{Name: "something"}:  {false, false, false},
+		RestartPolicy{Name: "no"}:         {true, false, false},
+		RestartPolicy{Name: "always"}:     {false, true, false},
+		RestartPolicy{Name: "on-failure"}: {false, false, true},
 	}
 	for restartPolicy, state := range restartPolicies {
 		if restartPolicy.IsNone() != state[0] {
@@ -59,6 +59,9 @@ func Merge(userConf, imageConf *Config) error {
 	if userConf.WorkingDir == "" {
 		userConf.WorkingDir = imageConf.WorkingDir
 	}
+	if userConf.StopTimeout == nil {
+		userConf.StopTimeout = imageConf.StopTimeout
+	}
 	if len(userConf.Volumes) == 0 {
 		userConf.Volumes = imageConf.Volumes
 	} else {
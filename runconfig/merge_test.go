@@ -81,3 +81,24 @@ func TestMerge(t *testing.T) {
 		}
 	}
 }
+
+func TestMergeStopTimeout(t *testing.T) {
+	imageTimeout, userTimeout := 30, 5
+	configImage := &Config{StopTimeout: &imageTimeout}
+
+	configUser := &Config{}
+	if err := Merge(configUser, configImage); err != nil {
+		t.Fatal(err)
+	}
+	if configUser.StopTimeout == nil || *configUser.StopTimeout != 30 {
+		t.Fatalf("Expected the stop timeout of the image, got %v", configUser.StopTimeout)
+	}
+
+	configUser = &Config{StopTimeout: &userTimeout}
+	if err := Merge(configUser, configImage); err != nil {
+		t.Fatal(err)
+	}
+	if *configUser.StopTimeout != 5 {
+		t.Fatalf("Expected the stop timeout of the user, got %d", *configUser.StopTimeout)
+	}
+}
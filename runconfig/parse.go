@@ -1,12 +1,17 @@
 package runconfig
 
 import (
+	"bytes"
+	"encoding/json"
 	"fmt"
+	"io/ioutil"
+	"path/filepath"
 	"strconv"
 	"strings"
 
 	"github.com/docker/docker/opts"
 	flag "github.com/docker/docker/pkg/mflag"
+	"github.com/docker/docker/pkg/mount"
 	"github.com/docker/docker/pkg/nat"
 	"github.com/docker/docker/pkg/parsers"
 	"github.com/docker/docker/pkg/signal"
@@ -48,6 +53,12 @@ func Parse(cmd *flag.FlagSet, args []string) (*Config, *HostConfig, *flag.FlagSe
 
 		flUlimits = opts.NewUlimitOpt(nil)
 
+		flBlkioWeightDevice = opts.NewWeightDeviceOpt()
+		flDeviceReadBps     = opts.NewThrottleDeviceOpt(true)
+		flDeviceWriteBps    = opts.NewThrottleDeviceOpt(true)
+		flDeviceReadIOps    = opts.NewThrottleDeviceOpt(false)
+		flDeviceWriteIOps   = opts.NewThrottleDeviceOpt(false)
+
 		flPublish     = opts.NewListOpts(nil)
 		flExpose      = opts.NewListOpts(nil)
 		flDNS         = opts.NewListOpts(opts.ValidateIPAddress)
@@ -55,6 +66,7 @@ func Parse(cmd *flag.FlagSet, args []string) (*Config, *HostConfig, *flag.FlagSe
 		flDNSOptions  = opts.NewListOpts(nil)
 		flExtraHosts  = opts.NewListOpts(opts.ValidateExtraHost)
 		flVolumesFrom = opts.NewListOpts(nil)
+		flTmpfs       = opts.NewListOpts(nil)
 		flLxcOpts     = opts.NewListOpts(nil)
 		flEnvFile     = opts.NewListOpts(nil)
 		flCapAdd      = opts.NewListOpts(nil)
@@ -72,10 +84,12 @@ func Parse(cmd *flag.FlagSet, args []string) (*Config, *HostConfig, *flag.FlagSe
 		flStdin           = cmd.Bool([]string{"i", "-interactive"}, false, "Keep STDIN open even if not attached")
 		flTty             = cmd.Bool([]string{"t", "-tty"}, false, "Allocate a pseudo-TTY")
 		flOomKillDisable  = cmd.Bool([]string{"-oom-kill-disable"}, false, "Disable OOM Killer")
+		flOomScoreAdj     = cmd.Int([]string{"-oom-score-adj"}, 0, "Tune host's OOM preferences (-1000 to 1000)")
 		flContainerIDFile = cmd.String([]string{"#cidfile", "-cidfile"}, "", "Write the container ID to the file")
 		flEntrypoint      = cmd.String([]string{"#entrypoint", "-entrypoint"}, "", "Overwrite the default ENTRYPOINT of the image")
 		flHostname        = cmd.String([]string{"h", "-hostname"}, "", "Container host name")
 		flMemoryString    = cmd.String([]string{"m", "-memory"}, "", "Memory limit")
+		flMemoryReserve   = cmd.String([]string{"-memory-reservation"}, "", "Memory soft limit")
 		flMemorySwap      = cmd.String([]string{"-memory-swap"}, "", "Total memory (memory + swap), '-1' to disable swap")
 		flKernelMemory    = cmd.String([]string{"-kernel-memory"}, "", "Kernel memory limit")
 		flUser            = cmd.String([]string{"u", "-user"}, "", "Username or UID (format: <name|uid>[:<group|gid>])")
@@ -87,19 +101,26 @@ func Parse(cmd *flag.FlagSet, args []string) (*Config, *HostConfig, *flag.FlagSe
 		flCpusetMems      = cmd.String([]string{"-cpuset-mems"}, "", "MEMs in which to allow execution (0-3, 0,1)")
 		flBlkioWeight     = cmd.Int64([]string{"-blkio-weight"}, 0, "Block IO (relative weight), between 10 and 1000")
 		flSwappiness      = cmd.Int64([]string{"-memory-swappiness"}, -1, "Tuning container memory swappiness (0 to 100)")
+		flPidsLimit       = cmd.Int64([]string{"-pids-limit"}, 0, "Tune container pids limit (set -1 for unlimited)")
 		flNetMode         = cmd.String([]string{"-net"}, "default", "Set the Network mode for the container")
 		flMacAddress      = cmd.String([]string{"-mac-address"}, "", "Container MAC address (e.g. 92:d0:c6:0a:29:33)")
 		flIpcMode         = cmd.String([]string{"-ipc"}, "", "IPC namespace to use")
+		flShmSize         = cmd.String([]string{"-shm-size"}, "", "Size of /dev/shm, default value is 64MB")
 		flRestartPolicy   = cmd.String([]string{"-restart"}, "no", "Restart policy to apply when a container exits")
+		flRestartMaxDelay = cmd.Duration([]string{"-restart-max-delay"}, 0, "Maximum delay between restarts (0 for no limit)")
+		flRestartReset    = cmd.Duration([]string{"-restart-reset-window"}, DefaultRestartResetWindow, "Time a container must run for the delay between restarts to be reset")
 		flReadonlyRootfs  = cmd.Bool([]string{"-read-only"}, false, "Mount the container's root filesystem as read only")
+		flInit            = cmd.Bool([]string{"-init"}, false, "Run an init inside the container that forwards signals and reaps processes")
 		flLoggingDriver   = cmd.String([]string{"-log-driver"}, "", "Logging driver for container")
 		flCgroupParent    = cmd.String([]string{"-cgroup-parent"}, "", "Optional parent cgroup for the container")
 		flVolumeDriver    = cmd.String([]string{"-volume-driver"}, "", "Optional volume driver for the container")
 		flStopSignal      = cmd.String([]string{"-stop-signal"}, signal.DefaultStopSignal, fmt.Sprintf("Signal to stop a container, %v by default", signal.DefaultStopSignal))
+		flStopTimeout     = cmd.Int([]string{"-stop-timeout"}, DefaultStopTimeout, fmt.Sprintf("Timeout (in seconds) to stop a container, %d by default", DefaultStopTimeout))
 	)
 
 	cmd.Var(&flAttach, []string{"a", "-attach"}, "Attach to STDIN, STDOUT or STDERR")
 	cmd.Var(&flVolumes, []string{"v", "-volume"}, "Bind mount a volume")
+	cmd.Var(&flTmpfs, []string{"-tmpfs"}, "Mount a tmpfs directory")
 	cmd.Var(&flLinks, []string{"#link", "-link"}, "Add link to another container")
 	cmd.Var(&flDevices, []string{"-device"}, "Add a host device to the container")
 	cmd.Var(&flLabels, []string{"l", "-label"}, "Set meta data on a container")
@@ -119,6 +140,11 @@ func Parse(cmd *flag.FlagSet, args []string) (*Config, *HostConfig, *flag.FlagSe
 	cmd.Var(&flGroupAdd, []string{"-group-add"}, "Add additional groups to join")
 	cmd.Var(&flSecurityOpt, []string{"-security-opt"}, "Security Options")
 	cmd.Var(flUlimits, []string{"-ulimit"}, "Ulimit options")
+	cmd.Var(flBlkioWeightDevice, []string{"-blkio-weight-device"}, "Block IO weight (relative device weight)")
+	cmd.Var(flDeviceReadBps, []string{"-device-read-bps"}, "Limit read rate (bytes per second) from a device")
+	cmd.Var(flDeviceWriteBps, []string{"-device-write-bps"}, "Limit write rate (bytes per second) to a device")
+	cmd.Var(flDeviceReadIOps, []string{"-device-read-iops"}, "Limit read rate (IO per second) from a device")
+	cmd.Var(flDeviceWriteIOps, []string{"-device-write-iops"}, "Limit write rate (IO per second) to a device")
 	cmd.Var(&flLoggingOpts, []string{"-log-opt"}, "Log driver options")
 
 	expFlags := attachExperimentalFlags(cmd)
@@ -160,6 +186,14 @@ func Parse(cmd *flag.FlagSet, args []string) (*Config, *HostConfig, *flag.FlagSe
 		}
 	}
 
+	var memoryReservation int64
+	if *flMemoryReserve != "" {
+		memoryReservation, err = units.RAMInBytes(*flMemoryReserve)
+		if err != nil {
+			return nil, nil, cmd, err
+		}
+	}
+
 	var memorySwap int64
 	if *flMemorySwap != "" {
 		if *flMemorySwap == "-1" {
@@ -180,6 +214,17 @@ func Parse(cmd *flag.FlagSet, args []string) (*Config, *HostConfig, *flag.FlagSe
 		}
 	}
 
+	var shmSize int64
+	if *flShmSize != "" {
+		shmSize, err = units.RAMInBytes(*flShmSize)
+		if err != nil {
+			return nil, nil, cmd, err
+		}
+		if shmSize <= 0 {
+			return nil, nil, cmd, fmt.Errorf("--shm-size: SHM size must be greater than 0")
+		}
+	}
+
 	swappiness := *flSwappiness
 	if swappiness != -1 && (swappiness < 0 || swappiness > 100) {
 		return nil, nil, cmd, fmt.Errorf("Invalid value: %d. Valid memory swappiness range is 0-100", swappiness)
@@ -201,6 +246,15 @@ func Parse(cmd *flag.FlagSet, args []string) (*Config, *HostConfig, *flag.FlagSe
 		}
 	}
 
+	tmpfs := make(map[string]string)
+	for _, t := range flTmpfs.GetAll() {
+		destination, options, err := ParseTmpfs(t)
+		if err != nil {
+			return nil, nil, cmd, err
+		}
+		tmpfs[destination] = options
+	}
+
 	var (
 		parsedArgs = cmd.Args()
 		runCmd     *stringutils.StrSlice
@@ -296,10 +350,19 @@ func Parse(cmd *flag.FlagSet, args []string) (*Config, *HostConfig, *flag.FlagSe
 		return nil, nil, cmd, fmt.Errorf("--uts: invalid UTS mode")
 	}
 
+	securityOpts, err := parseSecurityOpts(flSecurityOpt.GetAll())
+	if err != nil {
+		return nil, nil, cmd, err
+	}
+
 	restartPolicy, err := ParseRestartPolicy(*flRestartPolicy)
 	if err != nil {
 		return nil, nil, cmd, err
 	}
+	restartPolicy.MaximumDelay = *flRestartMaxDelay
+	if cmd.IsSet("-restart-reset-window") {
+		restartPolicy.ResetWindow = *flRestartReset
+	}
 
 	loggingOpts, err := parseLoggingOpts(*flLoggingDriver, flLoggingOpts.GetAll())
 	if err != nil {
@@ -328,45 +391,65 @@ func Parse(cmd *flag.FlagSet, args []string) (*Config, *HostConfig, *flag.FlagSe
 		StopSignal:      *flStopSignal,
 	}
 
+	// Leave the image the choice of the stop timeout unless it was given.
+	if cmd.IsSet("-stop-timeout") {
+		config.StopTimeout = flStopTimeout
+	}
+
 	hostConfig := &HostConfig{
-		Binds:            binds,
-		ContainerIDFile:  *flContainerIDFile,
-		LxcConf:          lxcConf,
-		Memory:           flMemory,
-		MemorySwap:       memorySwap,
-		KernelMemory:     KernelMemory,
-		CPUShares:        *flCPUShares,
-		CPUPeriod:        *flCPUPeriod,
-		CpusetCpus:       *flCpusetCpus,
-		CpusetMems:       *flCpusetMems,
-		CPUQuota:         *flCPUQuota,
-		BlkioWeight:      *flBlkioWeight,
-		OomKillDisable:   *flOomKillDisable,
-		MemorySwappiness: flSwappiness,
-		Privileged:       *flPrivileged,
-		PortBindings:     portBindings,
-		Links:            flLinks.GetAll(),
-		PublishAllPorts:  *flPublishAll,
-		DNS:              flDNS.GetAll(),
-		DNSSearch:        flDNSSearch.GetAll(),
-		DNSOptions:       flDNSOptions.GetAll(),
-		ExtraHosts:       flExtraHosts.GetAll(),
-		VolumesFrom:      flVolumesFrom.GetAll(),
-		NetworkMode:      NetworkMode(*flNetMode),
-		IpcMode:          ipcMode,
-		PidMode:          pidMode,
-		UTSMode:          utsMode,
-		Devices:          deviceMappings,
-		CapAdd:           stringutils.NewStrSlice(flCapAdd.GetAll()...),
-		CapDrop:          stringutils.NewStrSlice(flCapDrop.GetAll()...),
-		GroupAdd:         flGroupAdd.GetAll(),
-		RestartPolicy:    restartPolicy,
-		SecurityOpt:      flSecurityOpt.GetAll(),
-		ReadonlyRootfs:   *flReadonlyRootfs,
-		Ulimits:          flUlimits.GetList(),
-		LogConfig:        LogConfig{Type: *flLoggingDriver, Config: loggingOpts},
-		CgroupParent:     *flCgroupParent,
-		VolumeDriver:     *flVolumeDriver,
+		Binds:                binds,
+		Tmpfs:                tmpfs,
+		ContainerIDFile:      *flContainerIDFile,
+		LxcConf:              lxcConf,
+		Memory:               flMemory,
+		MemoryReservation:    memoryReservation,
+		MemorySwap:           memorySwap,
+		KernelMemory:         KernelMemory,
+		CPUShares:            *flCPUShares,
+		CPUPeriod:            *flCPUPeriod,
+		CpusetCpus:           *flCpusetCpus,
+		CpusetMems:           *flCpusetMems,
+		CPUQuota:             *flCPUQuota,
+		BlkioWeight:          *flBlkioWeight,
+		BlkioWeightDevice:    flBlkioWeightDevice.GetList(),
+		BlkioDeviceReadBps:   flDeviceReadBps.GetList(),
+		BlkioDeviceWriteBps:  flDeviceWriteBps.GetList(),
+		BlkioDeviceReadIOps:  flDeviceReadIOps.GetList(),
+		BlkioDeviceWriteIOps: flDeviceWriteIOps.GetList(),
+		OomKillDisable:       *flOomKillDisable,
+		OomScoreAdj:          *flOomScoreAdj,
+		MemorySwappiness:     flSwappiness,
+		PidsLimit:            *flPidsLimit,
+		Privileged:           *flPrivileged,
+		PortBindings:         portBindings,
+		Links:                flLinks.GetAll(),
+		PublishAllPorts:      *flPublishAll,
+		DNS:                  flDNS.GetAll(),
+		DNSSearch:            flDNSSearch.GetAll(),
+		DNSOptions:           flDNSOptions.GetAll(),
+		ExtraHosts:           flExtraHosts.GetAll(),
+		VolumesFrom:          flVolumesFrom.GetAll(),
+		NetworkMode:          NetworkMode(*flNetMode),
+		IpcMode:              ipcMode,
+		ShmSize:              shmSize,
+		PidMode:              pidMode,
+		UTSMode:              utsMode,
+		Devices:              deviceMappings,
+		CapAdd:               stringutils.NewStrSlice(flCapAdd.GetAll()...),
+		CapDrop:              stringutils.NewStrSlice(flCapDrop.GetAll()...),
+		GroupAdd:             flGroupAdd.GetAll(),
+		RestartPolicy:        restartPolicy,
+		SecurityOpt:          securityOpts,
+		ReadonlyRootfs:       *flReadonlyRootfs,
+		Ulimits:              flUlimits.GetList(),
+		LogConfig:            LogConfig{Type: *flLoggingDriver, Config: loggingOpts},
+		CgroupParent:         *flCgroupParent,
+		VolumeDriver:         *flVolumeDriver,
+	}
+
+	// Leave the choice to the daemon default unless --init was given.
+	if cmd.IsSet("-init") {
+		hostConfig.Init = flInit
 	}
 
 	applyExperimentalFlags(expFlags, config, hostConfig)
@@ -409,6 +492,62 @@ func ConvertKVStringsToMap(values []string) map[string]string {
 	return result
 }
 
+// ParseTmpfs parses a tmpfs mount specification in the form
+// /destination[:options] into its destination and its mount options.
+func ParseTmpfs(spec string) (string, string, error) {
+	arr := strings.SplitN(spec, ":", 2)
+	destination := arr[0]
+	if !filepath.IsAbs(destination) {
+		return "", "", fmt.Errorf("Invalid tmpfs %q: destination must be an absolute path", spec)
+	}
+	if filepath.Clean(destination) == "/" {
+		return "", "", fmt.Errorf("Invalid tmpfs %q: destination can't be '/'", spec)
+	}
+	var options string
+	if len(arr) == 2 {
+		options = arr[1]
+		if _, _, err := mount.ParseTmpfsOptions(options); err != nil {
+			return "", "", err
+		}
+	}
+	return filepath.Clean(destination), options, nil
+}
+
+// parseSecurityOpts replaces the path of the seccomp profiles of the
+// security options with their content, as the daemon can't read the files
+// of the client.
+func parseSecurityOpts(securityOpts []string) ([]string, error) {
+	for i, opt := range securityOpts {
+		key, value, ok := SplitSecurityOpt(opt)
+		if !ok {
+			return nil, fmt.Errorf("Invalid --security-opt: %q", opt)
+		}
+		if key != "seccomp" || value == "unconfined" {
+			continue
+		}
+		data, err := ioutil.ReadFile(value)
+		if err != nil {
+			return nil, fmt.Errorf("Opening seccomp profile (%s) failed: %v", value, err)
+		}
+		b := bytes.NewBuffer(nil)
+		if err := json.Compact(b, data); err != nil {
+			return nil, fmt.Errorf("Compacting json for seccomp profile (%s) failed: %v", value, err)
+		}
+		securityOpts[i] = "seccomp:" + b.String()
+	}
+	return securityOpts, nil
+}
+
+// SplitSecurityOpt splits a security option into its key and value,
+// separated by either ':' or '='.
+func SplitSecurityOpt(opt string) (string, string, bool) {
+	i := strings.IndexAny(opt, ":=")
+	if i < 0 {
+		return "", "", false
+	}
+	return opt[:i], opt[i+1:], true
+}
+
 func parseLoggingOpts(loggingDriver string, loggingOpts []string) (map[string]string, error) {
 	loggingOptsMap := ConvertKVStringsToMap(loggingOpts)
 	if loggingDriver == "none" && len(loggingOpts) > 0 {
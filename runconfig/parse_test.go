@@ -3,8 +3,10 @@ package runconfig
 import (
 	"fmt"
 	"io/ioutil"
+	"os"
 	"strings"
 	"testing"
+	"time"
 
 	flag "github.com/docker/docker/pkg/mflag"
 	"github.com/docker/docker/pkg/nat"
@@ -422,6 +424,31 @@ func TestParseRestartPolicy(t *testing.T) {
 	}
 }
 
+func TestParseRestartBackoff(t *testing.T) {
+	_, hostconfig, _, err := parseRun([]string{"--restart=always", "img", "cmd"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if hostconfig.RestartPolicy.MaximumDelay != 0 || hostconfig.RestartPolicy.ResetWindow != 0 {
+		t.Fatalf("Expected no backoff settings, got %v", hostconfig.RestartPolicy)
+	}
+
+	_, hostconfig, _, err = parseRun([]string{"--restart=always", "--restart-max-delay=1m", "--restart-reset-window=30s", "img", "cmd"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if hostconfig.RestartPolicy.MaximumDelay != time.Minute {
+		t.Fatalf("Expected a maximum delay of %v, got %v", time.Minute, hostconfig.RestartPolicy.MaximumDelay)
+	}
+	if hostconfig.RestartPolicy.ResetWindow != 30*time.Second {
+		t.Fatalf("Expected a reset window of %v, got %v", 30*time.Second, hostconfig.RestartPolicy.ResetWindow)
+	}
+
+	if _, _, _, err := parseRun([]string{"--restart-max-delay=invalid", "img", "cmd"}); err == nil {
+		t.Fatalf("Expected an error for an invalid maximum delay")
+	}
+}
+
 func TestParseLoggingOpts(t *testing.T) {
 	// logging opts ko
 	if _, _, _, err := parseRun([]string{"--log-driver=none", "--log-opt=anything", "img", "cmd"}); err == nil || err.Error() != "Invalid logging opts for driver none" {
@@ -490,3 +517,162 @@ func TestParseEntryPoint(t *testing.T) {
 		t.Fatalf("Expected entrypoint 'anything', got %v", config.Entrypoint)
 	}
 }
+
+func TestParseSecurityOpts(t *testing.T) {
+	profile, err := ioutil.TempFile("", "seccomp-profile")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(profile.Name())
+	profile.WriteString("{\n  \"defaultAction\": \"SCMP_ACT_ALLOW\"\n}\n")
+	profile.Close()
+
+	_, hostConfig, err := parse(t, "--security-opt seccomp="+profile.Name()+" --security-opt apparmor:unconfined")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []string{`seccomp:{"defaultAction":"SCMP_ACT_ALLOW"}`, "apparmor:unconfined"}
+	if len(hostConfig.SecurityOpt) != 2 || hostConfig.SecurityOpt[0] != expected[0] || hostConfig.SecurityOpt[1] != expected[1] {
+		t.Fatalf("Expected the security options %v, got %v", expected, hostConfig.SecurityOpt)
+	}
+
+	if _, hostConfig, err = parse(t, "--security-opt seccomp:unconfined"); err != nil {
+		t.Fatal(err)
+	}
+	if len(hostConfig.SecurityOpt) != 1 || hostConfig.SecurityOpt[0] != "seccomp:unconfined" {
+		t.Fatalf("Expected the unconfined seccomp option to be kept, got %v", hostConfig.SecurityOpt)
+	}
+
+	for _, opts := range []string{"--security-opt seccomp=/nonexistent", "--security-opt label"} {
+		if _, _, err := parse(t, opts); err == nil {
+			t.Fatalf("Expected an error parsing %q", opts)
+		}
+	}
+}
+
+func TestParseTmpfs(t *testing.T) {
+	_, hostConfig, err := parse(t, "--tmpfs /run --tmpfs /tmp/:size=64m,mode=1777")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(hostConfig.Tmpfs) != 2 || hostConfig.Tmpfs["/run"] != "" || hostConfig.Tmpfs["/tmp"] != "size=64m,mode=1777" {
+		t.Fatalf("Unexpected tmpfs mounts %v", hostConfig.Tmpfs)
+	}
+
+	for _, opts := range []string{"--tmpfs run", "--tmpfs /", "--tmpfs /run:foo=bar"} {
+		if _, _, err := parse(t, opts); err == nil {
+			t.Fatalf("Expected an error parsing %q", opts)
+		}
+	}
+}
+
+func TestParseShmSize(t *testing.T) {
+	_, hostConfig, err := parse(t, "--shm-size 128m --ipc shareable")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if hostConfig.ShmSize != 128*1024*1024 || hostConfig.IpcMode != "shareable" {
+		t.Fatalf("Expected a shareable IPC with a 128MB /dev/shm, got %d and %q", hostConfig.ShmSize, hostConfig.IpcMode)
+	}
+
+	for _, opts := range []string{"--shm-size 0", "--shm-size foo", "--ipc public"} {
+		if _, _, err := parse(t, opts); err == nil {
+			t.Fatalf("Expected an error parsing %q", opts)
+		}
+	}
+}
+
+func TestParsePidsLimit(t *testing.T) {
+	_, hostConfig, err := parse(t, "--pids-limit 100")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if hostConfig.PidsLimit != 100 {
+		t.Fatalf("Expected a pids limit of 100, got %d", hostConfig.PidsLimit)
+	}
+	if _, hostConfig, err = parse(t, ""); err != nil {
+		t.Fatal(err)
+	}
+	if hostConfig.PidsLimit != 0 {
+		t.Fatalf("Expected no pids limit by default, got %d", hostConfig.PidsLimit)
+	}
+}
+
+func TestParseMemoryReservationAndOomScoreAdj(t *testing.T) {
+	_, hostConfig, err := parse(t, "--memory-reservation 64m --oom-score-adj 500")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if hostConfig.MemoryReservation != 64*1024*1024 {
+		t.Fatalf("Expected a memory reservation of 64m, got %d", hostConfig.MemoryReservation)
+	}
+	if hostConfig.OomScoreAdj != 500 {
+		t.Fatalf("Expected an oom score adjustment of 500, got %d", hostConfig.OomScoreAdj)
+	}
+	if _, _, err := parse(t, "--memory-reservation invalid"); err == nil {
+		t.Fatalf("Expected an error with an invalid memory reservation")
+	}
+}
+
+func TestParseStopTimeout(t *testing.T) {
+	config, _, err := parse(t, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if config.StopTimeout != nil {
+		t.Fatalf("Expected no stop timeout by default, got %d", *config.StopTimeout)
+	}
+	if config, _, err = parse(t, "--stop-timeout 30"); err != nil {
+		t.Fatal(err)
+	}
+	if config.StopTimeout == nil || *config.StopTimeout != 30 {
+		t.Fatalf("Expected a stop timeout of 30, got %v", config.StopTimeout)
+	}
+}
+
+func TestParseInit(t *testing.T) {
+	_, hostConfig, err := parse(t, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if hostConfig.Init != nil {
+		t.Fatalf("Expected the daemon default init, got %v", *hostConfig.Init)
+	}
+	for args, expected := range map[string]bool{"--init": true, "--init=false": false} {
+		_, hostConfig, err := parse(t, args)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if hostConfig.Init == nil || *hostConfig.Init != expected {
+			t.Fatalf("Expected init to be %v with %q, got %v", expected, args, hostConfig.Init)
+		}
+	}
+}
+
+func TestParseBlkioDevices(t *testing.T) {
+	_, hostConfig, err := parse(t, "--blkio-weight-device /dev/sda:300 --device-read-bps /dev/sda:10mb --device-write-bps /dev/sdb:1024 --device-read-iops /dev/sda:1000 --device-write-iops /dev/sda:500")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(hostConfig.BlkioWeightDevice) != 1 || hostConfig.BlkioWeightDevice[0].Path != "/dev/sda" || hostConfig.BlkioWeightDevice[0].Weight != 300 {
+		t.Fatalf("Unexpected weight devices %v", hostConfig.BlkioWeightDevice)
+	}
+	if len(hostConfig.BlkioDeviceReadBps) != 1 || hostConfig.BlkioDeviceReadBps[0].Rate != 10*1024*1024 {
+		t.Fatalf("Unexpected read bps devices %v", hostConfig.BlkioDeviceReadBps)
+	}
+	if len(hostConfig.BlkioDeviceWriteBps) != 1 || hostConfig.BlkioDeviceWriteBps[0].Path != "/dev/sdb" {
+		t.Fatalf("Unexpected write bps devices %v", hostConfig.BlkioDeviceWriteBps)
+	}
+	if len(hostConfig.BlkioDeviceReadIOps) != 1 || hostConfig.BlkioDeviceReadIOps[0].Rate != 1000 {
+		t.Fatalf("Unexpected read iops devices %v", hostConfig.BlkioDeviceReadIOps)
+	}
+	if len(hostConfig.BlkioDeviceWriteIOps) != 1 || hostConfig.BlkioDeviceWriteIOps[0].Rate != 500 {
+		t.Fatalf("Unexpected write iops devices %v", hostConfig.BlkioDeviceWriteIOps)
+	}
+
+	for _, opts := range []string{"--device-read-bps sda:10mb", "--device-write-iops /dev/sda:1kb", "--blkio-weight-device /dev/sda:5"} {
+		if _, _, err := parse(t, opts); err == nil {
+			t.Fatalf("Expected an error parsing %q", opts)
+		}
+	}
+}
@@ -5,6 +5,9 @@ package runconfig
 import (
 	"fmt"
 	"strings"
+	"syscall"
+
+	"github.com/docker/docker/pkg/blkiodev"
 )
 
 // ValidateNetMode ensures that the various combinations of requested
@@ -58,3 +61,33 @@ func ValidateNetMode(c *Config, hc *HostConfig) error {
 	}
 	return nil
 }
+
+// ValidateBlkioDevices ensures that the devices of the per-device block IO
+// settings are block devices under /dev.
+func ValidateBlkioDevices(hc *HostConfig) error {
+	if hc == nil {
+		return nil
+	}
+	var paths []string
+	for _, d := range hc.BlkioWeightDevice {
+		paths = append(paths, d.Path)
+	}
+	for _, devices := range [][]*blkiodev.ThrottleDevice{hc.BlkioDeviceReadBps, hc.BlkioDeviceWriteBps, hc.BlkioDeviceReadIOps, hc.BlkioDeviceWriteIOps} {
+		for _, d := range devices {
+			paths = append(paths, d.Path)
+		}
+	}
+	for _, path := range paths {
+		if !strings.HasPrefix(path, "/dev/") {
+			return fmt.Errorf("Invalid device %s: not under /dev", path)
+		}
+		var stat syscall.Stat_t
+		if err := syscall.Stat(path, &stat); err != nil {
+			return fmt.Errorf("Invalid device %s: %v", path, err)
+		}
+		if stat.Mode&syscall.S_IFMT != syscall.S_IFBLK {
+			return fmt.Errorf("Invalid device %s: not a block device", path)
+		}
+	}
+	return nil
+}
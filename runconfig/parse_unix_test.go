@@ -0,0 +1,29 @@
+// +build !windows
+
+package runconfig
+
+import (
+	"testing"
+
+	"github.com/docker/docker/pkg/blkiodev"
+)
+
+func TestValidateBlkioDevices(t *testing.T) {
+	if err := ValidateBlkioDevices(nil); err != nil {
+		t.Fatal(err)
+	}
+	if err := ValidateBlkioDevices(&HostConfig{}); err != nil {
+		t.Fatal(err)
+	}
+
+	invalid := []*HostConfig{
+		{BlkioWeightDevice: []*blkiodev.WeightDevice{{Path: "/dev/null", Weight: 300}}},
+		{BlkioDeviceReadBps: []*blkiodev.ThrottleDevice{{Path: "/dev/does-not-exist", Rate: 1024}}},
+		{BlkioDeviceWriteIOps: []*blkiodev.ThrottleDevice{{Path: "/tmp", Rate: 1024}}},
+	}
+	for _, hc := range invalid {
+		if err := ValidateBlkioDevices(hc); err == nil {
+			t.Fatalf("Expected an error validating %v", hc)
+		}
+	}
+}
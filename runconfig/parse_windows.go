@@ -20,3 +20,9 @@ func ValidateNetMode(c *Config, hc *HostConfig) error {
 	}
 	return nil
 }
+
+// ValidateBlkioDevices ensures that the devices of the per-device block IO
+// settings are valid. There are no block devices to check on Windows.
+func ValidateBlkioDevices(hc *HostConfig) error {
+	return nil
+}